@@ -8,9 +8,12 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/adimarco/hive/config"
 	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/tools"
 )
 
 // AgentType represents different types of agents
@@ -19,14 +22,17 @@ type AgentType string
 const (
 	// AgentTypeBasic represents a simple agent that processes messages
 	AgentTypeBasic AgentType = "agent"
-	// AgentTypeOrchestrator coordinates multiple agents
+	// AgentTypeOrchestrator coordinates multiple agents; see NewOrchestrator
 	AgentTypeOrchestrator AgentType = "orchestrator"
-	// AgentTypeRouter routes messages to appropriate agents
+	// AgentTypeRouter routes messages to appropriate agents; see NewRouter
 	AgentTypeRouter AgentType = "router"
-	// AgentTypeChain chains multiple agents in sequence
+	// AgentTypeChain chains multiple agents in sequence; see NewChain
 	AgentTypeChain AgentType = "chain"
-	// AgentTypeParallel runs multiple agents in parallel
+	// AgentTypeParallel runs multiple agents in parallel; see NewParallel
 	AgentTypeParallel AgentType = "parallel"
+	// AgentTypeEvaluatorOptimizer refines answers until an evaluator accepts
+	// them; see NewEvaluatorOptimizer
+	AgentTypeEvaluatorOptimizer AgentType = "evaluator_optimizer"
 )
 
 // Agent represents a configured agent instance
@@ -37,9 +43,18 @@ type Agent struct {
 	model       string
 	useHistory  bool
 	humanInput  bool
+	approval    *tools.ApprovalPolicy // Approval for sensitive tool calls
 	params      *llm.RequestParams
 	llm         llm.AugmentedLLM
-	output      io.Writer // For configurable output
+	output      io.Writer             // For configurable output
+	usage       *llm.UsageTracker     // Running token and cost totals
+	memory      llm.Memory            // Conversation history of the default session
+	memoryOpts  config.MemorySettings // Memory strategy of sessions started by RunSession
+	summarizer  llm.AugmentedLLM      // LLM summarizing for the summarizing strategy
+	workflow    workflow              // Runs other agents in place of the LLM, for workflow agents
+
+	sessionsMu sync.Mutex
+	sessions   map[string]llm.Memory // Conversation history of named sessions
 }
 
 // New creates a new Agent with basic configuration
@@ -49,10 +64,13 @@ func New(name, instruction string) *Agent {
 		instruction: instruction,
 		agentType:   AgentTypeBasic,
 		output:      os.Stdout,
+		usage:       llm.NewUsageTracker(name),
+		memory:      llm.NewSimpleMemory(),
 	}
 }
 
-// WithModel sets the model for the agent
+// WithModel sets the model for the agent. Accepts the same aliases and
+// <provider>.<model>.<reasoning_effort?> strings as the default_model setting.
 func (a *Agent) WithModel(model string) *Agent {
 	a.model = model
 	return a
@@ -64,9 +82,21 @@ func (a *Agent) WithHistory() *Agent {
 	return a
 }
 
-// WithHumanInput enables human input requests
+// WithHumanInput enables human input requests: tool calls that need
+// approval, those of tools tagged tools.TagRequiresApproval, are confirmed
+// at the terminal unless WithApproval sets another policy
 func (a *Agent) WithHumanInput() *Agent {
 	a.humanInput = true
+	if a.approval == nil {
+		a.approval = tools.NewApprovalPolicy(tools.NewTerminalApprover(os.Stdin, os.Stderr))
+	}
+	return a
+}
+
+// WithApproval sets the policy deciding which tool calls need approval and
+// who gives it. Denied calls are reported to the model as tool errors.
+func (a *Agent) WithApproval(policy *tools.ApprovalPolicy) *Agent {
+	a.approval = policy
 	return a
 }
 
@@ -76,7 +106,10 @@ func (a *Agent) WithParams(params *llm.RequestParams) *Agent {
 	return a
 }
 
-// WithTools adds MCP tools to the agent
+// WithTools adds registered tools, including those from MCP servers, to the
+// agent. Each entry is a tool name such as "github.create_issue", a
+// namespace such as "github" for all of its tools, or a glob pattern such
+// as "github.*_issue".
 func (a *Agent) WithTools(tools ...string) *Agent {
 	if a.params == nil {
 		a.params = &llm.RequestParams{}
@@ -88,6 +121,16 @@ func (a *Agent) WithTools(tools ...string) *Agent {
 	return a
 }
 
+// WithoutTools keeps tools matching any of the given names, namespaces or
+// glob patterns from the agent, even if WithTools added them
+func (a *Agent) WithoutTools(tools ...string) *Agent {
+	if a.params == nil {
+		a.params = &llm.RequestParams{}
+	}
+	a.params.ExcludeTools = append(a.params.ExcludeTools, tools...)
+	return a
+}
+
 // WithConfig adds additional configuration to the agent
 func (a *Agent) WithConfig(cfg map[string]any) *Agent {
 	if a.params == nil {
@@ -102,6 +145,52 @@ func (a *Agent) WithConfig(cfg map[string]any) *Agent {
 	return a
 }
 
+// WithMemory sets the memory holding the agent's conversation history.
+// Agents given the same Memory share one conversation.
+func (a *Agent) WithMemory(memory llm.Memory) *Agent {
+	a.memory = memory
+	return a
+}
+
+// WithMemorySettings makes the agent's conversations use the memory
+// strategy in settings: the default session's memory is replaced, and
+// sessions started by RunSession get memory of the same kind. The
+// summarizing strategy summarizes with summarizer.
+func (a *Agent) WithMemorySettings(settings config.MemorySettings, summarizer llm.AugmentedLLM) error {
+	memory, err := llm.NewMemory(settings, summarizer)
+	if err != nil {
+		return fmt.Errorf("invalid memory settings for agent %q: %w", a.name, err)
+	}
+	a.memory = memory
+	a.memoryOpts = settings
+	a.summarizer = summarizer
+	return nil
+}
+
+// Memory returns the conversation history of the agent's default session
+func (a *Agent) Memory() llm.Memory {
+	return a.memory
+}
+
+// sessionMemory returns the memory of a named session, creating it on first use
+func (a *Agent) sessionMemory(sessionID string) (llm.Memory, error) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	if a.sessions == nil {
+		a.sessions = make(map[string]llm.Memory)
+	}
+	memory, ok := a.sessions[sessionID]
+	if !ok {
+		var err error
+		if memory, err = llm.NewMemory(a.memoryOpts, a.summarizer); err != nil {
+			return nil, fmt.Errorf("failed to create memory for session %q: %w", sessionID, err)
+		}
+		a.sessions[sessionID] = memory
+	}
+	return memory, nil
+}
+
 // WithLLM sets the LLM for the agent
 func (a *Agent) WithLLM(llm llm.AugmentedLLM) *Agent {
 	a.llm = llm
@@ -114,6 +203,18 @@ func (a *Agent) WithType(agentType AgentType) *Agent {
 	return a
 }
 
+// Usage returns the tokens and cost spent by this agent so far
+func (a *Agent) Usage() llm.Usage {
+	return a.usage.Total()
+}
+
+// recordUsage adds the usage reported on a response to the agent's totals
+func (a *Agent) recordUsage(ctx context.Context, response llm.Message) {
+	if response.Usage != nil {
+		a.usage.Record(ctx, *response.Usage)
+	}
+}
+
 // SetOutput configures where the agent writes output
 func (a *Agent) SetOutput(w io.Writer) {
 	a.output = w
@@ -129,16 +230,52 @@ func (a *Agent) Run(ctx context.Context) (*RunningAgent, error) {
 		return nil, fmt.Errorf("agent instruction is required")
 	}
 
+	return a.runWith(ctx, a.llm)
+}
+
+// runWith starts an agent session sending requests to model
+func (a *Agent) runWith(ctx context.Context, model llm.AugmentedLLM) (*RunningAgent, error) {
+	// Resolve aliases and <provider>.<model>.<effort> strings up front so a
+	// model the agent's LLM cannot serve is reported before any request
+	var spec llm.ModelSpec
+	if a.model != "" && model != nil {
+		resolved, err := llm.ResolveModelFor(model.Provider(), a.model)
+		if err != nil {
+			return nil, fmt.Errorf("invalid model for agent %q: %w", a.name, err)
+		}
+		spec = resolved
+	}
+
 	return &RunningAgent{
-		agent: a,
-		ctx:   ctx,
+		agent:  a,
+		ctx:    ctx,
+		llm:    model,
+		model:  spec,
+		memory: a.memory,
 	}, nil
 }
 
+// RunSession starts an agent session with its own conversation history.
+// Running the same sessionID again resumes that conversation, while other
+// sessions and the default session started by Run are unaffected.
+func (a *Agent) RunSession(ctx context.Context, sessionID string) (*RunningAgent, error) {
+	ra, err := a.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ra.memory, err = a.sessionMemory(sessionID); err != nil {
+		return nil, err
+	}
+	return ra, nil
+}
+
 // RunningAgent represents an active agent session
 type RunningAgent struct {
-	agent *Agent
-	ctx   context.Context
+	agent  *Agent
+	ctx    context.Context
+	llm    llm.AugmentedLLM
+	model  llm.ModelSpec
+	memory llm.Memory
 }
 
 // Send sends a single message to the agent and returns the response
@@ -150,6 +287,10 @@ func (ra *RunningAgent) Send(msg string) (string, error) {
 	default:
 	}
 
+	if ra.agent.workflow != nil {
+		return ra.agent.workflow.send(ra.ctx, ra.llm, msg)
+	}
+
 	// Ensure we have params properly initialized
 	params := ra.buildRequestParams()
 
@@ -159,10 +300,11 @@ func (ra *RunningAgent) Send(msg string) (string, error) {
 	}
 
 	// Generate response using the LLM
-	response, err := ra.agent.llm.Generate(ra.ctx, message, params)
+	response, err := ra.llm.Generate(ra.ctx, message, params)
 	if err != nil {
 		return "", fmt.Errorf("failed to get LLM completion: %w", err)
 	}
+	ra.agent.recordUsage(ra.ctx, response)
 
 	// Check for and execute any tool calls
 	if len(response.ToolCalls) > 0 {
@@ -177,13 +319,88 @@ func (ra *RunningAgent) Send(msg string) (string, error) {
 	return response.Content, nil
 }
 
+// Stream sends a single message to the agent and returns the response as a
+// stream of events. The stream ends with a final message or error event.
+// Read it until it is closed, or cancel the session's context to stop the
+// response early.
+func (ra *RunningAgent) Stream(msg string) (<-chan llm.StreamEvent, error) {
+	// Check context cancellation
+	select {
+	case <-ra.ctx.Done():
+		return nil, ra.ctx.Err()
+	default:
+	}
+
+	if ra.agent.workflow != nil {
+		return ra.streamWorkflow(msg), nil
+	}
+
+	message := llm.Message{
+		Type:    llm.MessageTypeUser,
+		Content: msg,
+	}
+
+	ctx, cancel := context.WithCancel(ra.ctx)
+	events, err := ra.llm.GenerateStream(ctx, message, ra.buildRequestParams())
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start LLM stream: %w", err)
+	}
+
+	// Forward events, recording usage when the final message arrives. If
+	// the context ends first, the LLM stream is cancelled and drained so
+	// neither side is left blocked.
+	out := make(chan llm.StreamEvent)
+	go func() {
+		defer close(out)
+		defer cancel()
+		for event := range events {
+			if event.Type == llm.StreamEventMessage {
+				ra.agent.recordUsage(ctx, *event.Message)
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				cancel()
+				for range events {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// streamWorkflow runs a workflow agent, delivering its answer as a single
+// final message event, or an error event if it fails
+func (ra *RunningAgent) streamWorkflow(msg string) <-chan llm.StreamEvent {
+	out := make(chan llm.StreamEvent, 1)
+	go func() {
+		defer close(out)
+		content, err := ra.agent.workflow.send(ra.ctx, ra.llm, msg)
+		if err != nil {
+			out <- llm.StreamEvent{Type: llm.StreamEventError, Err: err}
+			return
+		}
+		out <- llm.StreamEvent{Type: llm.StreamEventMessage, Message: &llm.Message{
+			Type:    llm.MessageTypeAssistant,
+			Content: content,
+			Name:    ra.agent.name,
+		}}
+	}()
+	return out
+}
+
 // buildRequestParams creates a properly initialized RequestParams
 // using the agent's configuration
 func (ra *RunningAgent) buildRequestParams() *llm.RequestParams {
 	params := &llm.RequestParams{
-		SystemPrompt: ra.agent.instruction,
-		Model:        ra.agent.model,
-		UseHistory:   ra.agent.useHistory,
+		SystemPrompt:    ra.agent.instruction,
+		Model:           ra.model.Model,
+		ReasoningEffort: ra.model.ReasoningEffort,
+		UseHistory:      ra.agent.useHistory,
+		Memory:          ra.memory,
+		Approval:        ra.agent.approval,
 	}
 
 	// Copy existing params if available
@@ -192,6 +409,10 @@ func (ra *RunningAgent) buildRequestParams() *llm.RequestParams {
 			params.Tools = make([]string, len(ra.agent.params.Tools))
 			copy(params.Tools, ra.agent.params.Tools)
 		}
+		if ra.agent.params.ExcludeTools != nil {
+			params.ExcludeTools = make([]string, len(ra.agent.params.ExcludeTools))
+			copy(params.ExcludeTools, ra.agent.params.ExcludeTools)
+		}
 
 		if ra.agent.params.Config != nil {
 			params.Config = make(map[string]any)
@@ -205,15 +426,23 @@ func (ra *RunningAgent) buildRequestParams() *llm.RequestParams {
 			params.MaxTokens = ra.agent.params.MaxTokens
 		}
 
-		if ra.agent.params.Temperature > 0 {
-			params.Temperature = ra.agent.params.Temperature
+		if ra.agent.params.Temperature != nil {
+			temperature := *ra.agent.params.Temperature
+			params.Temperature = &temperature
 		}
 
 		if ra.agent.params.MaxIterations > 0 {
 			params.MaxIterations = ra.agent.params.MaxIterations
 		}
 
-		params.ParallelTools = ra.agent.params.ParallelTools
+		if ra.agent.params.MaxParallelTools > 0 {
+			params.MaxParallelTools = ra.agent.params.MaxParallelTools
+		}
+
+		if ra.agent.params.ParallelTools != nil {
+			parallel := *ra.agent.params.ParallelTools
+			params.ParallelTools = &parallel
+		}
 	}
 
 	return params
@@ -244,13 +473,43 @@ func (ra *RunningAgent) Chat() error {
 			return nil
 		}
 
-		response, err := ra.Send(input)
-		if err != nil {
+		fmt.Fprint(ra.agent.output, "Assistant: ")
+		if err := ra.streamTo(ra.agent.output, input); err != nil {
 			return fmt.Errorf("failed to get response: %w", err)
 		}
+	}
+}
 
-		fmt.Fprintf(ra.agent.output, "Assistant: %s\n", response)
+// streamTo sends a message and writes the response to w as it is generated
+func (ra *RunningAgent) streamTo(w io.Writer, msg string) error {
+	events, err := ra.Stream(msg)
+	if err != nil {
+		return err
+	}
+
+	for event := range events {
+		switch event.Type {
+		case llm.StreamEventTextDelta:
+			fmt.Fprint(w, event.Text)
+		case llm.StreamEventToolUse:
+			fmt.Fprintf(w, "[using tool %s]\n", event.ToolCall.Name)
+		case llm.StreamEventToolProgress:
+			// Chunks of output are left for the model; only progress is shown
+			if event.Partial.Progress > 0 {
+				status := fmt.Sprintf("[%s %.0f%%]", event.ToolCall.Name, event.Partial.Progress*100)
+				if event.Partial.Content != "" {
+					status += " " + event.Partial.Content
+				}
+				fmt.Fprintln(w, status)
+			}
+		case llm.StreamEventMessage:
+			fmt.Fprintln(w)
+		case llm.StreamEventError:
+			fmt.Fprintln(w)
+			return event.Err
+		}
 	}
+	return nil
 }
 
 // NewDefaultAgent creates a new agent with sensible defaults
@@ -258,9 +517,9 @@ func NewDefaultAgent(instruction string) *Agent {
 	// Generate a unique name if not provided
 	name := fmt.Sprintf("agent-%d", time.Now().UnixNano())
 
-	// Create agent with sensible defaults
+	// Create agent with sensible defaults; the model is left to the LLM's
+	// configured default so agents work with any provider
 	return New(name, instruction).
-		WithModel("claude-3-haiku-20240307").
 		WithHistory()
 }
 
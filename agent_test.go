@@ -0,0 +1,295 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/tools"
+)
+
+func TestRunningAgent_Stream(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("renders deltas", func(t *testing.T) {
+		agent := New("echo", "Echo the input").WithLLM(llm.NewPassthroughLLM("echo").WithTransform(strings.ToUpper))
+		ra, err := agent.Run(ctx)
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		require.NoError(t, ra.streamTo(&out, "hello"))
+		assert.Equal(t, "HELLO\n", out.String())
+	})
+
+	t.Run("collects final message", func(t *testing.T) {
+		agent := New("echo", "Echo the input").WithLLM(llm.NewPassthroughLLM("echo"))
+		ra, err := agent.Run(ctx)
+		require.NoError(t, err)
+
+		events, err := ra.Stream("hello")
+		require.NoError(t, err)
+		msg, err := llm.CollectStream(events)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", msg.Content)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		agent := New("echo", "Echo the input").WithLLM(llm.NewPassthroughLLM("echo"))
+		ra, err := agent.Run(cancelled)
+		require.NoError(t, err)
+
+		_, err = ra.Stream("hello")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("cancelling stops the llm stream", func(t *testing.T) {
+		model := &endlessLLM{PassthroughLLM: llm.NewPassthroughLLM("endless"), stopped: make(chan struct{})}
+		running, cancel := context.WithCancel(ctx)
+		ra, err := New("talker", "Keep talking").WithLLM(model).Run(running)
+		require.NoError(t, err)
+
+		events, err := ra.Stream("hello")
+		require.NoError(t, err)
+		<-events
+
+		// The consumer stops reading and cancels
+		cancel()
+		select {
+		case <-model.stopped:
+		case <-time.After(time.Second):
+			t.Fatal("llm stream was not drained after cancelling")
+		}
+
+		// At most the event in flight is delivered before the stream closes
+		for delivered := 0; ; delivered++ {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					return
+				}
+				require.Zero(t, delivered, "events delivered after cancelling")
+			case <-time.After(time.Second):
+				t.Fatal("stream was not closed")
+			}
+		}
+	})
+}
+
+// endlessLLM streams text deltas until its context ends, then sends a last
+// one and closes stopped once it has been read. A stream left blocked
+// delivering events nobody reads never reads it.
+type endlessLLM struct {
+	*llm.PassthroughLLM
+	stopped chan struct{}
+}
+
+func (l *endlessLLM) GenerateStream(ctx context.Context, msg llm.Message, params *llm.RequestParams) (<-chan llm.StreamEvent, error) {
+	events := make(chan llm.StreamEvent)
+	go func() {
+		defer close(l.stopped)
+		defer close(events)
+		for {
+			select {
+			case events <- llm.StreamEvent{Type: llm.StreamEventTextDelta, Text: "more "}:
+			case <-ctx.Done():
+				events <- llm.StreamEvent{Type: llm.StreamEventTextDelta, Text: "done"}
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func TestAgent_ParallelTools(t *testing.T) {
+	calls := make([]llm.ToolCall, 3)
+	for i := range calls {
+		calls[i] = llm.ToolCall{ID: fmt.Sprintf("call-%d", i), Name: "fetch", Args: map[string]any{"input": fmt.Sprint(i)}}
+	}
+	playback := llm.NewPlaybackLLM("playback", []llm.Message{
+		{Type: llm.MessageTypeAssistant, ToolCalls: calls},
+		{Type: llm.MessageTypeAssistant, Content: "fetched"},
+	})
+
+	// Each call waits for all of them to start, so they only finish if
+	// they overlap
+	var mu sync.Mutex
+	started, timedOut := 0, 0
+	all := make(chan struct{})
+	require.NoError(t, tools.RegisterFunctionTool(playback.Tools(), "fetch", "Fetch a page", func(page string) string {
+		mu.Lock()
+		if started++; started == len(calls) {
+			close(all)
+		}
+		mu.Unlock()
+		select {
+		case <-all:
+			return "page " + page
+		case <-time.After(time.Second):
+			mu.Lock()
+			timedOut++
+			mu.Unlock()
+			return "timed out"
+		}
+	}))
+
+	agent := New("fetcher", "Fetch pages").WithTools("fetch").WithLLM(playback)
+	agent.SetOutput(io.Discard)
+	ra, err := agent.Run(context.Background())
+	require.NoError(t, err)
+	_, err = ra.Send("fetch them")
+	require.NoError(t, err)
+
+	assert.Equal(t, len(calls), started)
+	assert.Zero(t, timedOut, "tool calls did not overlap")
+}
+
+func TestAgent_RunSession(t *testing.T) {
+	ctx := context.Background()
+	echo := llm.NewPassthroughLLM("echo")
+	agent := New("echo", "Echo the input").WithLLM(echo).WithHistory()
+
+	send := func(ra *RunningAgent, msg string) {
+		_, err := ra.Send(msg)
+		require.NoError(t, err)
+	}
+	count := func(memory llm.Memory) int {
+		messages, err := memory.Get(true)
+		require.NoError(t, err)
+		return len(messages)
+	}
+
+	def, err := agent.Run(ctx)
+	require.NoError(t, err)
+	send(def, "default")
+
+	alice, err := agent.RunSession(ctx, "alice")
+	require.NoError(t, err)
+	send(alice, "hi from alice")
+
+	// Resuming a session continues its conversation
+	resumed, err := agent.RunSession(ctx, "alice")
+	require.NoError(t, err)
+	send(resumed, "alice again")
+
+	bob, err := agent.RunSession(ctx, "bob")
+	require.NoError(t, err)
+	send(bob, "hi from bob")
+
+	assert.Equal(t, 2, count(agent.Memory()))
+	assert.Equal(t, 4, count(resumed.memory))
+	assert.Equal(t, 2, count(bob.memory))
+	assert.Zero(t, count(echo.Memory()))
+}
+
+func TestAgent_MemorySettings(t *testing.T) {
+	ctx := context.Background()
+	echo := llm.NewPassthroughLLM("echo")
+	agent := New("echo", "Echo the input").WithLLM(echo).WithHistory()
+
+	err := agent.WithMemorySettings(config.MemorySettings{Strategy: "keep_first_last", KeepLast: 1}, echo)
+	require.NoError(t, err)
+	assert.IsType(t, &llm.KeepFirstLastMemory{}, agent.Memory())
+
+	// Sessions use the strategy too
+	session, err := agent.RunSession(ctx, "alice")
+	require.NoError(t, err)
+	for _, msg := range []string{"one", "two", "three"} {
+		_, err := session.Send(msg)
+		require.NoError(t, err)
+	}
+	messages, err := session.memory.Get(true)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "three", messages[0].Content)
+
+	err = agent.WithMemorySettings(config.MemorySettings{Strategy: "summarizing", MaxTokens: 100}, nil)
+	assert.ErrorContains(t, err, `invalid memory settings for agent "echo": summarizing memory requires an LLM`)
+}
+
+func TestChannelAgent_Streaming(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	agent := New("echo", "Echo the input").WithLLM(llm.NewPassthroughLLM("echo"))
+	ca := NewChannelAgent(agent).WithStreaming()
+	require.NoError(t, ca.Start(ctx))
+	defer ca.Close()
+
+	require.NoError(t, ca.Send("hello"))
+
+	select {
+	case event := <-ca.Events():
+		assert.Equal(t, llm.StreamEventTextDelta, event.Type)
+		assert.Equal(t, "hello", event.Text)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for stream event")
+	}
+
+	select {
+	case resp := <-ca.Output():
+		assert.Equal(t, "hello", resp)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for response")
+	}
+}
+
+func TestChannelAgent_Approvals(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	passthrough := llm.NewPassthroughLLM("tools")
+	require.NoError(t, tools.RegisterFunctionTool(passthrough.Tools(), "readFile", "Read a file", func(path string) string {
+		return "contents of " + path
+	}))
+	agent := New("reader", "Read files").WithLLM(passthrough).WithTools("readFile")
+	agent.SetOutput(io.Discard)
+	ca := NewChannelAgent(agent).WithApprovals("read*")
+	require.NoError(t, ca.Start(ctx))
+	defer ca.Close()
+
+	// answer waits for the next approval request and answers it
+	answer := func(approve bool) {
+		select {
+		case pending := <-ca.Approvals():
+			assert.Equal(t, "readFile", pending.Tool.Name)
+			assert.Equal(t, map[string]any{"input": "notes.txt"}, pending.Args)
+			if approve {
+				pending.Approve()
+			} else {
+				pending.Deny("private file")
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for approval request")
+		}
+	}
+	response := func() string {
+		select {
+		case resp := <-ca.Output():
+			return resp
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for response")
+			return ""
+		}
+	}
+
+	require.NoError(t, ca.Send(`***CALL_TOOL readFile {"input":"notes.txt"}`))
+	answer(true)
+	assert.Equal(t, "contents of notes.txt", response())
+
+	require.NoError(t, ca.Send(`***CALL_TOOL readFile {"input":"notes.txt"}`))
+	answer(false)
+	assert.Equal(t, `tool "readFile" was not approved: private file`, response())
+}
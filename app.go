@@ -1,38 +1,120 @@
 package hive
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/adimarco/hive/config"
 	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/mcp"
 	"github.com/adimarco/hive/tools"
 )
 
 // App provides a high-level interface for creating and managing agents and tools
 type App struct {
+	name   string
 	llm    llm.AugmentedLLM
 	agents map[string]*Agent
+	usage  *llm.UsageTracker
+	memory config.MemorySettings // Memory strategy for new agents
+	mcp    *mcp.Manager          // Configured MCP servers
 }
 
-// NewApp creates a new App instance with default configuration
-func NewApp(name string) *App {
-	llm, err := NewAnthropicLLM(name)
+// NewApp creates a new App instance with default configuration.
+// Options select the model and provider, e.g. NewApp("demo", WithModel("sonnet")).
+func NewApp(name string, opts ...LLMOption) *App {
+	app, err := NewAppFromSettings(name, newSettings(defaultModel, opts))
 	if err != nil {
-		// For now, we'll panic on initialization errors
-		// In the future, we can return error and let caller handle it
-		panic(fmt.Sprintf("failed to create LLM: %v", err))
+		// For now, we'll panic on initialization errors; callers that
+		// want to handle them can use NewAppFromSettings
+		panic(err.Error())
+	}
+	return app
+}
+
+// NewAppFromSettings creates an App from loaded configuration. It starts
+// the configured MCP servers and creates the agents defined in settings.
+func NewAppFromSettings(name string, settings *config.Settings) (*App, error) {
+	if err := settings.Memory.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid memory settings: %w", err)
+	}
+	if settings.DefaultModel == "" {
+		withDefault := *settings
+		withDefault.DefaultModel = defaultModel
+		settings = &withDefault
+	}
+
+	model, err := llm.DefaultProviders().CreateLLM(context.Background(), name, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM: %w", err)
 	}
 
 	app := &App{
-		llm:    llm,
+		name:   name,
+		llm:    model,
 		agents: make(map[string]*Agent),
+		usage:  llm.NewUsageTracker(name),
+		memory: settings.Memory,
 	}
 
-	return app
+	// Launch the configured MCP servers and register their tools so every
+	// agent can use them
+	if len(settings.MCP.Servers) > 0 {
+		app.mcp = mcp.NewManager(settings.MCP)
+		if err := app.mcp.Start(context.Background(), model.Tools()); err != nil {
+			_ = model.Cleanup()
+			return nil, fmt.Errorf("failed to start MCP servers: %w", err)
+		}
+	}
+
+	names := make([]string, 0, len(settings.Agents))
+	for agentName := range settings.Agents {
+		names = append(names, agentName)
+	}
+	sort.Strings(names)
+	for _, agentName := range names {
+		agentSettings := settings.Agents[agentName]
+		if err := agentSettings.Validate(); err != nil {
+			_ = app.Close()
+			return nil, fmt.Errorf("invalid agent %q: %w", agentName, err)
+		}
+		agent := New(agentName, agentSettings.Instruction).WithModel(agentSettings.Model)
+		if agentSettings.History {
+			agent.WithHistory()
+		}
+		app.addAgent(agent)
+	}
+
+	return app, nil
 }
 
 // Agent creates a new agent with the given instruction
 func (a *App) Agent(instruction string) *Agent {
-	agent := NewDefaultAgent(instruction).WithLLM(a.llm)
+	agent := NewDefaultAgent(instruction)
+	a.addAgent(agent)
+	return agent
+}
+
+// GetAgent returns an agent created by the app, such as one defined in
+// configuration
+func (a *App) GetAgent(name string) (*Agent, bool) {
+	agent, ok := a.agents[name]
+	return agent, ok
+}
+
+// addAgent connects an agent to the app's LLM, usage tracking, memory
+// strategy and tools, and records it
+func (a *App) addAgent(agent *Agent) {
+	agent.WithLLM(a.llm)
+	agent.usage.WithParent(a.usage)
+
+	// Each agent gets its own memory using the app's strategy; the
+	// settings were validated by NewAppFromSettings
+	if a.memory.Strategy != "" {
+		_ = agent.WithMemorySettings(a.memory, a.llm)
+	}
 
 	// Add all available tools automatically
 	tools := a.llm.Tools().List()
@@ -41,8 +123,8 @@ func (a *App) Agent(instruction string) *Agent {
 	addedTools := make(map[string]bool)
 
 	for _, tool := range tools {
-		// Skip tools we've already added
-		if addedTools[tool.Name] {
+		// Skip tools we've already added, and workflows' own tools
+		if addedTools[tool.Name] || tool.IsInternal() {
 			continue
 		}
 
@@ -51,20 +133,48 @@ func (a *App) Agent(instruction string) *Agent {
 	}
 
 	a.agents[agent.name] = agent
-	return agent
 }
 
-// Tool creates and registers a new tool with a simple function handler
+// Tool creates and registers a new tool from a function. Handlers taking a
+// struct of arguments, like func(ctx, Args) (Result, error), get an input
+// schema generated from the struct; see tools.NewFunctionTool.
 func (a *App) Tool(name string, handler interface{}) error {
-	// Use the new RegisterFunctionTool helper
 	description := fmt.Sprintf("Tool '%s' provided by the application", name)
 	return tools.RegisterFunctionTool(a.llm.Tools(), name, description, handler)
 }
 
-// Close cleans up app resources
+// ToolProvider registers a provider's tools under namespace, so a tool
+// "search" of provider "docs" is called "docs.search". The provider is
+// cleaned up when the app is closed.
+func (a *App) ToolProvider(namespace string, provider tools.ToolProvider) error {
+	return a.llm.Tools().RegisterProvider(namespace, provider)
+}
+
+// MCPServer creates an MCP server publishing the app's tools and the given
+// agents
+func (a *App) MCPServer(agents ...*Agent) (*mcp.Server, error) {
+	return NewMCPServer(a.name, a.llm.Tools(), agents...)
+}
+
+// Usage returns the tokens and cost spent by all of the app's agents
+func (a *App) Usage() llm.Usage {
+	return a.usage.Total()
+}
+
+// UsageByModel returns the app's usage broken down by model
+func (a *App) UsageByModel() map[string]llm.Usage {
+	return a.usage.ByModel()
+}
+
+// Close shuts down the app's MCP servers, cleans up its tools and tool
+// providers, and cleans up app resources
 func (a *App) Close() error {
+	var errs []error
+	if a.mcp != nil {
+		errs = append(errs, a.mcp.Close())
+	}
 	if a.llm != nil {
-		return a.llm.Cleanup()
+		errs = append(errs, a.llm.Cleanup())
 	}
-	return nil
+	return errors.Join(errs...)
 }
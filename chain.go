@@ -0,0 +1,141 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/adimarco/hive/llm"
+)
+
+// Chain is a workflow agent running agents in sequence, each working on
+// the previous one's output. It runs and sends like any other Agent, so
+// chains can be members of teams, steps of other chains, or served as tools.
+type Chain struct {
+	*Agent
+	steps      []chainStep
+	cumulative bool
+}
+
+// chainStep is an agent in a chain and the template of its prompt
+type chainStep struct {
+	agent  *Agent
+	prompt string
+}
+
+// StepResult is what one agent of a workflow was sent and answered
+type StepResult struct {
+	Agent  string
+	Input  string
+	Output string
+}
+
+// ChainResult is the outcome of a chain: the last agent's answer and every
+// step that ran
+type ChainResult struct {
+	Output string
+	Steps  []StepResult
+}
+
+// ChainPromptData is passed to step prompt templates
+type ChainPromptData struct {
+	Input    string       // The message sent to the chain
+	Previous string       // The previous step's output, or Input for the first step
+	Steps    []StepResult // The steps run so far
+}
+
+// NewChain creates a chain running agents in the given order
+func NewChain(name string, agents ...*Agent) *Chain {
+	c := &Chain{
+		Agent: New(name, "Runs a chain of agents in sequence, each working on the previous one's output"),
+	}
+	c.agentType = AgentTypeChain
+	c.workflow = c
+	for _, agent := range agents {
+		c.WithStep(agent, "")
+	}
+	return c
+}
+
+// WithStep adds an agent to the end of the chain. A non-empty prompt is a
+// text/template executed with ChainPromptData to build the agent's message,
+// e.g. "Translate to French:\n{{.Previous}}".
+func (c *Chain) WithStep(agent *Agent, prompt string) *Chain {
+	c.steps = append(c.steps, chainStep{agent: agent, prompt: prompt})
+	return c
+}
+
+// WithCumulative sends each agent the original message and every earlier
+// output instead of only the previous output. Steps with a prompt template
+// are unaffected.
+func (c *Chain) WithCumulative() *Chain {
+	c.cumulative = true
+	return c
+}
+
+// Execute runs the chain on msg, stopping at the first failing step. The
+// result holds the steps completed so far even when an error is returned.
+func (c *Chain) Execute(ctx context.Context, msg string) (ChainResult, error) {
+	return c.execute(ctx, c.llm, msg)
+}
+
+// send implements workflow
+func (c *Chain) send(ctx context.Context, model llm.AugmentedLLM, msg string) (string, error) {
+	result, err := c.execute(ctx, model, msg)
+	return result.Output, err
+}
+
+// execute runs the chain, using model for agents without an LLM of their own
+func (c *Chain) execute(ctx context.Context, model llm.AugmentedLLM, msg string) (ChainResult, error) {
+	var result ChainResult
+	if len(c.steps) == 0 {
+		return result, fmt.Errorf("chain %q has no steps", c.name)
+	}
+
+	previous := msg
+	for i, step := range c.steps {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		input, err := c.stepInput(step, ChainPromptData{Input: msg, Previous: previous, Steps: result.Steps})
+		if err != nil {
+			return result, fmt.Errorf("chain %q: step %d (%s): %w", c.name, i+1, step.agent.name, err)
+		}
+		output, err := sendTo(ctx, step.agent, model, input)
+		if err != nil {
+			return result, fmt.Errorf("chain %q: step %d (%s) failed: %w", c.name, i+1, step.agent.name, err)
+		}
+
+		result.Steps = append(result.Steps, StepResult{Agent: step.agent.name, Input: input, Output: output})
+		result.Output = output
+		previous = output
+	}
+	return result, nil
+}
+
+// stepInput builds the message sent to a step
+func (c *Chain) stepInput(step chainStep, data ChainPromptData) (string, error) {
+	if step.prompt != "" {
+		tmpl, err := template.New(step.agent.name).Parse(step.prompt)
+		if err != nil {
+			return "", fmt.Errorf("invalid prompt template: %w", err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("failed to render prompt: %w", err)
+		}
+		return b.String(), nil
+	}
+
+	if !c.cumulative || len(data.Steps) == 0 {
+		return data.Previous, nil
+	}
+	var b strings.Builder
+	writeRequest(&b, data.Input)
+	for _, step := range data.Steps {
+		writeResponse(&b, step.Agent, step.Output, nil)
+	}
+	return b.String(), nil
+}
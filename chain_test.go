@@ -0,0 +1,112 @@
+package hive
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/llm"
+)
+
+// transformAgent returns an agent answering with transform applied to its
+// message
+func transformAgent(name string, transform func(string) string) *Agent {
+	return New(name, "Transform the input").WithLLM(llm.NewPassthroughLLM(name).WithTransform(transform))
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	upper := transformAgent("upper", strings.ToUpper)
+	exclaim := transformAgent("exclaim", func(s string) string { return s + "!" })
+
+	t.Run("feeds each output into the next agent", func(t *testing.T) {
+		chain := NewChain("shout", upper, exclaim)
+		assert.Equal(t, AgentTypeChain, chain.agentType)
+
+		result, err := chain.Execute(ctx, "hello")
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO!", result.Output)
+		assert.Equal(t, []StepResult{
+			{Agent: "upper", Input: "hello", Output: "HELLO"},
+			{Agent: "exclaim", Input: "HELLO", Output: "HELLO!"},
+		}, result.Steps)
+	})
+
+	t.Run("prompt templates", func(t *testing.T) {
+		chain := NewChain("shout", upper).
+			WithStep(exclaim, "{{.Input}} became {{.Previous}} after {{len .Steps}} step")
+
+		result, err := chain.Execute(ctx, "hello")
+		require.NoError(t, err)
+		assert.Equal(t, "hello became HELLO after 1 step!", result.Output)
+
+		_, err = NewChain("broken").WithStep(exclaim, "{{.Missing").Execute(ctx, "hello")
+		assert.ErrorContains(t, err, `chain "broken": step 1 (exclaim): invalid prompt template`)
+	})
+
+	t.Run("cumulative", func(t *testing.T) {
+		echo := transformAgent("echo", func(s string) string { return s })
+		result, err := NewChain("notes", upper, exclaim, echo).WithCumulative().Execute(ctx, "hi")
+		require.NoError(t, err)
+		assert.Equal(t, `<request>
+hi
+</request>
+
+<response agent="upper">
+HI
+</response>
+
+<response agent="exclaim">
+<request>
+hi
+</request>
+
+<response agent="upper">
+HI
+</response>!
+</response>`, result.Output)
+	})
+
+	t.Run("stops on error", func(t *testing.T) {
+		unconfigured := New("unconfigured", "Has no LLM")
+		result, err := NewChain("broken", upper, unconfigured, exclaim).Execute(ctx, "hello")
+		assert.EqualError(t, err, `chain "broken": step 2 (unconfigured) failed: agent "unconfigured" has no LLM`)
+		assert.Equal(t, "HELLO", result.Output)
+		assert.Len(t, result.Steps, 1)
+
+		_, err = NewChain("empty").Execute(ctx, "hello")
+		assert.EqualError(t, err, `chain "empty" has no steps`)
+	})
+
+	t.Run("runs like an agent", func(t *testing.T) {
+		ra, err := NewChain("shout", upper, exclaim).Run(ctx)
+		require.NoError(t, err)
+
+		reply, err := ra.Send("hello")
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO!", reply)
+
+		events, err := ra.Stream("hi")
+		require.NoError(t, err)
+		msg, err := llm.CollectStream(events)
+		require.NoError(t, err)
+		assert.Equal(t, "HI!", msg.Content)
+		assert.Equal(t, "shout", msg.Name)
+	})
+
+	t.Run("nested in chains and teams", func(t *testing.T) {
+		// Members without an LLM use the team's
+		plain := New("plain", "Repeat the input")
+		inner := NewChain("inner", upper, plain)
+		outer := NewChain("outer", inner.Agent, exclaim)
+		team := TeamWithLLM("team", llm.NewPassthroughLLM("team"), outer.Agent)
+		defer team.Close()
+
+		reply, err := team.Send("outer", "hello")
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO!", reply)
+	})
+}
@@ -5,18 +5,24 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/tools"
 )
 
 // ChannelAgent extends Agent with channel-based message handling
 type ChannelAgent struct {
-	*Agent                  // Embed base Agent
-	input     chan string   // Channel for incoming messages
-	output    chan string   // Channel for responses
-	done      chan struct{} // Channel for shutdown signaling
-	errors    chan error    // Channel for error reporting
-	closeOnce sync.Once     // Ensure cleanup happens once
-	closed    bool          // Track closed state
-	mu        sync.RWMutex  // Protect closed state
+	*Agent                           // Embed base Agent
+	input     chan string            // Channel for incoming messages
+	output    chan string            // Channel for responses
+	done      chan struct{}          // Channel for shutdown signaling
+	errors    chan error             // Channel for error reporting
+	events    chan llm.StreamEvent   // Channel for streaming events
+	streaming bool                   // Whether responses are generated with streaming
+	approver  *tools.ChannelApprover // Publishes tool calls awaiting approval
+	closeOnce sync.Once              // Ensure cleanup happens once
+	closed    bool                   // Track closed state
+	mu        sync.RWMutex           // Protect closed state
 }
 
 // NewChannelAgent creates a new ChannelAgent with the given configuration
@@ -27,9 +33,28 @@ func NewChannelAgent(agent *Agent) *ChannelAgent {
 		output: make(chan string, 100), // Increased buffer for high concurrency
 		done:   make(chan struct{}),    // Unbuffered for clean shutdown
 		errors: make(chan error, 100),  // Increased buffer for high concurrency
+		events: make(chan llm.StreamEvent, 100),
 	}
 }
 
+// WithStreaming generates responses with streaming and publishes text
+// deltas, tool activity and usage on the Events() channel. Complete
+// responses are still delivered on Output(). Must be called before Start.
+func (ca *ChannelAgent) WithStreaming() *ChannelAgent {
+	ca.streaming = true
+	return ca
+}
+
+// WithApprovals publishes tool calls needing approval on the Approvals()
+// channel and waits for each to be approved or denied. Tools tagged
+// tools.TagRequiresApproval need approval, as do tools whose names match
+// patterns. Must be called before Start.
+func (ca *ChannelAgent) WithApprovals(patterns ...string) *ChannelAgent {
+	ca.approver = tools.NewChannelApprover()
+	ca.Agent.WithApproval(tools.NewApprovalPolicy(ca.approver).WithTools(patterns...))
+	return ca
+}
+
 // Start begins processing messages in a separate goroutine
 func (ca *ChannelAgent) Start(ctx context.Context) error {
 	// Create running agent
@@ -87,7 +112,13 @@ func (ca *ChannelAgent) processMessages(ctx context.Context, ra *RunningAgent) {
 				default:
 				}
 
-				response, err := ra.Send(message)
+				var response string
+				var err error
+				if ca.streaming {
+					response, err = ca.streamMessage(ctx, ra, message)
+				} else {
+					response, err = ra.Send(message)
+				}
 				if err != nil {
 					// Construct a meaningful error message
 					errMsg := fmt.Errorf("failed to process message: %w", err)
@@ -138,6 +169,36 @@ func (ca *ChannelAgent) processMessages(ctx context.Context, ra *RunningAgent) {
 	}
 }
 
+// streamMessage generates a response with streaming, forwarding intermediate
+// events to the events channel, and returns the final content
+func (ca *ChannelAgent) streamMessage(ctx context.Context, ra *RunningAgent, message string) (string, error) {
+	events, err := ra.Stream(message)
+	if err != nil {
+		return "", err
+	}
+
+	for event := range events {
+		switch event.Type {
+		case llm.StreamEventMessage:
+			return event.Message.Content, nil
+		case llm.StreamEventError:
+			return "", event.Err
+		}
+
+		ca.mu.RLock()
+		if !ca.closed {
+			select {
+			case ca.events <- event:
+			case <-time.After(100 * time.Millisecond):
+				// Events are best effort; drop them if nobody is listening
+			case <-ctx.Done():
+			}
+		}
+		ca.mu.RUnlock()
+	}
+	return "", fmt.Errorf("stream ended without a final message")
+}
+
 // Send queues a message for processing
 // Returns immediately, responses come through the Output() channel
 func (ca *ChannelAgent) Send(msg string) error {
@@ -169,6 +230,21 @@ func (ca *ChannelAgent) Output() <-chan string {
 	return ca.output
 }
 
+// Events returns the channel for receiving streaming events when
+// streaming is enabled
+func (ca *ChannelAgent) Events() <-chan llm.StreamEvent {
+	return ca.events
+}
+
+// Approvals returns the channel of tool calls waiting for approval when
+// approvals are enabled. It is not closed; stop reading when Done is closed.
+func (ca *ChannelAgent) Approvals() <-chan *tools.PendingApproval {
+	if ca.approver == nil {
+		return nil
+	}
+	return ca.approver.Requests()
+}
+
 // Errors returns the channel for receiving errors
 func (ca *ChannelAgent) Errors() <-chan error {
 	return ca.errors
@@ -199,5 +275,6 @@ func (ca *ChannelAgent) Close() {
 		// Close remaining channels
 		close(ca.errors)
 		close(ca.output)
+		close(ca.events)
 	})
 }
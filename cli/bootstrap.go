@@ -157,7 +157,7 @@ func showCompletionMessage(exampleType, dir string) {
 		fmt.Println("2. Check other examples:")
 		fmt.Println("   - parallel.go: Run agents in parallel")
 		fmt.Println("   - router.go: Route requests between agents")
-		fmt.Println("   - evaluator.go: Add evaluation capabilities")
+		fmt.Println("   - evaluator.go: Refine drafts until an evaluator rates them highly")
 		fmt.Println("   - human_input.go: Incorporate human feedback")
 		fmt.Println("3. Run an example:")
 		fmt.Println("   cd", dir)
@@ -224,11 +224,38 @@ func main() {
 import (
 	"context"
 	"fmt"
+	"log"
+
+	"github.com/adimarco/hive"
 )
 
 func main() {
-	// Example of evaluating agent responses
-	fmt.Println("Evaluator Workflow Example")
+	// The writer drafts an answer, the critic rates it and gives feedback,
+	// and the writer revises the draft until it is rated excellent or three
+	// drafts have been written
+	model, err := hive.NewLLM("evaluator", hive.WithModel("sonnet"))
+	if err != nil {
+		log.Fatalf("Failed to create LLM: %v", err)
+	}
+
+	writer := hive.New("writer", "You write short, vivid product descriptions.")
+	critic := hive.New("critic", "You are a demanding copy editor. Judge descriptions on "+
+		"clarity, concreteness and tone, and say exactly what to change.")
+
+	loop := hive.NewEvaluatorOptimizer("refine", writer, critic).
+		WithMinRating(hive.RatingExcellent).
+		WithMaxRounds(3)
+	loop.WithLLM(model)
+
+	result, err := loop.Execute(context.Background(), "Describe a solar-powered camping lantern in two sentences.")
+	if err != nil {
+		log.Fatalf("Evaluation failed: %v", err)
+	}
+
+	for _, round := range result.Rounds {
+		fmt.Printf("Round %d (%s)\n%s\nFeedback: %s\n\n", round.Round, round.Rating, round.Draft, round.Feedback)
+	}
+	fmt.Printf("Best draft (round %d, %s):\n%s\n", result.Best.Round, result.Best.Rating, result.Output)
 }
 `
 
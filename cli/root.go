@@ -49,6 +49,7 @@ func init() {
 		setupCmd(),
 		bootstrapCmd(),
 		configCmd(),
+		serveCmd(),
 	)
 
 	// Disable the completion command for now since we haven't implemented it
@@ -67,6 +68,7 @@ func showWelcome() error {
 	fmt.Println("  setup      Set up a new agent project with configuration files")
 	fmt.Println("  bootstrap  Create example applications (workflow, researcher, etc.)")
 	fmt.Println("  config     Manage FastAgent configuration")
+	fmt.Println("  serve      Serve agents and tools over MCP")
 
 	fmt.Println("\nGetting Started:")
 	fmt.Println("1. Set up a new project:")
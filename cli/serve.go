@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adimarco/hive"
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/logging"
+)
+
+func serveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve agents and tools to other applications",
+		Long:  `Publish the agents and tools defined in your configuration to other applications.`,
+	}
+
+	cmd.AddCommand(serveMCPCmd())
+	return cmd
+}
+
+// serveMCPOptions holds the flags of "hive serve mcp"
+type serveMCPOptions struct {
+	configFile string
+	transport  string
+	addr       string
+	path       string
+	origins    []string
+	agents     []string
+}
+
+func serveMCPCmd() *cobra.Command {
+	var opts serveMCPOptions
+
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve agents and tools over the Model Context Protocol",
+		Long: `Publish the configured agents, each as a tool taking a message and returning
+the agent's reply, together with the tools of the configured MCP servers,
+so MCP hosts such as IDE assistants can call them.
+Example:
+  hive serve mcp
+  hive serve mcp --agent reviewer --agent researcher
+  hive serve mcp --transport http --addr 127.0.0.1:8080`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return serveMCP(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.configFile, "file", "f", "", "Path to configuration file")
+	cmd.Flags().StringVar(&opts.transport, "transport", "stdio", "Transport to serve (stdio or http)")
+	cmd.Flags().StringVar(&opts.addr, "addr", "127.0.0.1:8080", "Address to listen on for the http transport")
+	cmd.Flags().StringVar(&opts.path, "path", "/mcp", "URL path of the http transport")
+	cmd.Flags().StringSliceVar(&opts.origins, "allow-origin", nil, "Web origin allowed to call the http transport besides loopback ones (repeatable)")
+	cmd.Flags().StringSliceVar(&opts.agents, "agent", nil, "Agent to publish (repeatable; defaults to all configured agents)")
+	return cmd
+}
+
+func serveMCP(ctx context.Context, opts serveMCPOptions) error {
+	if opts.transport != "stdio" && opts.transport != "http" {
+		return fmt.Errorf("invalid transport %q, must be stdio or http", opts.transport)
+	}
+
+	cfg, err := config.LoadSettings(opts.configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Stdout carries the protocol, so everything else goes to stderr
+	if err := logging.Initialize(logging.Config{Type: "console", Level: cfg.Logger.Level, Writer: os.Stderr}); err != nil {
+		return fmt.Errorf("failed to initialize logging: %w", err)
+	}
+
+	app, err := hive.NewAppFromSettings("hive", cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	names := opts.agents
+	if len(names) == 0 {
+		for name := range cfg.Agents {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	agents := make([]*hive.Agent, 0, len(names))
+	for _, name := range names {
+		agent, ok := app.GetAgent(name)
+		if !ok {
+			return fmt.Errorf("unknown agent %q", name)
+		}
+		agent.SetOutput(os.Stderr)
+		agents = append(agents, agent)
+	}
+
+	server, err := app.MCPServer(agents...)
+	if err != nil {
+		return err
+	}
+
+	if opts.transport == "stdio" {
+		return server.ServeStdio(ctx, os.Stdin, os.Stdout)
+	}
+
+	if host, _, err := net.SplitHostPort(opts.addr); err != nil || !isLoopback(host) {
+		fmt.Fprintf(os.Stderr, "Warning: %s is not a loopback address; the published tools are reachable without authentication\n", opts.addr)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(opts.path, server.WithAllowedOrigins(opts.origins...))
+	httpServer := &http.Server{Addr: opts.addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errs := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "Serving MCP on http://%s%s\n", opts.addr, opts.path)
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// isLoopback reports whether host names the loopback interface
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
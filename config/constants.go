@@ -20,8 +20,17 @@ var validLogLevels = map[string]bool{
 	"error":   true,
 }
 
+// Valid memory strategies
+var validMemoryStrategies = map[string]bool{
+	"simple":          true,
+	"token_window":    true,
+	"keep_first_last": true,
+	"summarizing":     true,
+}
+
 // Valid transport types
 var validTransportTypes = map[string]bool{
 	"stdio": true,
 	"sse":   true,
+	"http":  true,
 }
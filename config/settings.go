@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -21,6 +22,83 @@ type Settings struct {
 
 	// MCP server configurations
 	MCP MCPSettings `yaml:"mcp"`
+
+	// Anthropic provider configuration
+	Anthropic AnthropicSettings `yaml:"anthropic"`
+
+	// OpenAI-compatible provider configuration
+	OpenAI OpenAISettings `yaml:"openai"`
+
+	// Retry policy for provider API calls
+	Retry RetrySettings `yaml:"retry"`
+
+	// Client-side rate limit for provider API calls
+	RateLimit RateLimitSettings `yaml:"rate_limit"`
+
+	// Conversation memory strategy for agents
+	Memory MemorySettings `yaml:"memory"`
+
+	// Agents defined in configuration, keyed by name
+	Agents map[string]AgentSettings `yaml:"agents"`
+}
+
+// AnthropicSettings configures the Anthropic provider
+type AnthropicSettings struct {
+	// API key used to authenticate requests (falls back to ANTHROPIC_API_KEY)
+	APIKey string `yaml:"api_key" env:"ANTHROPIC_API_KEY"`
+	// Base URL of the API; leave empty for the default endpoint
+	BaseURL string `yaml:"base_url" env:"ANTHROPIC_BASE_URL"`
+}
+
+// RetrySettings configures how failed provider API calls are retried
+type RetrySettings struct {
+	// Maximum number of attempts per API call, including the first (1 disables retries)
+	MaxAttempts int `yaml:"max_attempts" env:"RETRY_MAX_ATTEMPTS" default:"4"`
+	// Delay before the first retry; doubled after each attempt
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"RETRY_INITIAL_BACKOFF" default:"1s"`
+	// Upper bound for the delay between attempts
+	MaxBackoff time.Duration `yaml:"max_backoff" env:"RETRY_MAX_BACKOFF" default:"30s"`
+}
+
+// RateLimitSettings configures the client-side token bucket shared by all
+// agents using the same LLM
+type RateLimitSettings struct {
+	// Sustained request rate; 0 disables rate limiting
+	RequestsPerMinute float64 `yaml:"requests_per_minute" env:"RATE_LIMIT_REQUESTS_PER_MINUTE"`
+	// Number of requests that may be made in a burst (defaults to 1)
+	Burst int `yaml:"burst" env:"RATE_LIMIT_BURST"`
+}
+
+// MemorySettings selects how conversation history is kept within the
+// model's context window
+type MemorySettings struct {
+	// Strategy is one of simple (unbounded), token_window, keep_first_last or summarizing
+	Strategy string `yaml:"strategy" env:"MEMORY_STRATEGY"`
+	// History budget in estimated tokens for token_window and summarizing
+	MaxTokens int `yaml:"max_tokens" env:"MEMORY_MAX_TOKENS"`
+	// Number of earliest exchanges kept by keep_first_last
+	KeepFirst int `yaml:"keep_first" env:"MEMORY_KEEP_FIRST"`
+	// Number of most recent exchanges kept by keep_first_last
+	KeepLast int `yaml:"keep_last" env:"MEMORY_KEEP_LAST"`
+}
+
+// AgentSettings defines an agent in configuration, e.g. one published by
+// "hive serve mcp"
+type AgentSettings struct {
+	// Instruction given to the agent as its system prompt
+	Instruction string `yaml:"instruction"`
+	// Model to use instead of the default model (optional)
+	Model string `yaml:"model,omitempty"`
+	// Whether the agent remembers earlier messages of a conversation
+	History bool `yaml:"history,omitempty"`
+}
+
+// OpenAISettings configures the OpenAI-compatible chat completions provider
+type OpenAISettings struct {
+	// API key used to authenticate requests (falls back to OPENAI_API_KEY)
+	APIKey string `yaml:"api_key" env:"OPENAI_API_KEY"`
+	// Base URL of the API, e.g. http://localhost:8080/v1 for llama.cpp or vLLM
+	BaseURL string `yaml:"base_url" env:"OPENAI_BASE_URL" default:"https://api.openai.com/v1"`
 }
 
 // LoggerSettings configures logging behavior
@@ -55,7 +133,7 @@ type MCPServerSettings struct {
 	// Description of the server (optional)
 	Description string `yaml:"description,omitempty"`
 
-	// Transport mechanism ("stdio" or "sse")
+	// Transport mechanism ("stdio", "sse" or "http" for streamable HTTP)
 	Transport string `yaml:"transport" default:"stdio"`
 
 	// Command to execute the server (e.g. npx)
@@ -64,9 +142,15 @@ type MCPServerSettings struct {
 	// Arguments for the server command
 	Args []string `yaml:"args,omitempty"`
 
-	// URL for the server (required for SSE transport)
+	// URL for the server (required for SSE and HTTP transports)
 	URL string `yaml:"url,omitempty"`
 
+	// HTTP headers sent with every request, e.g. Authorization
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Maximum time to wait for a response to each request (no limit if zero)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
 	// Environment variables to pass to the server process
 	Env map[string]string `yaml:"env,omitempty"`
 }
@@ -90,6 +174,11 @@ func LoadSettings(configPath string) (*Settings, error) {
 		MCP: MCPSettings{
 			Servers: make(map[string]MCPServerSettings),
 		},
+		Retry: RetrySettings{
+			MaxAttempts:    4,
+			InitialBackoff: time.Second,
+			MaxBackoff:     30 * time.Second,
+		},
 	}
 
 	// Load from YAML if file exists
@@ -154,6 +243,69 @@ func loadFromEnv(settings *Settings) error {
 		}
 	}
 
+	// Load OpenAI settings
+	if val := os.Getenv(EnvPrefix + "OPENAI_API_KEY"); val != "" {
+		settings.OpenAI.APIKey = val
+	}
+	if val := os.Getenv(EnvPrefix + "OPENAI_BASE_URL"); val != "" {
+		settings.OpenAI.BaseURL = val
+	}
+
+	// Load Anthropic settings
+	if val := os.Getenv(EnvPrefix + "ANTHROPIC_API_KEY"); val != "" {
+		settings.Anthropic.APIKey = val
+	}
+	if val := os.Getenv(EnvPrefix + "ANTHROPIC_BASE_URL"); val != "" {
+		settings.Anthropic.BaseURL = val
+	}
+
+	// Load retry and rate limit settings
+	if val := os.Getenv(EnvPrefix + "RETRY_MAX_ATTEMPTS"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			settings.Retry.MaxAttempts = i
+		}
+	}
+	if val := os.Getenv(EnvPrefix + "RETRY_INITIAL_BACKOFF"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			settings.Retry.InitialBackoff = d
+		}
+	}
+	if val := os.Getenv(EnvPrefix + "RETRY_MAX_BACKOFF"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			settings.Retry.MaxBackoff = d
+		}
+	}
+	if val := os.Getenv(EnvPrefix + "RATE_LIMIT_REQUESTS_PER_MINUTE"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			settings.RateLimit.RequestsPerMinute = f
+		}
+	}
+	if val := os.Getenv(EnvPrefix + "RATE_LIMIT_BURST"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			settings.RateLimit.Burst = i
+		}
+	}
+
+	// Load memory settings
+	if val := os.Getenv(EnvPrefix + "MEMORY_STRATEGY"); val != "" {
+		settings.Memory.Strategy = val
+	}
+	if val := os.Getenv(EnvPrefix + "MEMORY_MAX_TOKENS"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			settings.Memory.MaxTokens = i
+		}
+	}
+	if val := os.Getenv(EnvPrefix + "MEMORY_KEEP_FIRST"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			settings.Memory.KeepFirst = i
+		}
+	}
+	if val := os.Getenv(EnvPrefix + "MEMORY_KEEP_LAST"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			settings.Memory.KeepLast = i
+		}
+	}
+
 	// Load MCP server settings from environment
 	// Format: FASTAGENT_MCP_SERVER_<name>_<field>=value
 	prefix := EnvPrefix + "MCP_SERVER_"
@@ -195,6 +347,21 @@ func loadFromEnv(settings *Settings) error {
 			server.Args = strings.Split(value, ",")
 		case "url":
 			server.URL = value
+		case "headers":
+			// Format: key1=value1,key2=value2
+			if server.Headers == nil {
+				server.Headers = make(map[string]string)
+			}
+			for _, pair := range strings.Split(value, ",") {
+				kv := strings.SplitN(pair, "=", 2)
+				if len(kv) == 2 {
+					server.Headers[kv[0]] = kv[1]
+				}
+			}
+		case "timeout":
+			if d, err := time.ParseDuration(value); err == nil {
+				server.Timeout = d
+			}
 		case "env":
 			// Format: key1=value1,key2=value2
 			if server.Env == nil {
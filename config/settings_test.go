@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,6 +35,12 @@ mcp:
       args: ["-y", "@modelcontextprotocol/server-test"]
       env:
         TEST_KEY: test_value
+
+agents:
+  reviewer:
+    instruction: "Review Go code for bugs"
+    model: sonnet
+    history: true
 `
 
 	err := os.WriteFile(configPath, []byte(configData), 0644)
@@ -62,6 +69,11 @@ mcp:
 	assert.Equal(t, "npx", server.Command)
 	assert.Equal(t, []string{"-y", "@modelcontextprotocol/server-test"}, server.Args)
 	assert.Equal(t, "test_value", server.Env["TEST_KEY"])
+
+	// Verify agent definitions
+	assert.Equal(t, map[string]AgentSettings{
+		"reviewer": {Instruction: "Review Go code for bugs", Model: "sonnet", History: true},
+	}, settings.Agents)
 }
 
 func TestLoadSettings_FileNotFound(t *testing.T) {
@@ -76,6 +88,10 @@ func TestLoadSettings_FileNotFound(t *testing.T) {
 	assert.True(t, settings.Logger.ProgressDisplay)
 	assert.Equal(t, "hive.jsonl", settings.Logger.Path)
 	assert.Equal(t, 100, settings.Logger.BatchSize)
+	assert.Equal(t, 4, settings.Retry.MaxAttempts)
+	assert.Equal(t, time.Second, settings.Retry.InitialBackoff)
+	assert.Equal(t, 30*time.Second, settings.Retry.MaxBackoff)
+	assert.Zero(t, settings.RateLimit.RequestsPerMinute)
 }
 
 func TestLoadSettings_InvalidYAML(t *testing.T) {
@@ -99,16 +115,29 @@ logger:
 func TestLoadSettings_EnvironmentOverrides(t *testing.T) {
 	// Set up environment variables
 	envVars := map[string]string{
-		"HIVE_DEFAULT_MODEL":           "sonnet",
-		"HIVE_LOGGER_TYPE":             "console",
-		"HIVE_LOGGER_LEVEL":            "debug",
-		"HIVE_LOGGER_PROGRESS_DISPLAY": "false",
-		"HIVE_LOGGER_PATH":             "env.jsonl",
-		"HIVE_LOGGER_BATCH_SIZE":       "200",
-		"HIVE_MCP_SERVER_TEST_NAME":    "Env Test Server",
-		"HIVE_MCP_SERVER_TEST_COMMAND": "test-cmd",
-		"HIVE_MCP_SERVER_TEST_ARGS":    "arg1,arg2",
-		"HIVE_MCP_SERVER_TEST_ENV":     "KEY1=value1,KEY2=value2",
+		"HIVE_DEFAULT_MODEL":                  "sonnet",
+		"HIVE_LOGGER_TYPE":                    "console",
+		"HIVE_LOGGER_LEVEL":                   "debug",
+		"HIVE_LOGGER_PROGRESS_DISPLAY":        "false",
+		"HIVE_LOGGER_PATH":                    "env.jsonl",
+		"HIVE_LOGGER_BATCH_SIZE":              "200",
+		"HIVE_MCP_SERVER_TEST_NAME":           "Env Test Server",
+		"HIVE_MCP_SERVER_TEST_COMMAND":        "test-cmd",
+		"HIVE_MCP_SERVER_TEST_ARGS":           "arg1,arg2",
+		"HIVE_MCP_SERVER_TEST_ENV":            "KEY1=value1,KEY2=value2",
+		"HIVE_OPENAI_BASE_URL":                "http://localhost:8080/v1",
+		"HIVE_ANTHROPIC_BASE_URL":             "http://localhost:9090",
+		"HIVE_RETRY_MAX_ATTEMPTS":             "6",
+		"HIVE_RETRY_INITIAL_BACKOFF":          "250ms",
+		"HIVE_RATE_LIMIT_REQUESTS_PER_MINUTE": "50",
+		"HIVE_RATE_LIMIT_BURST":               "5",
+		"HIVE_MEMORY_STRATEGY":                "keep_first_last",
+		"HIVE_MEMORY_KEEP_FIRST":              "2",
+		"HIVE_MEMORY_KEEP_LAST":               "10",
+		"HIVE_MCP_SERVER_REMOTE_TRANSPORT":    "http",
+		"HIVE_MCP_SERVER_REMOTE_URL":          "https://mcp.example.com/mcp",
+		"HIVE_MCP_SERVER_REMOTE_HEADERS":      "Authorization=Bearer abc,X-Team=hive",
+		"HIVE_MCP_SERVER_REMOTE_TIMEOUT":      "45s",
 	}
 
 	// Set environment variables
@@ -127,6 +156,16 @@ func TestLoadSettings_EnvironmentOverrides(t *testing.T) {
 	assert.False(t, settings.Logger.ProgressDisplay)
 	assert.Equal(t, "env.jsonl", settings.Logger.Path)
 	assert.Equal(t, 200, settings.Logger.BatchSize)
+	assert.Equal(t, "http://localhost:8080/v1", settings.OpenAI.BaseURL)
+	assert.Equal(t, "http://localhost:9090", settings.Anthropic.BaseURL)
+	assert.Equal(t, 6, settings.Retry.MaxAttempts)
+	assert.Equal(t, 250*time.Millisecond, settings.Retry.InitialBackoff)
+	assert.Equal(t, 30*time.Second, settings.Retry.MaxBackoff)
+	assert.Equal(t, 50.0, settings.RateLimit.RequestsPerMinute)
+	assert.Equal(t, 5, settings.RateLimit.Burst)
+	assert.Equal(t, "keep_first_last", settings.Memory.Strategy)
+	assert.Equal(t, 2, settings.Memory.KeepFirst)
+	assert.Equal(t, 10, settings.Memory.KeepLast)
 
 	// Verify MCP server settings from environment
 	server, ok := settings.MCP.Servers["test"]
@@ -136,6 +175,13 @@ func TestLoadSettings_EnvironmentOverrides(t *testing.T) {
 	assert.Equal(t, []string{"arg1", "arg2"}, server.Args)
 	assert.Equal(t, "value1", server.Env["KEY1"])
 	assert.Equal(t, "value2", server.Env["KEY2"])
+
+	remote, ok := settings.MCP.Servers["remote"]
+	require.True(t, ok)
+	assert.Equal(t, "http", remote.Transport)
+	assert.Equal(t, "https://mcp.example.com/mcp", remote.URL)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer abc", "X-Team": "hive"}, remote.Headers)
+	assert.Equal(t, 45*time.Second, remote.Timeout)
 }
 
 func TestLoadSettings_EnvironmentOverridesWithFile(t *testing.T) {
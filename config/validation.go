@@ -17,6 +17,28 @@ func (s *Settings) Validate() error {
 		return fmt.Errorf("invalid MCP settings: %w", err)
 	}
 
+	// Validate retry settings
+	if err := s.Retry.Validate(); err != nil {
+		return fmt.Errorf("invalid retry settings: %w", err)
+	}
+
+	// Validate rate limit settings
+	if err := s.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("invalid rate limit settings: %w", err)
+	}
+
+	// Validate memory settings
+	if err := s.Memory.Validate(); err != nil {
+		return fmt.Errorf("invalid memory settings: %w", err)
+	}
+
+	// Validate agent definitions
+	for name, agent := range s.Agents {
+		if err := agent.Validate(); err != nil {
+			return fmt.Errorf("invalid agent %q: %w", name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -72,13 +94,77 @@ func (s *MCPServerSettings) Validate() error {
 		}
 	}
 
-	// Validate SSE transport requirements
-	if s.Transport == "sse" {
+	// Validate SSE and streamable HTTP transport requirements
+	if s.Transport == "sse" || s.Transport == "http" {
 		if s.URL == "" {
-			return fmt.Errorf("url is required for sse transport")
+			return fmt.Errorf("url is required for %s transport", s.Transport)
+		}
+	}
+
+	if s.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+
+	return nil
+}
+
+// Validate checks if the retry settings are valid
+func (s *RetrySettings) Validate() error {
+	if s.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must not be negative")
+	}
+	if s.InitialBackoff < 0 || s.MaxBackoff < 0 {
+		return fmt.Errorf("backoff durations must not be negative")
+	}
+	if s.MaxBackoff > 0 && s.InitialBackoff > s.MaxBackoff {
+		return fmt.Errorf("initial backoff %s exceeds max backoff %s", s.InitialBackoff, s.MaxBackoff)
+	}
+	return nil
+}
+
+// Validate checks if the rate limit settings are valid
+func (s *RateLimitSettings) Validate() error {
+	if s.RequestsPerMinute < 0 {
+		return fmt.Errorf("requests per minute must not be negative")
+	}
+	if s.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	return nil
+}
+
+// Validate checks if the memory settings are valid. An empty strategy
+// selects simple, unbounded memory.
+func (s *MemorySettings) Validate() error {
+	if s.Strategy == "" {
+		return nil
+	}
+	if !validMemoryStrategies[s.Strategy] {
+		return fmt.Errorf("invalid memory strategy %q, must be one of: %s",
+			s.Strategy, strings.Join(mapKeys(validMemoryStrategies), ", "))
+	}
+
+	switch s.Strategy {
+	case "token_window", "summarizing":
+		if s.MaxTokens <= 0 {
+			return fmt.Errorf("max tokens must be greater than 0 for %s memory", s.Strategy)
+		}
+	case "keep_first_last":
+		if s.KeepFirst < 0 {
+			return fmt.Errorf("keep first must not be negative")
+		}
+		if s.KeepLast <= 0 {
+			return fmt.Errorf("keep last must be greater than 0")
 		}
 	}
+	return nil
+}
 
+// Validate checks if the agent settings are valid
+func (s *AgentSettings) Validate() error {
+	if strings.TrimSpace(s.Instruction) == "" {
+		return fmt.Errorf("instruction is required")
+	}
 	return nil
 }
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -81,6 +82,55 @@ func TestSettings_Validate(t *testing.T) {
 			wantErr:     true,
 			errContains: "batch size must be greater than 0",
 		},
+		{
+			name: "initial backoff exceeds max backoff",
+			settings: Settings{
+				Logger: LoggerSettings{Type: "console", Level: "info", BatchSize: 100},
+				Retry: RetrySettings{
+					MaxAttempts:    3,
+					InitialBackoff: time.Minute,
+					MaxBackoff:     time.Second,
+				},
+			},
+			wantErr:     true,
+			errContains: "invalid retry settings",
+		},
+		{
+			name: "negative rate limit",
+			settings: Settings{
+				Logger:    LoggerSettings{Type: "console", Level: "info", BatchSize: 100},
+				RateLimit: RateLimitSettings{RequestsPerMinute: -1},
+			},
+			wantErr:     true,
+			errContains: "invalid rate limit settings",
+		},
+		{
+			name: "agent without instruction",
+			settings: Settings{
+				Logger: LoggerSettings{Type: "console", Level: "info", BatchSize: 100},
+				Agents: map[string]AgentSettings{"reviewer": {Model: "sonnet"}},
+			},
+			wantErr:     true,
+			errContains: `invalid agent "reviewer"`,
+		},
+		{
+			name: "unknown memory strategy",
+			settings: Settings{
+				Logger: LoggerSettings{Type: "console", Level: "info", BatchSize: 100},
+				Memory: MemorySettings{Strategy: "forgetful"},
+			},
+			wantErr:     true,
+			errContains: "invalid memory settings",
+		},
+		{
+			name: "token window without budget",
+			settings: Settings{
+				Logger: LoggerSettings{Type: "console", Level: "info", BatchSize: 100},
+				Memory: MemorySettings{Strategy: "token_window"},
+			},
+			wantErr:     true,
+			errContains: "invalid memory settings",
+		},
 	}
 
 	for _, tt := range tests {
@@ -145,6 +195,34 @@ func TestMCPServerSettings_Validate(t *testing.T) {
 			wantErr:     true,
 			errContains: "url is required for sse transport",
 		},
+		{
+			name: "valid http server",
+			server: MCPServerSettings{
+				Transport: "http",
+				URL:       "http://localhost:8080/mcp",
+				Headers:   map[string]string{"Authorization": "Bearer token"},
+				Timeout:   30 * time.Second,
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing url for http",
+			server: MCPServerSettings{
+				Transport: "http",
+			},
+			wantErr:     true,
+			errContains: "url is required for http transport",
+		},
+		{
+			name: "negative timeout",
+			server: MCPServerSettings{
+				Transport: "sse",
+				URL:       "http://localhost:8080",
+				Timeout:   -time.Second,
+			},
+			wantErr:     true,
+			errContains: "timeout must not be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,272 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/tools"
+)
+
+// DefaultMaxRounds is how many drafts an evaluator-optimizer writes unless
+// WithMaxRounds sets another limit
+const DefaultMaxRounds = 3
+
+// rateToolName is the tool an evaluator's LLM calls to rate a draft
+var rateToolName = tools.QualifiedName("evaluator", "rate")
+
+// Rating is an evaluator's verdict on a draft, from Poor to Excellent
+type Rating int
+
+const (
+	// RatingPoor means the draft does not meet the request
+	RatingPoor Rating = iota + 1
+	// RatingFair means the draft meets the request with major gaps
+	RatingFair
+	// RatingGood means the draft meets the request with minor gaps
+	RatingGood
+	// RatingExcellent means the draft cannot be meaningfully improved
+	RatingExcellent
+)
+
+// ratingNames are the names of the ratings, as evaluators give them
+var ratingNames = map[Rating]string{
+	RatingPoor:      "POOR",
+	RatingFair:      "FAIR",
+	RatingGood:      "GOOD",
+	RatingExcellent: "EXCELLENT",
+}
+
+// ratingPattern finds a rating in an evaluator's text answer
+var ratingPattern = regexp.MustCompile(`(?i)\b(excellent|good|fair|poor)\b`)
+
+// String returns the rating's name, e.g. "GOOD"
+func (r Rating) String() string {
+	if name, ok := ratingNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Rating(%d)", int(r))
+}
+
+// ParseRating parses a rating name, ignoring case
+func ParseRating(s string) (Rating, error) {
+	for rating, name := range ratingNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return rating, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rating %q", s)
+}
+
+// rateArgs are the arguments of the rating tool
+type rateArgs struct {
+	Rating   string `json:"rating" jsonschema:"description=Overall quality of the draft,enum=EXCELLENT,enum=GOOD,enum=FAIR,enum=POOR"`
+	Feedback string `json:"feedback" jsonschema:"description=What is wrong with the draft and how to improve it"`
+}
+
+// EvaluationRound is one draft written by the generator and the
+// evaluator's verdict on it
+type EvaluationRound struct {
+	Round    int // From 1
+	Draft    string
+	Rating   Rating
+	Feedback string
+}
+
+// EvaluationResult is the outcome of an evaluator-optimizer loop: every
+// round in order, the best of them and its draft
+type EvaluationResult struct {
+	Output string
+	Best   EvaluationRound
+	Rounds []EvaluationRound
+}
+
+// EvaluatorOptimizer is a workflow agent refining an answer: its generator
+// writes a draft, its evaluator rates it and gives feedback, and the
+// generator revises the draft with that feedback until it is rated at
+// least the minimum rating or the rounds run out. The evaluator reports
+// its rating by calling a tool; a rating named in its text answer is used
+// when it does not.
+type EvaluatorOptimizer struct {
+	*Agent
+	generator *Agent
+	evaluator *Agent
+	minRating Rating
+	maxRounds int
+}
+
+// NewEvaluatorOptimizer creates a loop in which evaluator rates the drafts
+// generator writes
+func NewEvaluatorOptimizer(name string, generator, evaluator *Agent) *EvaluatorOptimizer {
+	e := &EvaluatorOptimizer{
+		Agent:     New(name, "You refine answers until they are rated good enough."),
+		generator: generator,
+		evaluator: evaluator,
+		minRating: RatingGood,
+		maxRounds: DefaultMaxRounds,
+	}
+	e.agentType = AgentTypeEvaluatorOptimizer
+	e.workflow = e
+	return e
+}
+
+// WithMinRating sets the rating at which a draft is accepted. The default
+// is RatingGood.
+func (e *EvaluatorOptimizer) WithMinRating(rating Rating) *EvaluatorOptimizer {
+	e.minRating = rating
+	return e
+}
+
+// WithMaxRounds sets how many drafts may be written before the best one is
+// returned
+func (e *EvaluatorOptimizer) WithMaxRounds(n int) *EvaluatorOptimizer {
+	e.maxRounds = n
+	return e
+}
+
+// Execute refines an answer to msg
+func (e *EvaluatorOptimizer) Execute(ctx context.Context, msg string) (EvaluationResult, error) {
+	return e.execute(ctx, e.llm, msg)
+}
+
+// send implements workflow
+func (e *EvaluatorOptimizer) send(ctx context.Context, model llm.AugmentedLLM, msg string) (string, error) {
+	result, err := e.execute(ctx, model, msg)
+	return result.Output, err
+}
+
+// execute runs the loop, using model for members without an LLM of their
+// own. On failure the rounds completed so far are returned with the error.
+func (e *EvaluatorOptimizer) execute(ctx context.Context, model llm.AugmentedLLM, msg string) (EvaluationResult, error) {
+	if e.generator == nil || e.evaluator == nil {
+		return EvaluationResult{}, fmt.Errorf("evaluator %q needs a generator and an evaluator", e.name)
+	}
+
+	var result EvaluationResult
+	for round := 1; round <= max(e.maxRounds, 1); round++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		draft, err := sendTo(ctx, e.generator, model, e.generatorPrompt(msg, result.Rounds))
+		if err != nil {
+			return result, fmt.Errorf("evaluator %q: round %d: generator %q failed: %w", e.name, round, e.generator.name, err)
+		}
+		rating, feedback, err := e.evaluate(ctx, model, msg, draft)
+		if err != nil {
+			return result, fmt.Errorf("evaluator %q: round %d: evaluator %q failed: %w", e.name, round, e.evaluator.name, err)
+		}
+
+		current := EvaluationRound{Round: round, Draft: draft, Rating: rating, Feedback: feedback}
+		result.Rounds = append(result.Rounds, current)
+		// Later drafts have seen more feedback, so they win ties
+		if rating >= result.Best.Rating {
+			result.Best = current
+			result.Output = draft
+		}
+		if rating >= e.minRating {
+			break
+		}
+	}
+	return result, nil
+}
+
+// generatorPrompt asks for a first draft, or for a revision of the last
+// draft addressing its feedback
+func (e *EvaluatorOptimizer) generatorPrompt(msg string, rounds []EvaluationRound) string {
+	if len(rounds) == 0 {
+		return msg
+	}
+	last := rounds[len(rounds)-1]
+
+	var b strings.Builder
+	writeRequest(&b, msg)
+	fmt.Fprintf(&b, "\n\n<draft>\n%s\n</draft>\n\n<feedback rating=%q>\n%s\n</feedback>\n\n", last.Draft, last.Rating, last.Feedback)
+	b.WriteString("Revise the draft to address the feedback. Answer with the complete revised response only.")
+	return b.String()
+}
+
+// evaluate asks the evaluator to rate draft as an answer to msg
+func (e *EvaluatorOptimizer) evaluate(ctx context.Context, model llm.AugmentedLLM, msg, draft string) (Rating, string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rate the response below to the request as EXCELLENT, GOOD, FAIR or POOR and explain how to improve it, "+
+		"reporting your verdict by calling %s.\n\n", rateToolName)
+	writeRequest(&b, msg)
+	writeResponse(&b, e.generator.name, draft, nil)
+	prompt := b.String()
+
+	if e.evaluator.llm != nil {
+		model = e.evaluator.llm
+	}
+	if e.evaluator.workflow != nil || model == nil {
+		// Workflows cannot be given the rating tool, so rely on their text
+		answer, err := sendTo(ctx, e.evaluator, model, prompt)
+		if err != nil {
+			return 0, "", err
+		}
+		return parseRating(answer)
+	}
+
+	if err := registerRateTool(model.Tools()); err != nil {
+		return 0, "", err
+	}
+	ra, err := e.evaluator.runWith(ctx, model)
+	if err != nil {
+		return 0, "", err
+	}
+	params := ra.buildRequestParams()
+	params.Tools = []string{rateToolName}
+	params.ExcludeTools = nil
+	params.UseHistory = false
+
+	response, err := model.Generate(ctx, llm.Message{Type: llm.MessageTypeUser, Content: prompt}, params)
+	if err != nil {
+		return 0, "", err
+	}
+	e.evaluator.recordUsage(ctx, response)
+
+	for _, call := range response.ToolCalls {
+		if call.Name != rateToolName || call.IsError {
+			continue
+		}
+		var args rateArgs
+		data, _ := json.Marshal(call.Args)
+		if err := json.Unmarshal(data, &args); err != nil {
+			return 0, "", fmt.Errorf("invalid rating arguments: %w", err)
+		}
+		rating, err := ParseRating(args.Rating)
+		if err != nil {
+			return 0, "", err
+		}
+		return rating, args.Feedback, nil
+	}
+	return parseRating(response.Content)
+}
+
+// parseRating reads the first rating named in an evaluator's text answer,
+// which is kept whole as the feedback
+func parseRating(answer string) (Rating, string, error) {
+	match := ratingPattern.FindString(answer)
+	if match == "" {
+		return 0, "", fmt.Errorf("no rating in answer: %q", answer)
+	}
+	rating, err := ParseRating(match)
+	if err != nil {
+		return 0, "", err
+	}
+	return rating, strings.TrimSpace(answer), nil
+}
+
+// registerRateTool registers the rating tool in registry. The tool only
+// acknowledges the rating; the evaluator reads it from the tool call.
+func registerRateTool(registry tools.ToolRegistry) error {
+	tool, err := tools.NewFunctionTool(rateToolName, "Report your rating of the draft and feedback on it",
+		func(args rateArgs) string { return "Rating recorded." })
+	if err != nil {
+		return err
+	}
+	return registerWorkflowTool(registry, tool)
+}
@@ -0,0 +1,140 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/llm"
+)
+
+// ratingTurns returns the assistant turns of an evaluator rating a draft
+// with the rating tool
+func ratingTurns(rating, feedback string) []llm.Message {
+	return []llm.Message{
+		{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{{ID: "rate-1", Name: rateToolName, Args: map[string]any{"rating": rating, "feedback": feedback}}}},
+		{Type: llm.MessageTypeAssistant, Content: "Rated."},
+	}
+}
+
+// draftingAgent returns an agent answering with numbered drafts, recording
+// the prompts it was sent
+func draftingAgent(prompts *[]string) *Agent {
+	return transformAgent("writer", func(s string) string {
+		*prompts = append(*prompts, s)
+		return fmt.Sprintf("draft %d", len(*prompts))
+	})
+}
+
+func TestRating(t *testing.T) {
+	assert.True(t, RatingPoor < RatingFair && RatingFair < RatingGood && RatingGood < RatingExcellent)
+	assert.Equal(t, "EXCELLENT", RatingExcellent.String())
+	assert.Equal(t, "Rating(0)", Rating(0).String())
+
+	rating, err := ParseRating(" fair ")
+	require.NoError(t, err)
+	assert.Equal(t, RatingFair, rating)
+
+	_, err = ParseRating("great")
+	assert.ErrorContains(t, err, `unknown rating "great"`)
+}
+
+func TestEvaluatorOptimizer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("refines until the minimum rating", func(t *testing.T) {
+		var prompts []string
+		var turns []llm.Message
+		turns = append(turns, ratingTurns("POOR", "Too vague")...)
+		turns = append(turns, ratingTurns("GOOD", "Clear enough")...)
+		evaluator := New("critic", "Rate drafts").WithLLM(llm.NewPlaybackLLM("critic", turns))
+
+		loop := NewEvaluatorOptimizer("refine", draftingAgent(&prompts), evaluator)
+		assert.Equal(t, AgentTypeEvaluatorOptimizer, loop.agentType)
+
+		result, err := loop.Execute(ctx, "Write a slogan")
+		require.NoError(t, err)
+		assert.Equal(t, "draft 2", result.Output)
+		assert.Equal(t, []EvaluationRound{
+			{Round: 1, Draft: "draft 1", Rating: RatingPoor, Feedback: "Too vague"},
+			{Round: 2, Draft: "draft 2", Rating: RatingGood, Feedback: "Clear enough"},
+		}, result.Rounds)
+		assert.Equal(t, result.Rounds[1], result.Best)
+
+		require.Len(t, prompts, 2)
+		assert.Equal(t, "Write a slogan", prompts[0])
+		assert.Contains(t, prompts[1], "<request>\nWrite a slogan\n</request>")
+		assert.Contains(t, prompts[1], "<draft>\ndraft 1\n</draft>")
+		assert.Contains(t, prompts[1], "<feedback rating=\"POOR\">\nToo vague\n</feedback>")
+	})
+
+	t.Run("returns the best round when rounds run out", func(t *testing.T) {
+		var prompts []string
+		var turns []llm.Message
+		turns = append(turns, ratingTurns("POOR", "Off topic")...)
+		turns = append(turns, ratingTurns("GOOD", "Better")...)
+		turns = append(turns, ratingTurns("FAIR", "Worse again")...)
+		evaluator := New("critic", "Rate drafts").WithLLM(llm.NewPlaybackLLM("critic", turns))
+
+		result, err := NewEvaluatorOptimizer("refine", draftingAgent(&prompts), evaluator).
+			WithMinRating(RatingExcellent).
+			Execute(ctx, "Write a slogan")
+		require.NoError(t, err)
+		assert.Len(t, result.Rounds, DefaultMaxRounds)
+		assert.Equal(t, "draft 2", result.Output)
+		assert.Equal(t, RatingGood, result.Best.Rating)
+	})
+
+	t.Run("rating from the evaluator's text", func(t *testing.T) {
+		var prompts []string
+		evaluator := New("critic", "Rate drafts").WithLLM(llm.NewPlaybackLLM("critic", []llm.Message{
+			{Type: llm.MessageTypeAssistant, Content: "Rating: Fair. Needs a rhyme."},
+		}))
+
+		result, err := NewEvaluatorOptimizer("refine", draftingAgent(&prompts), evaluator).
+			WithMinRating(RatingFair).
+			Execute(ctx, "Write a slogan")
+		require.NoError(t, err)
+		assert.Equal(t, []EvaluationRound{
+			{Round: 1, Draft: "draft 1", Rating: RatingFair, Feedback: "Rating: Fair. Needs a rhyme."},
+		}, result.Rounds)
+	})
+
+	t.Run("uses the workflow llm for members without one", func(t *testing.T) {
+		var prompts []string
+		evaluator := New("critic", "Rate drafts")
+		loop := NewEvaluatorOptimizer("refine", draftingAgent(&prompts), evaluator).WithMaxRounds(1)
+		loop.WithLLM(llm.NewPlaybackLLM("critic", ratingTurns("FAIR", "Shorter")))
+
+		ra, err := loop.Run(ctx)
+		require.NoError(t, err)
+		reply, err := ra.Send("Write a slogan")
+		require.NoError(t, err)
+		assert.Equal(t, "draft 1", reply)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		var prompts []string
+		critic := func(turns []llm.Message) *Agent {
+			return New("critic", "Rate drafts").WithLLM(llm.NewPlaybackLLM("critic", turns))
+		}
+
+		_, err := NewEvaluatorOptimizer("refine", failingAgent("writer", errors.New("boom")), critic(ratingTurns("GOOD", ""))).
+			Execute(ctx, "Write a slogan")
+		assert.ErrorContains(t, err, `evaluator "refine": round 1: generator "writer" failed`)
+		assert.ErrorContains(t, err, "boom")
+
+		result, err := NewEvaluatorOptimizer("refine", draftingAgent(&prompts), critic([]llm.Message{
+			{Type: llm.MessageTypeAssistant, Content: "Looks fine to me."},
+		})).Execute(ctx, "Write a slogan")
+		assert.ErrorContains(t, err, `evaluator "refine": round 1: evaluator "critic" failed: no rating in answer`)
+		assert.Empty(t, result.Rounds)
+
+		_, err = NewEvaluatorOptimizer("refine", nil, nil).Execute(ctx, "Write a slogan")
+		assert.ErrorContains(t, err, `evaluator "refine" needs a generator and an evaluator`)
+	})
+}
@@ -8,8 +8,12 @@ import (
 	"time"
 
 	"github.com/adimarco/hive"
+	"github.com/adimarco/hive/tools"
 )
 
+// fileTools are the tools that touch the filesystem
+var fileTools = []string{"readFile", "listDir"}
+
 func main() {
 	// Create a new app with tool support
 	app := hive.NewApp("tool-demo")
@@ -31,8 +35,10 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create an agent with access to tools
-	agent := app.Agent("You are a helpful agent with access to system tools. Use them to help answer user questions.")
+	// Create an agent with access to tools. Filesystem access must be
+	// approved at the terminal before each call.
+	agent := app.Agent("You are a helpful agent with access to system tools. Use them to help answer user questions.").
+		WithApproval(tools.NewApprovalPolicy(tools.NewTerminalApprover(os.Stdin, os.Stdout)).WithTools(fileTools...))
 
 	// Start an interactive session
 	runningAgent, err := agent.Run(context.Background())
@@ -133,6 +139,12 @@ func registerSystemTools(app *hive.App) error {
 	return nil
 }
 
+// formatDateArgs are the arguments of the formatDate tool
+type formatDateArgs struct {
+	Time   string `json:"time,omitempty" jsonschema:"description=Time to format in RFC3339; defaults to now"`
+	Layout string `json:"layout,omitempty" jsonschema:"description=Go time layout,default=2006-01-02 15:04:05"`
+}
+
 // registerDateTimeTools registers tools for date and time operations
 func registerDateTimeTools(app *hive.App) error {
 	// Get current date and time
@@ -143,19 +155,18 @@ func registerDateTimeTools(app *hive.App) error {
 		return err
 	}
 
-	// Format a date
-	if err := app.Tool("formatDate", func(ctx context.Context, args map[string]interface{}) (string, error) {
-		layout, ok := args["layout"].(string)
-		if !ok {
+	// Format a date; the input schema is generated from formatDateArgs
+	if err := app.Tool("formatDate", func(ctx context.Context, args formatDateArgs) (string, error) {
+		layout := args.Layout
+		if layout == "" {
 			layout = "2006-01-02 15:04:05"
 		}
 
-		timeStr, ok := args["time"].(string)
-		if !ok {
+		if args.Time == "" {
 			return time.Now().Format(layout), nil
 		}
 
-		t, err := time.Parse(time.RFC3339, timeStr)
+		t, err := time.Parse(time.RFC3339, args.Time)
 		if err != nil {
 			return "", fmt.Errorf("invalid time format, expected RFC3339: %w", err)
 		}
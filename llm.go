@@ -8,15 +8,54 @@ import (
 	"github.com/adimarco/hive/llm"
 )
 
+// defaultModel is used when no model is configured
+const defaultModel = "claude-3-haiku-20240307"
+
+// NewLLM creates and initializes an LLM for the configured model.
+// The model string is resolved through the provider registry, so aliases such
+// as "haiku" and qualified names such as "openai.o3-mini.low" select the
+// provider as well as the model.
+func NewLLM(name string, opts ...LLMOption) (llm.AugmentedLLM, error) {
+	return newLLM(name, "", defaultModel, opts)
+}
+
 // NewAnthropicLLM creates and initializes a new Anthropic LLM with sensible defaults.
 // It will use environment variables and default configuration unless overridden.
 func NewAnthropicLLM(name string, opts ...LLMOption) (llm.AugmentedLLM, error) {
-	// Create base LLM
-	l := llm.NewAnthropicLLM(name)
+	return newLLM(name, "anthropic", defaultModel, opts) // Fast, cheap model by default
+}
+
+// NewOpenAILLM creates and initializes an LLM backed by an OpenAI-compatible
+// chat completions API. Use WithOpenAIBaseURL to target local servers such as
+// llama.cpp or vLLM.
+func NewOpenAILLM(name string, opts ...LLMOption) (llm.AugmentedLLM, error) {
+	return newLLM(name, "openai", "gpt-4o-mini", opts)
+}
+
+// newLLM builds settings from the options and creates the LLM through the
+// default provider registry. If provider is set, the model must belong to it.
+func newLLM(name, provider, defaultModel string, opts []LLMOption) (llm.AugmentedLLM, error) {
+	settings := newSettings(defaultModel, opts)
+	if provider != "" {
+		spec, err := llm.ResolveModelFor(provider, settings.DefaultModel)
+		if err != nil {
+			return nil, fmt.Errorf("invalid model: %w", err)
+		}
+		settings.DefaultModel = spec.String()
+	}
+
+	l, err := llm.DefaultProviders().CreateLLM(context.Background(), name, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize LLM: %w", err)
+	}
+
+	return l, nil
+}
 
-	// Load default settings
+// newSettings returns the default settings with the options applied
+func newSettings(defaultModel string, opts []LLMOption) *config.Settings {
 	settings := &config.Settings{
-		DefaultModel: "claude-3-haiku-20240307", // Fast, cheap model by default
+		DefaultModel: defaultModel,
 		Logger: config.LoggerSettings{
 			Level: "info",
 			Type:  "console",
@@ -27,19 +66,13 @@ func NewAnthropicLLM(name string, opts ...LLMOption) (llm.AugmentedLLM, error) {
 	for _, opt := range opts {
 		opt(settings)
 	}
-
-	// Initialize the LLM
-	if err := l.Initialize(context.Background(), settings); err != nil {
-		return nil, fmt.Errorf("failed to initialize LLM: %w", err)
-	}
-
-	return l, nil
+	return settings
 }
 
 // LLMOption allows customizing the LLM configuration
 type LLMOption func(*config.Settings)
 
-// WithModel sets the model to use
+// WithModel sets the model to use, e.g. "sonnet" or "openai.o3-mini.low"
 func WithModel(model string) LLMOption {
 	return func(s *config.Settings) {
 		s.DefaultModel = model
@@ -59,3 +92,39 @@ func WithLogType(logType string) LLMOption {
 		s.Logger.Type = logType
 	}
 }
+
+// WithOpenAIBaseURL sets the base URL for OpenAI-compatible providers
+func WithOpenAIBaseURL(url string) LLMOption {
+	return func(s *config.Settings) {
+		s.OpenAI.BaseURL = url
+	}
+}
+
+// WithOpenAIAPIKey sets the API key for OpenAI-compatible providers
+func WithOpenAIAPIKey(key string) LLMOption {
+	return func(s *config.Settings) {
+		s.OpenAI.APIKey = key
+	}
+}
+
+// WithMemoryStrategy selects how agents keep their conversation history
+// within the context window, e.g. a token_window of 8000 tokens
+func WithMemoryStrategy(memory config.MemorySettings) LLMOption {
+	return func(s *config.Settings) {
+		s.Memory = memory
+	}
+}
+
+// WithMCPServer adds an MCP server whose tools NewApp makes available to
+// every agent, e.g. a stdio server started with "uvx mcp-server-fetch"
+func WithMCPServer(name string, server config.MCPServerSettings) LLMOption {
+	return func(s *config.Settings) {
+		if s.MCP.Servers == nil {
+			s.MCP.Servers = make(map[string]config.MCPServerSettings)
+		}
+		if server.Transport == "" {
+			server.Transport = "stdio"
+		}
+		s.MCP.Servers[name] = server
+	}
+}
@@ -3,8 +3,10 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -22,6 +24,8 @@ type AnthropicLLM struct {
 	logger   logging.Logger
 	defaults *RequestParams
 	tools    *tools.SimpleToolRegistry
+	retry    *RetryPolicy
+	limiter  *RateLimiter
 }
 
 // NewAnthropicLLM creates a new AnthropicLLM instance
@@ -31,10 +35,11 @@ func NewAnthropicLLM(name string) *AnthropicLLM {
 		memory: NewSimpleMemory(),
 		logger: logging.GetLogger("llm.anthropic"),
 		tools:  tools.NewSimpleToolRegistry(),
+		retry:  DefaultRetryPolicy(),
 		defaults: &RequestParams{
 			Model:         "claude-3-haiku-20240307",
 			UseHistory:    true,
-			ParallelTools: true,
+			ParallelTools: Bool(true),
 			MaxIterations: 10,
 			MaxTokens:     1024,
 		},
@@ -43,60 +48,112 @@ func NewAnthropicLLM(name string) *AnthropicLLM {
 
 // Initialize sets up the LLM with configuration
 func (l *AnthropicLLM) Initialize(ctx context.Context, cfg *config.Settings) error {
-	// For now, we'll use an environment variable. Later we can add proper config
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	var settings config.AnthropicSettings
+	if cfg != nil {
+		settings = cfg.Anthropic
+	}
+
+	apiKey := settings.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
 	if apiKey == "" {
 		return fmt.Errorf("ANTHROPIC_API_KEY environment variable is required")
 	}
+	baseURL := settings.BaseURL
+	if baseURL == "" {
+		baseURL = os.Getenv("ANTHROPIC_BASE_URL")
+	}
 
-	client := anthropic.NewClient(
+	if cfg != nil && cfg.DefaultModel != "" {
+		spec, err := ResolveModelFor(l.Provider(), cfg.DefaultModel)
+		if err != nil {
+			return fmt.Errorf("invalid default model: %w", err)
+		}
+		l.defaults.Model = spec.Model
+		l.defaults.ReasoningEffort = spec.ReasoningEffort
+	}
+
+	if cfg != nil {
+		l.retry = NewRetryPolicy(cfg.Retry)
+		l.limiter = NewRateLimiter(cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst)
+		if cfg.Memory.Strategy != "" {
+			memory, err := NewMemory(cfg.Memory, l)
+			if err != nil {
+				return fmt.Errorf("invalid memory settings: %w", err)
+			}
+			l.memory = memory
+		}
+	}
+
+	// Retries are handled by our RetryPolicy so they can be configured and
+	// coordinated with the rate limiter
+	opts := []option.RequestOption{
 		option.WithAPIKey(apiKey),
-	)
+		option.WithMaxRetries(0),
+	}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	client := anthropic.NewClient(opts...)
 	l.client = &client
 	return nil
 }
 
 // Generate processes a message and returns a response
 func (l *AnthropicLLM) Generate(ctx context.Context, msg Message, params *RequestParams) (Message, error) {
+	return l.generate(ctx, msg, params, nil)
+}
+
+// GenerateStream processes a message using the streaming Messages API,
+// emitting text deltas as they arrive along with tool activity and usage
+func (l *AnthropicLLM) GenerateStream(ctx context.Context, msg Message, params *RequestParams) (<-chan StreamEvent, error) {
+	return runStream(ctx, func(emit emitFunc) (Message, error) {
+		return l.generate(ctx, msg, params, emit)
+	}), nil
+}
+
+// generate runs the request and tool loop. When emit is set, responses are
+// streamed and progress is reported to it.
+func (l *AnthropicLLM) generate(ctx context.Context, msg Message, params *RequestParams, emit emitFunc) (Message, error) {
 	l.logger.Info(ctx, "Generating response", logging.WithData(map[string]interface{}{
 		"content": msg.Content,
 		"type":    msg.Type,
 	}))
 
-	// Store user message in history if enabled
-	if params != nil && params.UseHistory {
-		if err := l.memory.Add(msg, false); err != nil {
-			return Message{}, fmt.Errorf("failed to add message to history: %w", err)
-		}
+	// Prepare request parameters
+	reqParams := mergeRequestParams(l.defaults, params)
+	memory := requestMemory(reqParams, l.memory)
+	if err := resolveRequestModel(l.Provider(), reqParams); err != nil {
+		return Message{}, err
 	}
 
-	// Build message list including history if needed
+	// Tools available to the model, sent under API-safe names
+	selected := selectTools(ctx, l.logger, l.tools, reqParams)
+	names := newToolNames(selected)
+
+	// Build message list: history if enabled, then the new message
 	var messages []anthropic.MessageParam
-	if params != nil && params.UseHistory {
-		history, err := l.memory.Get(true)
+	var system []anthropic.TextBlockParam
+	if reqParams.SystemPrompt != "" {
+		system = append(system, anthropic.TextBlockParam{Text: reqParams.SystemPrompt})
+	}
+	if reqParams.UseHistory {
+		history, err := memory.Get(true)
 		if err != nil {
 			return Message{}, err
 		}
-		messages = convertToAnthropicMessages(history)
-	}
-
-	// Add current message
-	messages = append(messages, anthropic.MessageParam{
-		Role: anthropic.MessageParamRoleUser,
-		Content: []anthropic.ContentBlockParamUnion{
-			{
-				OfRequestTextBlock: &anthropic.TextBlockParam{
-					Text: msg.Content,
-				},
-			},
-		},
-	})
+		messages = convertToAnthropicMessages(history, names)
 
-	// Prepare request parameters
-	reqParams := l.defaults
-	if params != nil {
-		reqParams = params
+		// System messages in history, such as conversation summaries,
+		// extend the system prompt
+		for _, m := range history {
+			if m.Type == MessageTypeSystem && m.Content != "" {
+				system = append(system, anthropic.TextBlockParam{Text: m.Content})
+			}
+		}
 	}
+	messages = append(messages, convertToAnthropicMessages([]Message{msg}, names)...)
 
 	// Create message request
 	req := anthropic.MessageNewParams{
@@ -105,59 +162,53 @@ func (l *AnthropicLLM) Generate(ctx context.Context, msg Message, params *Reques
 		MaxTokens: int64(reqParams.MaxTokens),
 	}
 
-	if reqParams.SystemPrompt != "" {
-		req.System = []anthropic.TextBlockParam{
-			{
-				Text: reqParams.SystemPrompt,
-			},
-		}
+	if len(system) > 0 {
+		req.System = system
 	}
-	if reqParams.Temperature > 0 {
-		req.Temperature = anthropic.Float(float64(reqParams.Temperature))
+	if reqParams.Temperature != nil {
+		req.Temperature = anthropic.Float(float64(*reqParams.Temperature))
 	}
 
 	// Add tools if specified
-	if len(reqParams.Tools) > 0 {
-		// Track unique tools by their name
-		uniqueToolParams := make(map[string]anthropic.ToolParam)
-
-		for _, toolName := range reqParams.Tools {
-			tool, err := l.tools.Get(toolName)
-			if err != nil {
-				l.logger.Error(ctx, "Tool not found", logging.WithData(map[string]interface{}{
-					"tool":  toolName,
-					"error": err.Error(),
-				}))
-				continue
-			}
-
-			// Skip if we already added this tool name
-			if _, exists := uniqueToolParams[tool.Name]; exists {
-				l.logger.Info(ctx, "Skipping duplicate tool", logging.WithData(map[string]interface{}{
-					"tool": tool.Name,
-				}))
-				continue
-			}
-
+	if len(selected) > 0 {
+		toolParams := make([]anthropic.ToolUnionParam, 0, len(selected))
+		toolNames := make([]string, 0, len(selected))
+		for _, tool := range selected {
 			// Convert tool schema to Anthropic's format
 			var schemaMap map[string]interface{}
 			if err := json.Unmarshal(tool.Schema, &schemaMap); err != nil {
 				l.logger.Error(ctx, "Failed to parse tool schema", logging.WithData(map[string]interface{}{
-					"tool":  toolName,
+					"tool":  tool.Name,
 					"error": err.Error(),
 				}))
 				continue
 			}
+			properties, _ := schemaMap["properties"].(map[string]interface{})
 
-			// Create tool parameter using just the tool name (no version)
-			uniqueToolParams[tool.Name] = anthropic.ToolParam{
-				Name:        tool.Name, // Use just the name without version
+			inputSchema := anthropic.ToolInputSchemaParam{
+				Type:       "object",
+				Properties: properties,
+			}
+			// Keys besides type and properties, such as required, are
+			// passed through so the model sees the whole schema. This SDK
+			// version encodes extra fields set with WithExtraFields only.
+			extra := make(map[string]interface{})
+			for key, value := range schemaMap {
+				if key != "type" && key != "properties" {
+					extra[key] = value
+				}
+			}
+			if len(extra) > 0 {
+				inputSchema.WithExtraFields(extra)
+			}
+
+			param := anthropic.ToolParam{
+				Name:        names.apiName(tool.Name),
 				Description: anthropic.String(tool.Description),
-				InputSchema: anthropic.ToolInputSchemaParam{
-					Type:       "object",
-					Properties: schemaMap["properties"].(map[string]interface{}),
-				},
+				InputSchema: inputSchema,
 			}
+			toolParams = append(toolParams, anthropic.ToolUnionParam{OfTool: &param})
+			toolNames = append(toolNames, tool.Name)
 
 			l.logger.Info(ctx, "Added tool", logging.WithData(map[string]interface{}{
 				"tool":   tool.Name,
@@ -165,35 +216,19 @@ func (l *AnthropicLLM) Generate(ctx context.Context, msg Message, params *Reques
 			}))
 		}
 
-		// Convert the unique tools to the format Anthropic expects
-		toolParams := make([]anthropic.ToolParam, 0, len(uniqueToolParams))
-		for _, param := range uniqueToolParams {
-			toolParams = append(toolParams, param)
-		}
-
 		if len(toolParams) > 0 {
-			// Convert to union type and add to request
-			tools := make([]anthropic.ToolUnionParam, len(toolParams))
-			for i, param := range toolParams {
-				tools[i] = anthropic.ToolUnionParam{OfTool: &param}
-			}
-			req.Tools = tools
+			req.Tools = toolParams
 
 			l.logger.Info(ctx, "Request with tools", logging.WithData(map[string]interface{}{
-				"tools_count": len(tools),
-				"tool_names": func() []string {
-					names := make([]string, len(toolParams))
-					for i, p := range toolParams {
-						names[i] = p.Name
-					}
-					return names
-				}(),
+				"tools_count": len(toolParams),
+				"tool_names":  toolNames,
 			}))
 		}
 	}
 
 	// Make initial API call
-	resp, err := l.client.Messages.New(ctx, req)
+	var usage Usage
+	resp, err := l.createMessage(ctx, req, emit, &usage)
 	if err != nil {
 		l.logger.Error(ctx, "Anthropic API error", logging.WithData(map[string]interface{}{
 			"error": err.Error(),
@@ -205,104 +240,54 @@ func (l *AnthropicLLM) Generate(ctx context.Context, msg Message, params *Reques
 		"content": resp.Content,
 	}))
 
-	// Process the response and handle tool calls
-	var finalResponse string
-	messages = append(messages, resp.ToParam())
-
 	// Tool calling loop - continue until no more tool calls or max iterations reached
+	var toolCalls []ToolCall
+	turns := []Message{msg}
 	iterCount := 0
 	maxIterations := reqParams.MaxIterations
 	if maxIterations <= 0 {
 		maxIterations = 10 // Default max iterations if not specified
 	}
 
-	for iterCount < maxIterations {
+	text, toolUses := splitAnthropicContent(resp)
+	for iterCount < maxIterations && len(toolUses) > 0 {
 		iterCount++
 		l.logger.Info(ctx, "Tool iteration", logging.WithData(map[string]interface{}{
 			"iteration": iterCount,
 			"max":       maxIterations,
 		}))
 
-		var toolResults []anthropic.ContentBlockParamUnion
-		var hasToolCalls bool
-
-		// Process each block in the response
-		for _, block := range resp.Content {
-			switch variant := block.AsAny().(type) {
-			case anthropic.TextBlock:
-				finalResponse = variant.Text
-			case anthropic.ToolUseBlock:
-				hasToolCalls = true
-				l.logger.Info(ctx, "Tool use request", logging.WithData(map[string]interface{}{
-					"tool":  variant.Name,
-					"input": variant.JSON.Input.Raw(),
-				}))
-
-				// Parse the input
-				var args map[string]any
-				if err := json.Unmarshal([]byte(variant.JSON.Input.Raw()), &args); err != nil {
-					errMsg := fmt.Sprintf("Failed to parse tool input: %s", err.Error())
-					l.logger.Error(ctx, errMsg, logging.WithData(map[string]interface{}{
-						"tool":  variant.Name,
-						"error": err.Error(),
-					}))
-
-					// Add error result so LLM can handle it
-					toolResults = append(toolResults, anthropic.NewToolResultBlock(
-						variant.ID,
-						errMsg,
-						true,
-					))
-					continue
-				}
-
-				// Execute the tool using the name as is (no version info)
-				result, err := l.tools.Call(ctx, variant.Name, args)
-				if err != nil {
-					errMsg := fmt.Sprintf("Tool execution failed: %s", err.Error())
-					l.logger.Error(ctx, errMsg, logging.WithData(map[string]interface{}{
-						"tool":  variant.Name,
-						"error": err.Error(),
-					}))
-
-					// Add error result so LLM can handle it
-					toolResults = append(toolResults, anthropic.NewToolResultBlock(
-						variant.ID,
-						errMsg,
-						true,
-					))
-					continue
-				}
-
-				l.logger.Info(ctx, "Tool result", logging.WithData(map[string]interface{}{
-					"tool":   variant.Name,
-					"result": result,
-				}))
-
-				// Add successful tool result
-				toolResults = append(toolResults, anthropic.NewToolResultBlock(
-					variant.ID,
-					result.Content,
-					result.IsError,
-				))
-			}
+		// Map the API names the model used back to registry names
+		calls := make([]ToolCall, len(toolUses))
+		for i := range toolUses {
+			toolUses[i].Name = names.toolName(toolUses[i].Name)
+			use := toolUses[i]
+			calls[i] = ToolCall{ID: use.ID, Name: use.Name, Args: parseToolArguments(string(use.Input))}
 		}
-
-		// If no tool calls, we're done
-		if !hasToolCalls || len(toolResults) == 0 {
-			break
+		uses := toolUses
+		results := executeToolCalls(ctx, calls, reqParams.parallelTools(), reqParams.MaxParallelTools, emit,
+			availableRunner(names, calls, approvedRunner(l.tools, reqParams.Approval, calls, func(ctx context.Context, i int) tools.ToolResult {
+				return l.executeToolUse(ctx, uses[i], emit)
+			})))
+
+		toolResults := make([]anthropic.ContentBlockParamUnion, len(results))
+		for i, result := range results {
+			toolResults[i] = anthropic.NewToolResultBlock(uses[i].ID, result.Content, result.IsError)
+			usage.addTool(result.Cost)
 		}
+		toolCalls = append(toolCalls, calls...)
+		turns = append(turns, toolUseTurn(l.name, text, calls)...)
 
 		// Add tool results and make another API call
 		l.logger.Info(ctx, "Sending tool results back to LLM", logging.WithData(map[string]interface{}{
 			"results_count": len(toolResults),
 		}))
 
-		messages = append(messages, anthropic.NewUserMessage(toolResults...))
+		messages = append(messages, resp.ToParam(), anthropic.NewUserMessage(toolResults...))
 		req.Messages = messages
 
 		// Make follow-up API call with tool results
-		resp, err = l.client.Messages.New(ctx, req)
+		resp, err = l.createMessage(ctx, req, emit, &usage)
 		if err != nil {
 			l.logger.Error(ctx, "Anthropic API error after tool calls", logging.WithData(map[string]interface{}{
 				"error": err.Error(),
@@ -313,19 +298,11 @@ func (l *AnthropicLLM) Generate(ctx context.Context, msg Message, params *Reques
 		l.logger.Info(ctx, "API response after tool calls", logging.WithData(map[string]interface{}{
 			"content": resp.Content,
 		}))
-
-		messages = append(messages, resp.ToParam())
-
-		// Update final response from the latest response
-		for _, block := range resp.Content {
-			if textBlock, ok := block.AsAny().(anthropic.TextBlock); ok {
-				finalResponse = textBlock.Text
-			}
-		}
+		text, toolUses = splitAnthropicContent(resp)
 	}
 
 	// Check if we hit the max iterations limit
-	if iterCount >= maxIterations {
+	if len(toolUses) > 0 {
 		l.logger.Error(ctx, "Reached maximum tool call iterations", logging.WithData(map[string]interface{}{
 			"max_iterations": maxIterations,
 		}))
@@ -333,26 +310,137 @@ func (l *AnthropicLLM) Generate(ctx context.Context, msg Message, params *Reques
 
 	// Build the final response message
 	response := Message{
-		Type:    MessageTypeAssistant,
-		Content: finalResponse,
-		Name:    l.name,
+		Type:      MessageTypeAssistant,
+		Content:   text,
+		Name:      l.name,
+		ToolCalls: toolCalls,
+		Usage:     &usage,
 	}
 
-	// Store response in history if enabled
+	// Store the exchange in history if enabled: the user message, each
+	// round of tool use and the final answer
 	if reqParams.UseHistory {
-		if err := l.memory.Add(response, false); err != nil {
-			return Message{}, fmt.Errorf("failed to add response to history: %w", err)
+		turns = append(turns, Message{
+			Type:    MessageTypeAssistant,
+			Content: response.Content,
+			Name:    l.name,
+		})
+		for _, turn := range turns {
+			if err := memory.Add(turn, false); err != nil {
+				return Message{}, fmt.Errorf("failed to add response to history: %w", err)
+			}
 		}
 	}
 
 	l.logger.Info(ctx, "Generated final response", logging.WithData(map[string]interface{}{
 		"content":    response.Content,
 		"iterations": iterCount,
+		"usage":      usage,
 	}))
 
 	return response, nil
 }
 
+// executeToolUse runs a tool requested by the model. Failures are returned
+// as error results so the model can handle them.
+func (l *AnthropicLLM) executeToolUse(ctx context.Context, use anthropic.ToolUseBlock, emit emitFunc) tools.ToolResult {
+	l.logger.Info(ctx, "Tool use request", logging.WithData(map[string]interface{}{
+		"tool":  use.Name,
+		"input": string(use.Input),
+	}))
+
+	var args map[string]any
+	if err := json.Unmarshal(use.Input, &args); err != nil {
+		errMsg := fmt.Sprintf("Failed to parse tool input: %s", err.Error())
+		l.logger.Error(ctx, errMsg, logging.WithData(map[string]interface{}{
+			"tool":  use.Name,
+			"error": err.Error(),
+		}))
+		return tools.ToolResult{Content: errMsg, IsError: true}
+	}
+
+	// Execute the tool using the name as is (no version info)
+	result, err := callTool(ctx, l.tools, ToolCall{ID: use.ID, Name: use.Name, Args: args}, emit)
+	if err != nil {
+		errMsg := fmt.Sprintf("Tool execution failed: %s", err.Error())
+		l.logger.Error(ctx, errMsg, logging.WithData(map[string]interface{}{
+			"tool":  use.Name,
+			"error": err.Error(),
+		}))
+		return tools.ToolResult{Content: errMsg, IsError: true}
+	}
+
+	l.logger.Info(ctx, "Tool result", logging.WithData(map[string]interface{}{
+		"tool":   use.Name,
+		"result": result,
+	}))
+	return result
+}
+
+// createMessage makes a single Messages API call. With an emitter the
+// streaming endpoint is used and text deltas are forwarded as they arrive;
+// either way the complete message is returned and its usage is reported
+// and added to usage.
+func (l *AnthropicLLM) createMessage(ctx context.Context, req anthropic.MessageNewParams, emit emitFunc, usage *Usage) (*anthropic.Message, error) {
+	var resp *anthropic.Message
+	err := l.retry.Do(ctx, l.logger, func() error {
+		if err := l.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		var err error
+		resp, err = l.sendMessage(ctx, req, emit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	callUsage := Usage{
+		Requests:                 1,
+		InputTokens:              int(resp.Usage.InputTokens),
+		OutputTokens:             int(resp.Usage.OutputTokens),
+		CacheCreationInputTokens: int(resp.Usage.CacheCreationInputTokens),
+		CacheReadInputTokens:     int(resp.Usage.CacheReadInputTokens),
+	}
+	priceUsage(string(req.Model), &callUsage)
+	usage.Add(callUsage)
+
+	emit.send(StreamEvent{Type: StreamEventUsage, Usage: &callUsage})
+	return resp, nil
+}
+
+// sendMessage makes one attempt at a Messages API call, streaming when an
+// emitter is set. A stream that fails after delivering text is not retried,
+// since the caller has already seen part of the response.
+func (l *AnthropicLLM) sendMessage(ctx context.Context, req anthropic.MessageNewParams, emit emitFunc) (*anthropic.Message, error) {
+	if emit == nil {
+		return l.client.Messages.New(ctx, req)
+	}
+
+	stream := l.client.Messages.NewStreaming(ctx, req)
+	defer stream.Close()
+
+	resp := &anthropic.Message{}
+	delivered := false
+	for stream.Next() {
+		event := stream.Current()
+		if err := resp.Accumulate(event); err != nil {
+			return nil, fmt.Errorf("failed to accumulate stream event: %w", err)
+		}
+		if event.Type == "content_block_delta" && event.Delta.Type == "text_delta" {
+			emit.send(StreamEvent{Type: StreamEventTextDelta, Text: event.Delta.Text})
+			delivered = true
+		}
+	}
+	if err := stream.Err(); err != nil {
+		if delivered {
+			return nil, &nonRetryableError{err: err}
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
 // GenerateString is a convenience method for simple text interactions
 func (l *AnthropicLLM) GenerateString(ctx context.Context, content string, params *RequestParams) (string, error) {
 	msg := Message{
@@ -392,9 +480,10 @@ func (l *AnthropicLLM) Provider() string {
 	return "anthropic"
 }
 
-// Cleanup performs any necessary cleanup
+// Cleanup closes the tool registry, cleaning up its tools and providers,
+// and clears the conversation history
 func (l *AnthropicLLM) Cleanup() error {
-	return l.memory.Clear(true)
+	return errors.Join(l.tools.Close(), l.memory.Clear(true))
 }
 
 // Tools returns the tool registry for this LLM
@@ -429,27 +518,60 @@ func (l *AnthropicLLM) ExecuteTool(ctx context.Context, toolName string, args ma
 
 // Helper functions
 
-func convertToAnthropicMessages(msgs []Message) []anthropic.MessageParam {
-	result := make([]anthropic.MessageParam, len(msgs))
-	for i, msg := range msgs {
-		role := anthropic.MessageParamRoleUser
+// convertToAnthropicMessages converts history to Anthropic messages, turning
+// assistant tool calls into tool_use blocks, named as names sends them, and
+// tool messages into tool_result blocks
+func convertToAnthropicMessages(msgs []Message, names *toolNames) []anthropic.MessageParam {
+	result := make([]anthropic.MessageParam, 0, len(msgs))
+	for _, msg := range msgs {
 		switch msg.Type {
-		case MessageTypeAssistant:
-			role = anthropic.MessageParamRoleAssistant
 		case MessageTypeSystem:
 			// System messages are handled differently in Anthropic's API
 			continue
-		}
-		result[i] = anthropic.MessageParam{
-			Role: role,
-			Content: []anthropic.ContentBlockParamUnion{
-				{
-					OfRequestTextBlock: &anthropic.TextBlockParam{
-						Text: msg.Content,
+		case MessageTypeTool:
+			// Tool results are sent back as a user turn
+			blocks := make([]anthropic.ContentBlockParamUnion, len(msg.ToolCalls))
+			for i, call := range msg.ToolCalls {
+				blocks[i] = anthropic.NewToolResultBlock(call.ID, call.Response, call.IsError)
+			}
+			result = append(result, anthropic.NewUserMessage(blocks...))
+		case MessageTypeAssistant:
+			var blocks []anthropic.ContentBlockParamUnion
+			if msg.Content != "" || len(msg.ToolCalls) == 0 {
+				blocks = append(blocks, anthropic.NewTextBlock(msg.Content))
+			}
+			for _, call := range msg.ToolCalls {
+				args := call.Args
+				if args == nil {
+					args = map[string]any{}
+				}
+				blocks = append(blocks, anthropic.ContentBlockParamUnion{
+					OfRequestToolUseBlock: &anthropic.ToolUseBlockParam{
+						ID:    call.ID,
+						Name:  names.apiName(call.Name),
+						Input: args,
 					},
-				},
-			},
+				})
+			}
+			result = append(result, anthropic.NewAssistantMessage(blocks...))
+		default:
+			result = append(result, anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Content)))
 		}
 	}
 	return result
 }
+
+// splitAnthropicContent returns the text of a response and the tool calls it requests
+func splitAnthropicContent(resp *anthropic.Message) (string, []anthropic.ToolUseBlock) {
+	var text strings.Builder
+	var toolUses []anthropic.ToolUseBlock
+	for _, block := range resp.Content {
+		switch variant := block.AsAny().(type) {
+		case anthropic.TextBlock:
+			text.WriteString(variant.Text)
+		case anthropic.ToolUseBlock:
+			toolUses = append(toolUses, variant)
+		}
+	}
+	return text.String(), toolUses
+}
@@ -0,0 +1,558 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/tools"
+)
+
+// fakeAnthropicTurn is one scripted assistant response: text chunks and an
+// optional tool use whose input JSON is delivered in chunks
+type fakeAnthropicTurn struct {
+	text      []string
+	toolName  string
+	toolInput []string
+}
+
+// fakeAnthropicServer records Messages API requests and replies with
+// scripted turns, as server-sent events when the request asks to stream
+type fakeAnthropicServer struct {
+	mu       sync.Mutex
+	requests []map[string]any
+	turns    []fakeAnthropicTurn
+}
+
+func (f *fakeAnthropicServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		f.mu.Lock()
+		f.requests = append(f.requests, req)
+		turn := fakeAnthropicTurn{text: []string{"out of script"}}
+		if len(f.turns) > 0 {
+			turn = f.turns[0]
+			f.turns = f.turns[1:]
+		}
+		f.mu.Unlock()
+
+		if stream, _ := req["stream"].(bool); stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, event := range turn.events() {
+				data, err := json.Marshal(event)
+				require.NoError(t, err)
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event["type"], data)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(turn.message()))
+	}
+}
+
+func (turn fakeAnthropicTurn) message() map[string]any {
+	var content []map[string]any
+	if len(turn.text) > 0 {
+		content = append(content, map[string]any{"type": "text", "text": strings.Join(turn.text, "")})
+	}
+	if turn.toolName != "" {
+		content = append(content, map[string]any{
+			"type":  "tool_use",
+			"id":    "toolu_" + turn.toolName,
+			"name":  turn.toolName,
+			"input": json.RawMessage(strings.Join(turn.toolInput, "")),
+		})
+	}
+	return map[string]any{
+		"id":            "msg_test",
+		"type":          "message",
+		"role":          "assistant",
+		"model":         "claude-3-haiku-20240307",
+		"content":       content,
+		"stop_reason":   "end_turn",
+		"stop_sequence": nil,
+		"usage":         map[string]any{"input_tokens": 10, "output_tokens": 5},
+	}
+}
+
+func (turn fakeAnthropicTurn) events() []map[string]any {
+	start := turn.message()
+	start["content"] = []any{}
+	start["usage"] = map[string]any{"input_tokens": 10, "output_tokens": 1}
+	events := []map[string]any{{"type": "message_start", "message": start}}
+
+	index := 0
+	if len(turn.text) > 0 {
+		events = append(events, map[string]any{
+			"type": "content_block_start", "index": index,
+			"content_block": map[string]any{"type": "text", "text": ""},
+		})
+		for _, chunk := range turn.text {
+			events = append(events, map[string]any{
+				"type": "content_block_delta", "index": index,
+				"delta": map[string]any{"type": "text_delta", "text": chunk},
+			})
+		}
+		events = append(events, map[string]any{"type": "content_block_stop", "index": index})
+		index++
+	}
+	if turn.toolName != "" {
+		events = append(events, map[string]any{
+			"type": "content_block_start", "index": index,
+			"content_block": map[string]any{
+				"type": "tool_use", "id": "toolu_" + turn.toolName, "name": turn.toolName, "input": map[string]any{},
+			},
+		})
+		for _, chunk := range turn.toolInput {
+			events = append(events, map[string]any{
+				"type": "content_block_delta", "index": index,
+				"delta": map[string]any{"type": "input_json_delta", "partial_json": chunk},
+			})
+		}
+		events = append(events, map[string]any{"type": "content_block_stop", "index": index})
+	}
+
+	return append(events,
+		map[string]any{
+			"type":  "message_delta",
+			"delta": map[string]any{"stop_reason": "end_turn", "stop_sequence": nil},
+			"usage": map[string]any{"output_tokens": 5},
+		},
+		map[string]any{"type": "message_stop"},
+	)
+}
+
+func newTestAnthropicLLM(t *testing.T, fake *fakeAnthropicServer) *AnthropicLLM {
+	server := httptest.NewServer(fake.handler(t))
+	t.Cleanup(server.Close)
+
+	l := NewAnthropicLLM("test")
+	client := anthropic.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithAPIKey("test-key"),
+		option.WithMaxRetries(0),
+	)
+	l.client = &client
+	return l
+}
+
+func TestAnthropicLLM_GenerateStream(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("text deltas", func(t *testing.T) {
+		fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{{text: []string{"Hel", "lo ", "there"}}}}
+		l := newTestAnthropicLLM(t, fake)
+
+		events, err := l.GenerateStream(ctx, Message{Type: MessageTypeUser, Content: "hi"}, nil)
+		require.NoError(t, err)
+
+		var deltas []string
+		var usage Usage
+		var final *Message
+		for event := range events {
+			switch event.Type {
+			case StreamEventTextDelta:
+				deltas = append(deltas, event.Text)
+			case StreamEventUsage:
+				usage.Add(*event.Usage)
+			case StreamEventMessage:
+				final = event.Message
+			case StreamEventError:
+				t.Fatalf("unexpected error: %v", event.Err)
+			}
+		}
+
+		assert.Equal(t, []string{"Hel", "lo ", "there"}, deltas)
+		assert.Equal(t, 1, usage.Requests)
+		assert.Equal(t, 10, usage.InputTokens)
+		assert.Equal(t, 5, usage.OutputTokens)
+		assert.InDelta(t, (10*0.25+5*1.25)/1_000_000, usage.Cost, 1e-12)
+		require.NotNil(t, final)
+		assert.Equal(t, "Hello there", final.Content)
+		assert.Equal(t, &usage, final.Usage)
+		require.Len(t, fake.requests, 1)
+		assert.Equal(t, true, fake.requests[0]["stream"])
+	})
+
+	t.Run("tool use", func(t *testing.T) {
+		fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{
+			{text: []string{"Let me check."}, toolName: "echo", toolInput: []string{`{"input":`, `"ping"}`}},
+			{text: []string{"It said ", "ping"}},
+		}}
+		l := newTestAnthropicLLM(t, fake)
+		require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "echo", "Echo input", func(s string) string {
+			return s
+		}))
+
+		events, err := l.GenerateStream(ctx, Message{Type: MessageTypeUser, Content: "call echo"}, &RequestParams{
+			Tools: []string{"echo"},
+		})
+		require.NoError(t, err)
+
+		var types []StreamEventType
+		var toolResult *ToolCall
+		for event := range events {
+			if event.Type == StreamEventUsage {
+				continue
+			}
+			types = append(types, event.Type)
+			if event.Type == StreamEventToolResult {
+				toolResult = event.ToolCall
+			}
+		}
+
+		assert.Equal(t, []StreamEventType{
+			StreamEventTextDelta,
+			StreamEventToolUse,
+			StreamEventToolResult,
+			StreamEventTextDelta,
+			StreamEventTextDelta,
+			StreamEventMessage,
+		}, types)
+		require.NotNil(t, toolResult)
+		assert.Equal(t, "echo", toolResult.Name)
+		assert.Equal(t, map[string]any{"input": "ping"}, toolResult.Args)
+		assert.Equal(t, "ping", toolResult.Response)
+		assert.Len(t, fake.requests, 2)
+	})
+
+	t.Run("streaming tool", func(t *testing.T) {
+		fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{
+			{toolName: "index", toolInput: []string{`{}`}},
+			{text: []string{"Indexed"}},
+		}}
+		l := newTestAnthropicLLM(t, fake)
+		require.NoError(t, l.Tools().Register(tools.New("index").
+			WithStreamHandler(func(ctx context.Context, args map[string]any, out chan<- tools.ToolResult) error {
+				out <- tools.NewProgressResult(0.5, "half way")
+				out <- tools.NewChunkResult("a.go b.go")
+				return nil
+			}).
+			Build()))
+
+		events, err := l.GenerateStream(ctx, Message{Type: MessageTypeUser, Content: "index"}, &RequestParams{
+			Tools: []string{"index"},
+		})
+		require.NoError(t, err)
+
+		var parts []tools.ToolResult
+		var toolResult *ToolCall
+		for event := range events {
+			switch event.Type {
+			case StreamEventToolProgress:
+				assert.Equal(t, "index", event.ToolCall.Name)
+				parts = append(parts, *event.Partial)
+			case StreamEventToolResult:
+				toolResult = event.ToolCall
+			}
+		}
+
+		require.Len(t, parts, 2)
+		assert.Equal(t, 0.5, parts[0].Progress)
+		assert.Equal(t, "a.go b.go", parts[1].Content)
+		require.NotNil(t, toolResult)
+		assert.Equal(t, "a.go b.go", toolResult.Response)
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`))
+		}))
+		t.Cleanup(server.Close)
+
+		l := NewAnthropicLLM("test")
+		client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test-key"), option.WithMaxRetries(0))
+		l.client = &client
+
+		events, err := l.GenerateStream(ctx, Message{Type: MessageTypeUser, Content: "hi"}, nil)
+		require.NoError(t, err)
+
+		_, err = CollectStream(events)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "anthropic API error")
+	})
+
+	t.Run("generate without streaming", func(t *testing.T) {
+		fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{{text: []string{"plain response"}}}}
+		l := newTestAnthropicLLM(t, fake)
+
+		resp, err := l.GenerateString(ctx, "hi", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "plain response", resp)
+		require.Len(t, fake.requests, 1)
+		assert.Nil(t, fake.requests[0]["stream"])
+	})
+}
+
+func TestAnthropicLLM_History(t *testing.T) {
+	ctx := context.Background()
+
+	fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{
+		{text: []string{"Let me check."}, toolName: "echo", toolInput: []string{`{"input":"ping"}`}},
+		{text: []string{"It said ping"}},
+		{text: []string{"You already asked"}},
+	}}
+	l := newTestAnthropicLLM(t, fake)
+	require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "echo", "Echo input", func(s string) string {
+		return s
+	}))
+	params := &RequestParams{UseHistory: true, Tools: []string{"echo"}}
+
+	_, err := l.GenerateString(ctx, "call echo", params)
+	require.NoError(t, err)
+
+	// The new message is sent once, not duplicated from history
+	require.Len(t, fake.requests, 2)
+	assert.Len(t, fake.requests[0]["messages"], 1)
+
+	_, err = l.GenerateString(ctx, "again?", params)
+	require.NoError(t, err)
+
+	history, err := l.memory.Get(true)
+	require.NoError(t, err)
+	require.Len(t, history, 6)
+	assert.Equal(t, MessageTypeAssistant, history[1].Type)
+	assert.Equal(t, "Let me check.", history[1].Content)
+	require.Len(t, history[1].ToolCalls, 1)
+	assert.Equal(t, map[string]any{"input": "ping"}, history[1].ToolCalls[0].Args)
+	assert.Equal(t, MessageTypeTool, history[2].Type)
+	assert.Equal(t, "ping", history[2].ToolCalls[0].Response)
+
+	require.Len(t, fake.requests, 3)
+	data, err := json.Marshal(fake.requests[2]["messages"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"role": "user", "content": [{"type": "text", "text": "call echo"}]},
+		{"role": "assistant", "content": [
+			{"type": "text", "text": "Let me check."},
+			{"type": "tool_use", "id": "toolu_echo", "name": "echo", "input": {"input": "ping"}}
+		]},
+		{"role": "user", "content": [
+			{"type": "tool_result", "tool_use_id": "toolu_echo", "is_error": false,
+			 "content": [{"type": "text", "text": "ping"}]}
+		]},
+		{"role": "assistant", "content": [{"type": "text", "text": "It said ping"}]},
+		{"role": "user", "content": [{"type": "text", "text": "again?"}]}
+	]`, string(data))
+}
+
+func TestAnthropicLLM_SummaryInSystem(t *testing.T) {
+	fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{{text: []string{"ok"}}}}
+	l := newTestAnthropicLLM(t, fake)
+
+	memory := NewSimpleMemory()
+	require.NoError(t, memory.Add(Message{Type: MessageTypeSystem, Content: "Earlier: the user likes tea"}, false))
+	require.NoError(t, memory.Add(Message{Type: MessageTypeUser, Content: "hello"}, false))
+
+	_, err := l.GenerateString(context.Background(), "what do I like?", &RequestParams{
+		SystemPrompt: "Be brief",
+		UseHistory:   true,
+		Memory:       memory,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, fake.requests, 1)
+	data, err := json.Marshal(fake.requests[0]["system"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"type": "text", "text": "Be brief"},
+		{"type": "text", "text": "Earlier: the user likes tea"}
+	]`, string(data))
+	assert.Len(t, fake.requests[0]["messages"], 2)
+}
+
+func TestStreamGenerated(t *testing.T) {
+	l := NewPassthroughLLM("echo")
+	require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "echo", "Echo input", func(s string) string {
+		return s
+	}))
+
+	events, err := l.GenerateStream(context.Background(), Message{
+		Type:    MessageTypeUser,
+		Content: `***CALL_TOOL echo {"input":"ping"}`,
+	}, &RequestParams{Tools: []string{"echo"}})
+	require.NoError(t, err)
+
+	var types []StreamEventType
+	for event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []StreamEventType{
+		StreamEventToolUse,
+		StreamEventToolResult,
+		StreamEventTextDelta,
+		StreamEventMessage,
+	}, types)
+}
+
+func TestAnthropicLLM_ToolApproval(t *testing.T) {
+	ctx := context.Background()
+
+	newLLM := func(t *testing.T) (*AnthropicLLM, *fakeAnthropicServer, *int) {
+		fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{
+			{toolName: "delete", toolInput: []string{`{"input":"notes.txt"}`}},
+			{text: []string{"done"}},
+		}}
+		l := newTestAnthropicLLM(t, fake)
+		deleted := 0
+		tool, err := tools.NewFunctionTool("delete", "Delete a file", func(s string) string {
+			deleted++
+			return "deleted " + s
+		})
+		require.NoError(t, err)
+		tool.Tags = []string{tools.TagRequiresApproval}
+		require.NoError(t, l.Tools().Register(tool))
+		return l, fake, &deleted
+	}
+
+	// toolResult returns the tool_result block sent back to the model
+	toolResult := func(t *testing.T, fake *fakeAnthropicServer) map[string]any {
+		require.Len(t, fake.requests, 2)
+		messages := fake.requests[1]["messages"].([]any)
+		content := messages[len(messages)-1].(map[string]any)["content"].([]any)
+		return content[0].(map[string]any)
+	}
+
+	t.Run("approved", func(t *testing.T) {
+		l, fake, deleted := newLLM(t)
+		var asked []tools.ApprovalRequest
+		policy := tools.NewApprovalPolicy(tools.ApprovalFunc(func(ctx context.Context, req tools.ApprovalRequest) (tools.Decision, error) {
+			asked = append(asked, req)
+			return tools.Decision{Approved: true}, nil
+		}))
+
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "delete notes"}, &RequestParams{
+			Tools:    []string{"delete"},
+			Approval: policy,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "done", resp.Content)
+		assert.Equal(t, 1, *deleted)
+		require.Len(t, asked, 1)
+		assert.Equal(t, "delete", asked[0].Tool.Name)
+		assert.Equal(t, map[string]any{"input": "notes.txt"}, asked[0].Args)
+		assert.Equal(t, false, toolResult(t, fake)["is_error"])
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		l, fake, deleted := newLLM(t)
+
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "delete notes"}, &RequestParams{
+			Tools:    []string{"delete"},
+			Approval: tools.NewApprovalPolicy(tools.AutoDeny("not on a Friday")),
+		})
+		require.NoError(t, err)
+		assert.Zero(t, *deleted)
+		require.Len(t, resp.ToolCalls, 1)
+		assert.True(t, resp.ToolCalls[0].IsError)
+		assert.Equal(t, `tool "delete" was not approved: not on a Friday`, resp.ToolCalls[0].Response)
+
+		result := toolResult(t, fake)
+		assert.Equal(t, true, result["is_error"])
+		assert.Contains(t, fmt.Sprint(result["content"]), "not on a Friday")
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		l, _, deleted := newLLM(t)
+
+		_, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "delete notes"}, &RequestParams{
+			Tools: []string{"delete"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, *deleted)
+	})
+}
+
+func TestAnthropicLLM_NamespacedTools(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{
+		{toolName: "docs__search", toolInput: []string{`{"input":"install"}`}},
+		{toolName: "web__fetch", toolInput: []string{`{"input":"example.com"}`}},
+		{text: []string{"done"}},
+	}}
+	l := newTestAnthropicLLM(t, fake)
+	for _, name := range []string{"docs.search", "web.fetch", "web.post"} {
+		name := name
+		tool, err := tools.NewFunctionTool(name, "Test tool", func(s string) string { return name + " " + s })
+		require.NoError(t, err)
+		require.NoError(t, l.Tools().Register(tool))
+	}
+
+	resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "look it up"}, &RequestParams{
+		Tools:        []string{"docs", "web.post"},
+		ExcludeTools: []string{"web.fetch"},
+		UseHistory:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", resp.Content)
+
+	// Tools are offered under API-safe names
+	require.Len(t, fake.requests, 3)
+	var offered []string
+	for _, tool := range fake.requests[0]["tools"].([]any) {
+		offered = append(offered, tool.(map[string]any)["name"].(string))
+	}
+	assert.Equal(t, []string{"docs__search", "web__post"}, offered)
+
+	// Calls are mapped back to registry names; tools not offered are refused
+	require.Len(t, resp.ToolCalls, 2)
+	assert.Equal(t, "docs.search", resp.ToolCalls[0].Name)
+	assert.Equal(t, "docs.search install", resp.ToolCalls[0].Response)
+	assert.Equal(t, "web__fetch", resp.ToolCalls[1].Name)
+	assert.True(t, resp.ToolCalls[1].IsError)
+	assert.Contains(t, resp.ToolCalls[1].Response, `tool "web__fetch" is not available to this request`)
+
+	// History keeps registry names and is sent with API names
+	_, err = l.Generate(ctx, Message{Type: MessageTypeUser, Content: "thanks"}, &RequestParams{
+		Tools:      []string{"docs"},
+		UseHistory: true,
+	})
+	require.NoError(t, err)
+	messages := fake.requests[3]["messages"].([]any)
+	toolUse := messages[1].(map[string]any)["content"].([]any)[0].(map[string]any)
+	assert.Equal(t, "tool_use", toolUse["type"])
+	assert.Equal(t, "docs__search", toolUse["name"])
+}
+
+func TestAnthropicLLM_ToolSchema(t *testing.T) {
+	type lookupArgs struct {
+		City  string `json:"city" jsonschema:"description=City to look up"`
+		Units string `json:"units,omitempty" jsonschema:"enum=metric,enum=imperial"`
+	}
+	fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{{text: []string{"done"}}}}
+	l := newTestAnthropicLLM(t, fake)
+	tool, err := tools.NewFunctionTool("lookup", "Look up the weather", func(args lookupArgs) string { return args.City })
+	require.NoError(t, err)
+	require.NoError(t, l.Tools().Register(tool))
+
+	_, err = l.Generate(context.Background(), Message{Type: MessageTypeUser, Content: "weather?"}, &RequestParams{
+		Tools: []string{"lookup"},
+	})
+	require.NoError(t, err)
+
+	// The whole schema is sent, including the required fields
+	require.Len(t, fake.requests, 1)
+	offered := fake.requests[0]["tools"].([]any)
+	require.Len(t, offered, 1)
+	schema := offered[0].(map[string]any)["input_schema"].(map[string]any)
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, []any{"city"}, schema["required"])
+	assert.Equal(t, map[string]any{"type": "string", "enum": []any{"metric", "imperial"}},
+		schema["properties"].(map[string]any)["units"])
+}
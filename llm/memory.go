@@ -25,6 +25,16 @@ type Memory interface {
 	Clear(clearPrompts bool) error
 }
 
+// requestMemory returns the memory a request reads history from and records
+// its exchange in: the Memory set on the request, or the LLM's own memory.
+// This lets many agents share one LLM while keeping separate conversations.
+func requestMemory(params *RequestParams, fallback Memory) Memory {
+	if params != nil && params.Memory != nil {
+		return params.Memory
+	}
+	return fallback
+}
+
 // SimpleMemory provides a basic thread-safe in-memory implementation
 type SimpleMemory struct {
 	mu      sync.RWMutex
@@ -98,7 +108,17 @@ func deepCopyMessage(msg Message) Message {
 		Metadata:  deepCopyMap(msg.Metadata),
 		ToolCalls: deepCopyToolCalls(msg.ToolCalls),
 		Parts:     deepCopyMessageParts(msg.Parts),
+		Usage:     deepCopyUsage(msg.Usage),
+	}
+}
+
+// deepCopyUsage creates a copy of a Usage pointer
+func deepCopyUsage(usage *Usage) *Usage {
+	if usage == nil {
+		return nil
 	}
+	copy := *usage
+	return &copy
 }
 
 // deepCopyMap creates a deep copy of a map[string]any
@@ -124,6 +144,7 @@ func deepCopyToolCalls(calls []ToolCall) []ToolCall {
 			ID:       call.ID,
 			Name:     call.Name,
 			Response: call.Response,
+			IsError:  call.IsError,
 			Args:     deepCopyMap(call.Args),
 		}
 	}
@@ -0,0 +1,387 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/logging"
+)
+
+// summarizeTimeout bounds how long SummarizingMemory waits for a summary
+const summarizeTimeout = 2 * time.Minute
+
+// summarizePrompt instructs the summarizer LLM
+const summarizePrompt = "You compress conversations between a user and an AI assistant. " +
+	"Summarize the conversation below, merging in any previous summary. Keep facts, " +
+	"decisions, open questions, tool results the assistant relied on and anything the " +
+	"user asked to remember. Reply with the summary only."
+
+// NewMemory creates the memory selected by settings. The summarizer is used
+// by the summarizing strategy and may be nil for the others.
+func NewMemory(cfg config.MemorySettings, summarizer AugmentedLLM) (Memory, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Strategy {
+	case "token_window":
+		return NewTokenWindowMemory(cfg.MaxTokens), nil
+	case "keep_first_last":
+		return NewKeepFirstLastMemory(cfg.KeepFirst, cfg.KeepLast), nil
+	case "summarizing":
+		if summarizer == nil {
+			return nil, fmt.Errorf("summarizing memory requires an LLM")
+		}
+		return NewSummarizingMemory(summarizer, cfg.MaxTokens), nil
+	default:
+		return NewSimpleMemory(), nil
+	}
+}
+
+// EstimateTokens approximates the number of tokens a message takes up in a
+// prompt, at about four characters per token plus a per-message overhead.
+// It is deliberately provider-agnostic; budgets should leave some headroom.
+func EstimateTokens(msg Message) int {
+	chars := len(msg.Content) + len(msg.Name)
+	for _, part := range msg.Parts {
+		chars += len(part.Content)
+	}
+	for _, call := range msg.ToolCalls {
+		chars += len(call.ID) + len(call.Name) + len(call.Response)
+		if len(call.Args) > 0 {
+			if args, err := json.Marshal(call.Args); err == nil {
+				chars += len(args)
+			}
+		}
+	}
+	return chars/4 + 4
+}
+
+// estimateTotal returns the estimated tokens of all messages
+func estimateTotal(msgs []Message) int {
+	total := 0
+	for _, msg := range msgs {
+		total += EstimateTokens(msg)
+	}
+	return total
+}
+
+// splitExchanges groups history into exchanges that each start with a user
+// message and hold the replies to it. Memory strategies drop or summarize
+// whole exchanges so a tool call is never separated from its result and the
+// remaining history still starts with a user message.
+func splitExchanges(history []Message) [][]Message {
+	var exchanges [][]Message
+	for _, msg := range history {
+		if msg.Type == MessageTypeUser || len(exchanges) == 0 {
+			exchanges = append(exchanges, nil)
+		}
+		last := len(exchanges) - 1
+		exchanges[last] = append(exchanges[last], msg)
+	}
+	return exchanges
+}
+
+// joinExchanges flattens exchanges back into a message list
+func joinExchanges(exchanges [][]Message) []Message {
+	var history []Message
+	for _, exchange := range exchanges {
+		history = append(history, exchange...)
+	}
+	return history
+}
+
+// prunedMemory is a thread-safe memory that applies a pruning strategy to
+// its history after every addition. Prompts are never pruned.
+type prunedMemory struct {
+	mu      sync.RWMutex
+	prompts []Message
+	history []Message
+	prune   func(prompts []Message, exchanges [][]Message) [][]Message
+}
+
+// Add adds a message and prunes the history
+func (m *prunedMemory) Add(msg Message, isPrompt bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if isPrompt {
+		m.prompts = append(m.prompts, deepCopyMessage(msg))
+		return nil
+	}
+	m.history = append(m.history, deepCopyMessage(msg))
+	m.history = joinExchanges(m.prune(m.prompts, splitExchanges(m.history)))
+	return nil
+}
+
+// Get retrieves prompts followed, if requested, by the retained history
+func (m *prunedMemory) Get(includeHistory bool) ([]Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Message, 0, len(m.prompts)+len(m.history))
+	for _, msg := range m.prompts {
+		result = append(result, deepCopyMessage(msg))
+	}
+	if includeHistory {
+		for _, msg := range m.history {
+			result = append(result, deepCopyMessage(msg))
+		}
+	}
+	return result, nil
+}
+
+// Clear clears the history and, if requested, the prompts
+func (m *prunedMemory) Clear(clearPrompts bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.history = nil
+	if clearPrompts {
+		m.prompts = nil
+	}
+	return nil
+}
+
+// TokenWindowMemory keeps the most recent exchanges that fit in a token
+// budget, dropping the oldest first. The latest exchange is always kept.
+type TokenWindowMemory struct {
+	prunedMemory
+	maxTokens int
+}
+
+// NewTokenWindowMemory creates a TokenWindowMemory holding at most maxTokens
+// estimated tokens of prompts and history
+func NewTokenWindowMemory(maxTokens int) *TokenWindowMemory {
+	m := &TokenWindowMemory{maxTokens: maxTokens}
+	m.prune = func(prompts []Message, exchanges [][]Message) [][]Message {
+		budget := m.maxTokens - estimateTotal(prompts)
+		total := estimateTotal(joinExchanges(exchanges))
+		for len(exchanges) > 1 && total > budget {
+			total -= estimateTotal(exchanges[0])
+			exchanges = exchanges[1:]
+		}
+		return exchanges
+	}
+	return m
+}
+
+// KeepFirstLastMemory keeps the first exchanges of a conversation, which
+// usually establish the task, and the most recent ones, dropping those in
+// between
+type KeepFirstLastMemory struct {
+	prunedMemory
+	first int
+	last  int
+}
+
+// NewKeepFirstLastMemory creates a KeepFirstLastMemory keeping the first and
+// last exchanges. At least one recent exchange is always kept.
+func NewKeepFirstLastMemory(first, last int) *KeepFirstLastMemory {
+	if first < 0 {
+		first = 0
+	}
+	if last < 1 {
+		last = 1
+	}
+	m := &KeepFirstLastMemory{first: first, last: last}
+	m.prune = func(_ []Message, exchanges [][]Message) [][]Message {
+		if len(exchanges) <= m.first+m.last {
+			return exchanges
+		}
+		kept := append([][]Message{}, exchanges[:m.first]...)
+		return append(kept, exchanges[len(exchanges)-m.last:]...)
+	}
+	return m
+}
+
+// SummarizingMemory compresses old exchanges into a summary once the history
+// exceeds a token budget. The summary is produced by an AugmentedLLM and
+// returned by Get as a system message ahead of the remaining history.
+type SummarizingMemory struct {
+	mu         sync.RWMutex
+	summarizer AugmentedLLM
+	maxTokens  int
+	prompts    []Message
+	summary    string
+	history    []Message
+	logger     logging.Logger
+}
+
+// NewSummarizingMemory creates a SummarizingMemory that keeps at most
+// maxTokens estimated tokens of summary and history. When the budget is
+// exceeded, the oldest exchanges are summarized until the recent history
+// fits in half the budget.
+func NewSummarizingMemory(summarizer AugmentedLLM, maxTokens int) *SummarizingMemory {
+	return &SummarizingMemory{
+		summarizer: summarizer,
+		maxTokens:  maxTokens,
+		logger:     logging.GetLogger("llm.memory"),
+	}
+}
+
+// Add adds a message, summarizing old exchanges if the budget is exceeded.
+// A failed summary is logged and retried on the next addition.
+func (m *SummarizingMemory) Add(msg Message, isPrompt bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if isPrompt {
+		m.prompts = append(m.prompts, deepCopyMessage(msg))
+		return nil
+	}
+	m.history = append(m.history, deepCopyMessage(msg))
+
+	if estimateTotal(m.history)+m.summaryTokens() <= m.maxTokens {
+		return nil
+	}
+
+	// Keep the most recent exchanges within half the budget, and at least
+	// the latest one, and summarize the rest
+	exchanges := splitExchanges(m.history)
+	keepFrom := len(exchanges) - 1
+	recent := estimateTotal(exchanges[keepFrom])
+	for keepFrom > 0 {
+		size := estimateTotal(exchanges[keepFrom-1])
+		if recent+size > m.maxTokens/2 {
+			break
+		}
+		recent += size
+		keepFrom--
+	}
+	if keepFrom == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), summarizeTimeout)
+	defer cancel()
+	summary, err := m.summarize(ctx, joinExchanges(exchanges[:keepFrom]))
+	if err != nil {
+		m.logger.Warning(ctx, "Failed to summarize conversation", logging.WithData(map[string]interface{}{
+			"error": err.Error(),
+		}))
+		return nil
+	}
+
+	m.summary = summary
+	m.history = joinExchanges(exchanges[keepFrom:])
+	return nil
+}
+
+// summarize asks the summarizer to merge old messages into the summary
+func (m *SummarizingMemory) summarize(ctx context.Context, old []Message) (string, error) {
+	var prompt strings.Builder
+	if m.summary != "" {
+		fmt.Fprintf(&prompt, "Previous summary:\n%s\n\n", m.summary)
+	}
+	prompt.WriteString("Conversation:\n")
+	prompt.WriteString(formatTranscript(old))
+
+	response, err := m.summarizer.Generate(ctx, Message{
+		Type:    MessageTypeUser,
+		Content: prompt.String(),
+	}, &RequestParams{
+		SystemPrompt: summarizePrompt,
+		UseHistory:   false,
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(response.Content) == "" {
+		return "", fmt.Errorf("summarizer returned an empty summary")
+	}
+	return strings.TrimSpace(response.Content), nil
+}
+
+// summaryTokens returns the estimated size of the summary message
+func (m *SummarizingMemory) summaryTokens() int {
+	if m.summary == "" {
+		return 0
+	}
+	return EstimateTokens(m.summaryMessage())
+}
+
+// summaryMessage returns the summary as a system message
+func (m *SummarizingMemory) summaryMessage() Message {
+	return Message{
+		Type:     MessageTypeSystem,
+		Content:  "Summary of the earlier conversation:\n" + m.summary,
+		Metadata: map[string]any{"summary": true},
+	}
+}
+
+// Summary returns the current summary of the summarized exchanges
+func (m *SummarizingMemory) Summary() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.summary
+}
+
+// Get retrieves prompts followed, if requested, by the summary and the
+// recent history
+func (m *SummarizingMemory) Get(includeHistory bool) ([]Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Message, 0, len(m.prompts)+len(m.history)+1)
+	for _, msg := range m.prompts {
+		result = append(result, deepCopyMessage(msg))
+	}
+	if includeHistory {
+		if m.summary != "" {
+			result = append(result, m.summaryMessage())
+		}
+		for _, msg := range m.history {
+			result = append(result, deepCopyMessage(msg))
+		}
+	}
+	return result, nil
+}
+
+// Clear clears the history and summary and, if requested, the prompts
+func (m *SummarizingMemory) Clear(clearPrompts bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.history = nil
+	m.summary = ""
+	if clearPrompts {
+		m.prompts = nil
+	}
+	return nil
+}
+
+// formatTranscript renders messages as plain text for summarization
+func formatTranscript(msgs []Message) string {
+	var b strings.Builder
+	for _, msg := range msgs {
+		switch msg.Type {
+		case MessageTypeUser:
+			fmt.Fprintf(&b, "User: %s\n", msg.GetAllText())
+		case MessageTypeAssistant:
+			if msg.Content != "" {
+				fmt.Fprintf(&b, "Assistant: %s\n", msg.GetAllText())
+			}
+			for _, call := range msg.ToolCalls {
+				args, _ := json.Marshal(call.Args)
+				fmt.Fprintf(&b, "Assistant called tool %s with %s\n", call.Name, args)
+			}
+		case MessageTypeTool:
+			for _, call := range msg.ToolCalls {
+				if call.IsError {
+					fmt.Fprintf(&b, "Tool %s failed: %s\n", call.Name, call.Response)
+				} else {
+					fmt.Fprintf(&b, "Tool %s returned: %s\n", call.Name, call.Response)
+				}
+			}
+		case MessageTypeSystem:
+			fmt.Fprintf(&b, "System: %s\n", msg.GetAllText())
+		}
+	}
+	return b.String()
+}
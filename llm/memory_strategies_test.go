@@ -0,0 +1,179 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+)
+
+// addExchange records a user message and an assistant reply of about
+// size estimated tokens each
+func addExchange(t *testing.T, m Memory, label string, size int) {
+	t.Helper()
+	text := label + strings.Repeat("x", size*4-len(label))
+	require.NoError(t, m.Add(Message{Type: MessageTypeUser, Content: text}, false))
+	require.NoError(t, m.Add(Message{Type: MessageTypeAssistant, Content: "re " + text}, false))
+}
+
+// labels returns the leading label of each user message in memory
+func labels(t *testing.T, m Memory) []string {
+	t.Helper()
+	messages, err := m.Get(true)
+	require.NoError(t, err)
+	var result []string
+	for _, msg := range messages {
+		if msg.Type == MessageTypeUser {
+			result = append(result, strings.TrimRight(msg.Content, "x"))
+		}
+	}
+	return result
+}
+
+func TestTokenWindowMemory(t *testing.T) {
+	t.Run("drops oldest exchanges over budget", func(t *testing.T) {
+		m := NewTokenWindowMemory(100)
+		for i := 1; i <= 5; i++ {
+			addExchange(t, m, fmt.Sprintf("q%d", i), 10)
+		}
+
+		// Each exchange is about 30 estimated tokens
+		assert.Equal(t, []string{"q3", "q4", "q5"}, labels(t, m))
+	})
+
+	t.Run("prompts count against the budget", func(t *testing.T) {
+		m := NewTokenWindowMemory(100)
+		require.NoError(t, m.Add(Message{Type: MessageTypeSystem, Content: strings.Repeat("p", 200)}, true))
+		for i := 1; i <= 3; i++ {
+			addExchange(t, m, fmt.Sprintf("q%d", i), 10)
+		}
+
+		assert.Equal(t, []string{"q3"}, labels(t, m))
+		prompts, err := m.Get(false)
+		require.NoError(t, err)
+		assert.Len(t, prompts, 1)
+	})
+
+	t.Run("always keeps the latest exchange", func(t *testing.T) {
+		m := NewTokenWindowMemory(10)
+		addExchange(t, m, "q1", 50)
+		addExchange(t, m, "q2", 50)
+
+		assert.Equal(t, []string{"q2"}, labels(t, m))
+	})
+
+	t.Run("keeps tool turns with their exchange", func(t *testing.T) {
+		m := NewTokenWindowMemory(60)
+		addExchange(t, m, "q1", 10)
+		require.NoError(t, m.Add(Message{Type: MessageTypeUser, Content: "q2"}, false))
+		for _, turn := range toolUseTurn("agent", "", []ToolCall{{ID: "1", Name: "fetch", Response: strings.Repeat("r", 100)}}) {
+			require.NoError(t, m.Add(turn, false))
+		}
+		require.NoError(t, m.Add(Message{Type: MessageTypeAssistant, Content: "done"}, false))
+
+		messages, err := m.Get(true)
+		require.NoError(t, err)
+		require.Len(t, messages, 4)
+		assert.Equal(t, MessageTypeUser, messages[0].Type)
+		assert.Equal(t, MessageTypeTool, messages[2].Type)
+	})
+}
+
+func TestKeepFirstLastMemory(t *testing.T) {
+	m := NewKeepFirstLastMemory(1, 2)
+	for i := 1; i <= 5; i++ {
+		addExchange(t, m, fmt.Sprintf("q%d", i), 1)
+	}
+	assert.Equal(t, []string{"q1", "q4", "q5"}, labels(t, m))
+
+	require.NoError(t, m.Clear(false))
+	assert.Empty(t, labels(t, m))
+}
+
+func TestSummarizingMemory(t *testing.T) {
+	t.Run("summarizes old exchanges", func(t *testing.T) {
+		var transcripts []string
+		summarizer := NewPassthroughLLM("summarizer").WithTransform(func(input string) string {
+			transcripts = append(transcripts, input)
+			return fmt.Sprintf("summary %d", len(transcripts))
+		})
+		m := NewSummarizingMemory(summarizer, 100)
+
+		for i := 1; i <= 4; i++ {
+			addExchange(t, m, fmt.Sprintf("q%d", i), 10)
+		}
+
+		require.Len(t, transcripts, 1)
+		assert.Contains(t, transcripts[0], "User: q1")
+		assert.Contains(t, transcripts[0], "Assistant: re q1")
+		assert.Equal(t, "summary 1", m.Summary())
+
+		messages, err := m.Get(true)
+		require.NoError(t, err)
+		require.NotEmpty(t, messages)
+		assert.Equal(t, MessageTypeSystem, messages[0].Type)
+		assert.Contains(t, messages[0].Content, "summary 1")
+		assert.Equal(t, []string{"q4"}, labels(t, m))
+
+		// The next summary merges in the previous one
+		for i := 5; i <= 7; i++ {
+			addExchange(t, m, fmt.Sprintf("q%d", i), 10)
+		}
+		require.Len(t, transcripts, 2)
+		assert.Contains(t, transcripts[1], "Previous summary:\nsummary 1")
+		assert.Equal(t, "summary 2", m.Summary())
+
+		// The summarizer's own memory is untouched
+		shared, err := summarizer.Memory().Get(true)
+		require.NoError(t, err)
+		assert.Empty(t, shared)
+	})
+
+	t.Run("keeps history when summarizing fails", func(t *testing.T) {
+		summarizer := NewPassthroughLLM("summarizer").WithTransformE(func(string) (string, error) {
+			return "", fmt.Errorf("unavailable")
+		})
+		m := NewSummarizingMemory(summarizer, 50)
+		for i := 1; i <= 3; i++ {
+			addExchange(t, m, fmt.Sprintf("q%d", i), 10)
+		}
+
+		assert.Empty(t, m.Summary())
+		assert.Equal(t, []string{"q1", "q2", "q3"}, labels(t, m))
+	})
+}
+
+func TestNewMemory(t *testing.T) {
+	summarizer := NewPassthroughLLM("summarizer")
+
+	tests := []struct {
+		name    string
+		cfg     config.MemorySettings
+		want    Memory
+		wantErr string
+	}{
+		{"default", config.MemorySettings{}, &SimpleMemory{}, ""},
+		{"token window", config.MemorySettings{Strategy: "token_window", MaxTokens: 1000}, &TokenWindowMemory{}, ""},
+		{"keep first last", config.MemorySettings{Strategy: "keep_first_last", KeepFirst: 1, KeepLast: 3}, &KeepFirstLastMemory{}, ""},
+		{"summarizing", config.MemorySettings{Strategy: "summarizing", MaxTokens: 1000}, &SummarizingMemory{}, ""},
+		{"missing budget", config.MemorySettings{Strategy: "token_window"}, nil, "max tokens"},
+		{"unknown", config.MemorySettings{Strategy: "forgetful"}, nil, "invalid memory strategy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory, err := NewMemory(tt.cfg, summarizer)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tt.want, memory)
+		})
+	}
+}
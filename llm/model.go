@@ -66,6 +66,8 @@ type Message struct {
 	Metadata map[string]any `json:"metadata,omitempty"`
 	// Parts supports multipart messages (e.g., text + images)
 	Parts []MessagePart `json:"parts,omitempty"`
+	// Usage reports the tokens and cost spent generating this message
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // MessagePart represents a part of a multipart message.
@@ -96,15 +98,71 @@ func (m *Message) GetAllText() string {
 
 // RequestParams holds parameters for an LLM request
 type RequestParams struct {
-	SystemPrompt  string         // System prompt to use
-	Model         string         // Model to use
-	Temperature   float32        // Temperature for sampling
-	MaxTokens     int            // Maximum tokens to generate
-	UseHistory    bool           // Whether to include conversation history
-	ParallelTools bool           // Whether to run tools in parallel
-	MaxIterations int            // Maximum number of tool call iterations
-	Tools         []string       // Required MCP tools
-	Config        map[string]any // Additional configuration
+	SystemPrompt     string                // System prompt to use
+	Model            string                // Model to use (name, alias or <provider>.<model>.<effort?>)
+	ReasoningEffort  string                // Reasoning effort for models that support it (low, medium, high)
+	Temperature      *float32              // Temperature for sampling; nil uses the LLM's default
+	MaxTokens        int                   // Maximum tokens to generate
+	UseHistory       bool                  // Whether to include conversation history
+	ParallelTools    *bool                 // Whether to run tools in parallel; nil uses the LLM's default
+	MaxParallelTools int                   // Maximum number of tools run at once when ParallelTools is set
+	MaxIterations    int                   // Maximum number of tool call iterations
+	Tools            []string              // Tools, namespaces or glob patterns available to the model
+	ExcludeTools     []string              // Tools, namespaces or glob patterns removed from Tools
+	Memory           Memory                // Conversation memory to use instead of the LLM's own
+	Approval         *tools.ApprovalPolicy // Approval asked before running sensitive tools
+	Config           map[string]any        // Additional configuration
+}
+
+// Bool returns a pointer to v, for optional settings such as ParallelTools
+func Bool(v bool) *bool {
+	return &v
+}
+
+// Float32 returns a pointer to v, for optional settings such as Temperature
+func Float32(v float32) *float32 {
+	return &v
+}
+
+// parallelTools reports whether the request runs tools in parallel
+func (p *RequestParams) parallelTools() bool {
+	return p.ParallelTools != nil && *p.ParallelTools
+}
+
+// mergeRequestParams returns a copy of params with unset fields filled from defaults.
+// Plain booleans are taken from params as given, since false is a meaningful choice.
+func mergeRequestParams(defaults, params *RequestParams) *RequestParams {
+	if params == nil {
+		merged := *defaults
+		return &merged
+	}
+
+	merged := *params
+	if merged.Model == "" {
+		merged.Model = defaults.Model
+		if merged.ReasoningEffort == "" {
+			merged.ReasoningEffort = defaults.ReasoningEffort
+		}
+	}
+	if merged.MaxTokens <= 0 {
+		merged.MaxTokens = defaults.MaxTokens
+	}
+	if merged.MaxIterations <= 0 {
+		merged.MaxIterations = defaults.MaxIterations
+	}
+	if merged.MaxParallelTools <= 0 {
+		merged.MaxParallelTools = defaults.MaxParallelTools
+	}
+	if merged.SystemPrompt == "" {
+		merged.SystemPrompt = defaults.SystemPrompt
+	}
+	if merged.Temperature == nil {
+		merged.Temperature = defaults.Temperature
+	}
+	if merged.ParallelTools == nil {
+		merged.ParallelTools = defaults.ParallelTools
+	}
+	return &merged
 }
 
 // AugmentedLLM represents an LLM enhanced with tools, memory, and context management.
@@ -121,6 +179,11 @@ type AugmentedLLM interface {
 	// Generate processes a message and returns a response
 	Generate(ctx context.Context, msg Message, params *RequestParams) (Message, error)
 
+	// GenerateStream processes a message like Generate, delivering text
+	// deltas, tool activity and usage as they happen. The stream always ends
+	// with a StreamEventMessage or StreamEventError, then the channel is closed.
+	GenerateStream(ctx context.Context, msg Message, params *RequestParams) (<-chan StreamEvent, error)
+
 	// GenerateString is a convenience method that returns just the content string
 	GenerateString(ctx context.Context, content string, params *RequestParams) (string, error)
 
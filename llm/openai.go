@@ -0,0 +1,643 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/logging"
+	"github.com/adimarco/hive/tools"
+)
+
+// DefaultOpenAIBaseURL is the base URL used when none is configured
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAILLM implements the AugmentedLLM interface against any OpenAI-compatible
+// chat completions endpoint. Pointing the base URL at a local server such as
+// llama.cpp or vLLM works the same way as talking to OpenAI itself.
+type OpenAILLM struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	name       string
+	memory     Memory
+	logger     logging.Logger
+	defaults   *RequestParams
+	tools      *tools.SimpleToolRegistry
+	retry      *RetryPolicy
+	limiter    *RateLimiter
+}
+
+// NewOpenAILLM creates a new OpenAILLM instance
+func NewOpenAILLM(name string) *OpenAILLM {
+	return &OpenAILLM{
+		httpClient: http.DefaultClient,
+		baseURL:    DefaultOpenAIBaseURL,
+		name:       name,
+		memory:     NewSimpleMemory(),
+		logger:     logging.GetLogger("llm.openai"),
+		tools:      tools.NewSimpleToolRegistry(),
+		retry:      DefaultRetryPolicy(),
+		defaults: &RequestParams{
+			Model:         "gpt-4o-mini",
+			UseHistory:    true,
+			ParallelTools: Bool(true),
+			MaxIterations: 10,
+			MaxTokens:     1024,
+		},
+	}
+}
+
+// Initialize sets up the LLM with configuration.
+// Settings take precedence over the OPENAI_API_KEY and OPENAI_BASE_URL
+// environment variables. An API key is only required when talking to the
+// default OpenAI endpoint; local servers usually run without one.
+func (l *OpenAILLM) Initialize(ctx context.Context, cfg *config.Settings) error {
+	var settings config.OpenAISettings
+	if cfg != nil {
+		settings = cfg.OpenAI
+		if cfg.DefaultModel != "" {
+			spec, err := ResolveModelFor(l.Provider(), cfg.DefaultModel)
+			if err != nil {
+				return fmt.Errorf("invalid default model: %w", err)
+			}
+			l.defaults.Model = spec.Model
+			l.defaults.ReasoningEffort = spec.ReasoningEffort
+		}
+		l.retry = NewRetryPolicy(cfg.Retry)
+		l.limiter = NewRateLimiter(cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst)
+		if cfg.Memory.Strategy != "" {
+			memory, err := NewMemory(cfg.Memory, l)
+			if err != nil {
+				return fmt.Errorf("invalid memory settings: %w", err)
+			}
+			l.memory = memory
+		}
+	}
+
+	l.apiKey = settings.APIKey
+	if l.apiKey == "" {
+		l.apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	l.baseURL = settings.BaseURL
+	if l.baseURL == "" {
+		l.baseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if l.baseURL == "" {
+		l.baseURL = DefaultOpenAIBaseURL
+	}
+	l.baseURL = strings.TrimRight(l.baseURL, "/")
+
+	if l.apiKey == "" && l.baseURL == DefaultOpenAIBaseURL {
+		return fmt.Errorf("OPENAI_API_KEY environment variable is required")
+	}
+	return nil
+}
+
+// Chat completions wire types
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content,omitempty"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// MarshalJSON omits empty content only from assistant messages, which may
+// carry tool calls alone. The API rejects user and tool messages without it.
+func (m openAIMessage) MarshalJSON() ([]byte, error) {
+	type message openAIMessage
+	if m.Role == "assistant" && m.Content == "" {
+		return json.Marshal(message(m))
+	}
+	return json.Marshal(struct {
+		message
+		Content string `json:"content"`
+	}{message(m), m.Content})
+}
+
+type openAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+type openAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type openAITool struct {
+	Type     string            `json:"type"`
+	Function openAIFunctionDef `json:"function"`
+}
+
+type openAIFunctionDef struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type openAIRequest struct {
+	Model               string          `json:"model"`
+	Messages            []openAIMessage `json:"messages"`
+	MaxTokens           int             `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
+	Temperature         *float32        `json:"temperature,omitempty"`
+	ReasoningEffort     string          `json:"reasoning_effort,omitempty"`
+	Tools               []openAITool    `json:"tools,omitempty"`
+	ParallelToolCalls   *bool           `json:"parallel_tool_calls,omitempty"`
+}
+
+type openAIResponse struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens        int `json:"prompt_tokens"`
+		CompletionTokens    int `json:"completion_tokens"`
+		PromptTokensDetails struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+	} `json:"usage"`
+}
+
+type openAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// openAIAPIError is returned when the API responds with a non-2xx status
+type openAIAPIError struct {
+	StatusCode int
+	Message    string
+	Header     http.Header
+}
+
+func (e *openAIAPIError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// Generate processes a message and returns a response
+func (l *OpenAILLM) Generate(ctx context.Context, msg Message, params *RequestParams) (Message, error) {
+	return l.generate(ctx, msg, params, nil)
+}
+
+// GenerateStream processes a message and streams tool activity and usage as
+// they happen. The chat completions API is called without streaming, so the
+// final text arrives as a single delta.
+func (l *OpenAILLM) GenerateStream(ctx context.Context, msg Message, params *RequestParams) (<-chan StreamEvent, error) {
+	return runStream(ctx, func(emit emitFunc) (Message, error) {
+		response, err := l.generate(ctx, msg, params, emit)
+		if err == nil && response.Content != "" {
+			emit.send(StreamEvent{Type: StreamEventTextDelta, Text: response.Content})
+		}
+		return response, err
+	}), nil
+}
+
+// generate runs the request and tool loop, reporting progress to emit
+func (l *OpenAILLM) generate(ctx context.Context, msg Message, params *RequestParams, emit emitFunc) (Message, error) {
+	l.logger.Info(ctx, "Generating response", logging.WithData(map[string]interface{}{
+		"content": msg.Content,
+		"type":    msg.Type,
+	}))
+
+	reqParams := mergeRequestParams(l.defaults, params)
+	memory := requestMemory(reqParams, l.memory)
+	if err := resolveRequestModel(l.Provider(), reqParams); err != nil {
+		return Message{}, err
+	}
+
+	// Tools available to the model, sent under API-safe names
+	selected := selectTools(ctx, l.logger, l.tools, reqParams)
+	names := newToolNames(selected)
+
+	// Build message list: system prompt, then history, then the new message
+	var messages []openAIMessage
+	if reqParams.SystemPrompt != "" {
+		messages = append(messages, openAIMessage{
+			Role:    "system",
+			Content: reqParams.SystemPrompt,
+		})
+	}
+	if reqParams.UseHistory {
+		history, err := memory.Get(true)
+		if err != nil {
+			return Message{}, err
+		}
+		messages = append(messages, convertToOpenAIMessages(history, names)...)
+	}
+	messages = append(messages, convertToOpenAIMessages([]Message{msg}, names)...)
+
+	req := openAIRequest{
+		Model:    reqParams.Model,
+		Messages: messages,
+		Tools:    l.buildTools(ctx, selected, names),
+	}
+	if isOpenAIReasoningModel(reqParams.Model) {
+		// Reasoning models take a completion budget and reject sampling parameters
+		req.MaxCompletionTokens = reqParams.MaxTokens
+		req.ReasoningEffort = reqParams.ReasoningEffort
+	} else {
+		req.MaxTokens = reqParams.MaxTokens
+		req.Temperature = reqParams.Temperature
+	}
+	if len(req.Tools) > 0 {
+		parallel := reqParams.parallelTools()
+		req.ParallelToolCalls = &parallel
+	}
+
+	// Make initial API call
+	var usage Usage
+	resp, err := l.complete(ctx, req, emit, &usage)
+	if err != nil {
+		l.logger.Error(ctx, "OpenAI API error", logging.WithData(map[string]interface{}{
+			"error": err.Error(),
+		}))
+		return Message{}, fmt.Errorf("openai API error: %w", err)
+	}
+
+	// Tool calling loop - continue until no more tool calls or max iterations reached
+	var toolCalls []ToolCall
+	turns := []Message{msg}
+	iterCount := 0
+	maxIterations := reqParams.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = 10 // Default max iterations if not specified
+	}
+
+	for iterCount < maxIterations && len(resp.ToolCalls) > 0 {
+		iterCount++
+		l.logger.Info(ctx, "Tool iteration", logging.WithData(map[string]interface{}{
+			"iteration": iterCount,
+			"max":       maxIterations,
+		}))
+
+		req.Messages = append(req.Messages, resp)
+		// Map the API names the model used back to registry names, leaving
+		// the response sent back to the API unchanged
+		calls := make([]ToolCall, len(resp.ToolCalls))
+		requested := make([]openAIToolCall, len(resp.ToolCalls))
+		for i, call := range resp.ToolCalls {
+			call.Function.Name = names.toolName(call.Function.Name)
+			requested[i] = call
+			calls[i] = ToolCall{
+				ID:   call.ID,
+				Name: call.Function.Name,
+				Args: parseToolArguments(call.Function.Arguments),
+			}
+		}
+		results := executeToolCalls(ctx, calls, reqParams.parallelTools(), reqParams.MaxParallelTools, emit,
+			availableRunner(names, calls, approvedRunner(l.tools, reqParams.Approval, calls, func(ctx context.Context, i int) tools.ToolResult {
+				return l.executeToolCall(ctx, requested[i], emit)
+			})))
+
+		for i, result := range results {
+			usage.addTool(result.Cost)
+			req.Messages = append(req.Messages, openAIMessage{
+				Role:       "tool",
+				Content:    result.Content,
+				ToolCallID: requested[i].ID,
+			})
+		}
+		toolCalls = append(toolCalls, calls...)
+		turns = append(turns, toolUseTurn(l.name, resp.Content, calls)...)
+
+		// Make follow-up API call with tool results
+		resp, err = l.complete(ctx, req, emit, &usage)
+		if err != nil {
+			l.logger.Error(ctx, "OpenAI API error after tool calls", logging.WithData(map[string]interface{}{
+				"error": err.Error(),
+			}))
+			return Message{}, fmt.Errorf("openai API error after tool calls: %w", err)
+		}
+	}
+
+	// Check if we hit the max iterations limit
+	if len(resp.ToolCalls) > 0 {
+		l.logger.Error(ctx, "Reached maximum tool call iterations", logging.WithData(map[string]interface{}{
+			"max_iterations": maxIterations,
+		}))
+	}
+
+	// Build the final response message
+	response := Message{
+		Type:      MessageTypeAssistant,
+		Content:   resp.Content,
+		Name:      l.name,
+		ToolCalls: toolCalls,
+		Usage:     &usage,
+	}
+
+	// Store the exchange in history if enabled: the user message, each
+	// round of tool use and the final answer
+	if reqParams.UseHistory {
+		turns = append(turns, Message{
+			Type:    MessageTypeAssistant,
+			Content: response.Content,
+			Name:    l.name,
+		})
+		for _, turn := range turns {
+			if err := memory.Add(turn, false); err != nil {
+				return Message{}, fmt.Errorf("failed to add response to history: %w", err)
+			}
+		}
+	}
+
+	l.logger.Info(ctx, "Generated final response", logging.WithData(map[string]interface{}{
+		"content":    response.Content,
+		"iterations": iterCount,
+		"usage":      usage,
+	}))
+
+	return response, nil
+}
+
+// complete performs a chat completions request, retrying transient
+// failures, and returns the assistant message from the first choice
+func (l *OpenAILLM) complete(ctx context.Context, req openAIRequest, emit emitFunc, usage *Usage) (openAIMessage, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return openAIMessage{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	var resp openAIResponse
+	err = l.retry.Do(ctx, l.logger, func() error {
+		if err := l.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		var err error
+		resp, err = l.send(ctx, body)
+		return err
+	})
+	if err != nil {
+		return openAIMessage{}, err
+	}
+
+	l.logger.Info(ctx, "API response", logging.WithData(map[string]interface{}{
+		"content":       resp.Choices[0].Message.Content,
+		"tool_calls":    len(resp.Choices[0].Message.ToolCalls),
+		"finish_reason": resp.Choices[0].FinishReason,
+	}))
+
+	// prompt_tokens includes cached tokens, which are billed separately
+	cached := resp.Usage.PromptTokensDetails.CachedTokens
+	callUsage := Usage{
+		Requests:             1,
+		InputTokens:          resp.Usage.PromptTokens - cached,
+		OutputTokens:         resp.Usage.CompletionTokens,
+		CacheReadInputTokens: cached,
+	}
+	priceUsage(req.Model, &callUsage)
+	usage.Add(callUsage)
+
+	emit.send(StreamEvent{Type: StreamEventUsage, Usage: &callUsage})
+
+	return resp.Choices[0].Message, nil
+}
+
+// send makes a single chat completions API call
+func (l *OpenAILLM) send(ctx context.Context, body []byte) (openAIResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, l.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return openAIResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if l.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+l.apiKey)
+	}
+
+	httpResp, err := l.httpClient.Do(httpReq)
+	if err != nil {
+		return openAIResponse{}, err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return openAIResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		apiErr := &openAIAPIError{
+			StatusCode: httpResp.StatusCode,
+			Message:    strings.TrimSpace(string(data)),
+			Header:     httpResp.Header,
+		}
+		var errResp openAIErrorResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error.Message != "" {
+			apiErr.Message = errResp.Error.Message
+		}
+		return openAIResponse{}, apiErr
+	}
+
+	var resp openAIResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return openAIResponse{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return openAIResponse{}, fmt.Errorf("response contained no choices")
+	}
+	return resp, nil
+}
+
+// buildTools converts the selected tools into function definitions, named
+// as names sends them
+func (l *OpenAILLM) buildTools(ctx context.Context, selected []tools.Tool, names *toolNames) []openAITool {
+	var result []openAITool
+	for _, tool := range selected {
+		schema := map[string]any{"type": "object", "properties": map[string]any{}}
+		if len(tool.Schema) > 0 {
+			if err := json.Unmarshal(tool.Schema, &schema); err != nil {
+				l.logger.Error(ctx, "Failed to parse tool schema", logging.WithData(map[string]interface{}{
+					"tool":  tool.Name,
+					"error": err.Error(),
+				}))
+				continue
+			}
+		}
+
+		result = append(result, openAITool{
+			Type: "function",
+			Function: openAIFunctionDef{
+				Name:        names.apiName(tool.Name),
+				Description: tool.Description,
+				Parameters:  schema,
+			},
+		})
+	}
+
+	return result
+}
+
+// executeToolCall runs a single requested tool call through the registry.
+// Failures are returned as error results so the model can react to them.
+func (l *OpenAILLM) executeToolCall(ctx context.Context, call openAIToolCall, emit emitFunc) tools.ToolResult {
+	l.logger.Info(ctx, "Tool use request", logging.WithData(map[string]interface{}{
+		"tool":  call.Function.Name,
+		"input": call.Function.Arguments,
+	}))
+
+	args := map[string]any{}
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			errMsg := fmt.Sprintf("Failed to parse tool input: %s", err.Error())
+			l.logger.Error(ctx, errMsg, logging.WithData(map[string]interface{}{
+				"tool":  call.Function.Name,
+				"error": err.Error(),
+			}))
+			return tools.ToolResult{Content: errMsg, IsError: true}
+		}
+	}
+
+	result, err := callTool(ctx, l.tools, ToolCall{ID: call.ID, Name: call.Function.Name, Args: args}, emit)
+	if err != nil {
+		errMsg := fmt.Sprintf("Tool execution failed: %s", err.Error())
+		l.logger.Error(ctx, errMsg, logging.WithData(map[string]interface{}{
+			"tool":  call.Function.Name,
+			"error": err.Error(),
+		}))
+		return tools.ToolResult{Content: errMsg, IsError: true}
+	}
+
+	l.logger.Info(ctx, "Tool result", logging.WithData(map[string]interface{}{
+		"tool":   call.Function.Name,
+		"result": result,
+	}))
+	return result
+}
+
+// GenerateString is a convenience method for simple text interactions
+func (l *OpenAILLM) GenerateString(ctx context.Context, content string, params *RequestParams) (string, error) {
+	msg := Message{
+		Type:    MessageTypeUser,
+		Content: content,
+	}
+	response, err := l.Generate(ctx, msg, params)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// CallTool executes a tool call through the registry and returns its content
+func (l *OpenAILLM) CallTool(ctx context.Context, call ToolCall) (string, error) {
+	result, err := l.ExecuteTool(ctx, call.Name, call.Args)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// Name returns the identifier for this LLM instance
+func (l *OpenAILLM) Name() string {
+	return l.name
+}
+
+// Provider returns the LLM provider
+func (l *OpenAILLM) Provider() string {
+	return "openai"
+}
+
+// Cleanup closes the tool registry, cleaning up its tools and providers,
+// and clears the conversation history
+func (l *OpenAILLM) Cleanup() error {
+	return errors.Join(l.tools.Close(), l.memory.Clear(true))
+}
+
+// Tools returns the tool registry for this LLM
+func (l *OpenAILLM) Tools() tools.ToolRegistry {
+	return l.tools
+}
+
+// ExecuteTool executes a specific tool directly
+func (l *OpenAILLM) ExecuteTool(ctx context.Context, toolName string, args map[string]any) (tools.ToolResult, error) {
+	l.logger.Info(ctx, "Executing tool directly", logging.WithData(map[string]interface{}{
+		"tool": toolName,
+		"args": args,
+	}))
+
+	result, err := l.tools.Call(ctx, toolName, args)
+	if err != nil {
+		l.logger.Error(ctx, "Tool execution failed", logging.WithData(map[string]interface{}{
+			"tool":  toolName,
+			"error": err.Error(),
+		}))
+		return tools.ToolResult{}, fmt.Errorf("failed to execute tool %s: %w", toolName, err)
+	}
+
+	return result, nil
+}
+
+// Helper functions
+
+// convertToOpenAIMessages converts history to chat completions messages,
+// including assistant tool calls, named as names sends them, and their tool
+// results
+func convertToOpenAIMessages(msgs []Message, names *toolNames) []openAIMessage {
+	result := make([]openAIMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		switch msg.Type {
+		case MessageTypeTool:
+			// Each tool result is a separate message answering one call
+			for _, call := range msg.ToolCalls {
+				result = append(result, openAIMessage{
+					Role:       "tool",
+					Content:    call.Response,
+					ToolCallID: call.ID,
+				})
+			}
+		case MessageTypeAssistant:
+			message := openAIMessage{Role: "assistant", Content: msg.Content}
+			for _, call := range msg.ToolCalls {
+				args, err := json.Marshal(call.Args)
+				if err != nil || call.Args == nil {
+					args = []byte("{}")
+				}
+				message.ToolCalls = append(message.ToolCalls, openAIToolCall{
+					ID:       call.ID,
+					Type:     "function",
+					Function: openAIFunctionCall{Name: names.apiName(call.Name), Arguments: string(args)},
+				})
+			}
+			result = append(result, message)
+		case MessageTypeSystem:
+			result = append(result, openAIMessage{Role: "system", Content: msg.Content})
+		default:
+			result = append(result, openAIMessage{Role: "user", Content: msg.Content})
+		}
+	}
+	return result
+}
+
+// isOpenAIReasoningModel reports whether the model is one of the o-series reasoning models
+func isOpenAIReasoningModel(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseToolArguments decodes a JSON arguments string, returning nil if it is not an object
+func parseToolArguments(raw string) map[string]any {
+	var args map[string]any
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return nil
+	}
+	return args
+}
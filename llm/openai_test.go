@@ -0,0 +1,290 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/tools"
+)
+
+// fakeOpenAIServer records chat completion requests and replies with scripted messages
+type fakeOpenAIServer struct {
+	mu        sync.Mutex
+	requests  []openAIRequest
+	responses []openAIMessage
+	status    int
+}
+
+func (f *fakeOpenAIServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+
+		var req openAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.requests = append(f.requests, req)
+
+		if f.status != 0 {
+			w.WriteHeader(f.status)
+			_, _ = w.Write([]byte(`{"error":{"message":"boom","type":"server_error"}}`))
+			return
+		}
+
+		msg := openAIMessage{Role: "assistant", Content: "out of script"}
+		if len(f.responses) > 0 {
+			msg = f.responses[0]
+			f.responses = f.responses[1:]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id": "chatcmpl-test",
+			"choices": []map[string]any{
+				{"message": msg, "finish_reason": "stop"},
+			},
+			"usage": map[string]any{
+				"prompt_tokens":         100,
+				"completion_tokens":     20,
+				"prompt_tokens_details": map[string]any{"cached_tokens": 40},
+			},
+		})
+	}
+}
+
+func newTestOpenAILLM(t *testing.T, fake *fakeOpenAIServer) *OpenAILLM {
+	server := httptest.NewServer(fake.handler(t))
+	t.Cleanup(server.Close)
+
+	l := NewOpenAILLM("test")
+	require.NoError(t, l.Initialize(context.Background(), &config.Settings{
+		DefaultModel: "local-model",
+		OpenAI:       config.OpenAISettings{BaseURL: server.URL + "/v1/"},
+	}))
+	return l
+}
+
+func TestOpenAILLM(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("system prompt and request params", func(t *testing.T) {
+		fake := &fakeOpenAIServer{responses: []openAIMessage{{Role: "assistant", Content: "hello there"}}}
+		l := newTestOpenAILLM(t, fake)
+
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "hi"}, &RequestParams{
+			SystemPrompt: "be brief",
+			Temperature:  Float32(0.5),
+			MaxTokens:    42,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "hello there", resp.Content)
+		assert.Equal(t, MessageTypeAssistant, resp.Type)
+
+		require.Len(t, fake.requests, 1)
+		req := fake.requests[0]
+		assert.Equal(t, "local-model", req.Model)
+		assert.Equal(t, 42, req.MaxTokens)
+		require.NotNil(t, req.Temperature)
+		assert.Equal(t, float32(0.5), *req.Temperature)
+		require.Len(t, req.Messages, 2)
+		assert.Equal(t, "system", req.Messages[0].Role)
+		assert.Equal(t, "be brief", req.Messages[0].Content)
+		assert.Equal(t, "user", req.Messages[1].Role)
+		assert.Equal(t, "hi", req.Messages[1].Content)
+	})
+
+	t.Run("zero temperature", func(t *testing.T) {
+		fake := &fakeOpenAIServer{}
+		l := newTestOpenAILLM(t, fake)
+
+		_, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "hi"}, &RequestParams{Temperature: Float32(0)})
+		require.NoError(t, err)
+		require.Len(t, fake.requests, 1)
+		require.NotNil(t, fake.requests[0].Temperature)
+		assert.Zero(t, *fake.requests[0].Temperature)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		fake := &fakeOpenAIServer{responses: []openAIMessage{
+			{Role: "assistant", Content: "first answer"},
+			{Role: "assistant", Content: "second answer"},
+		}}
+		l := newTestOpenAILLM(t, fake)
+		params := &RequestParams{UseHistory: true}
+
+		_, err := l.GenerateString(ctx, "first", params)
+		require.NoError(t, err)
+		_, err = l.GenerateString(ctx, "second", params)
+		require.NoError(t, err)
+
+		require.Len(t, fake.requests, 2)
+		msgs := fake.requests[1].Messages
+		require.Len(t, msgs, 3)
+		assert.Equal(t, "first", msgs[0].Content)
+		assert.Equal(t, "first answer", msgs[1].Content)
+		assert.Equal(t, "assistant", msgs[1].Role)
+		assert.Equal(t, "second", msgs[2].Content)
+	})
+
+	t.Run("history includes tool turns", func(t *testing.T) {
+		fake := &fakeOpenAIServer{responses: []openAIMessage{
+			{
+				Role:    "assistant",
+				Content: "checking",
+				ToolCalls: []openAIToolCall{{
+					ID:       "call_1",
+					Type:     "function",
+					Function: openAIFunctionCall{Name: "echo", Arguments: `{"input":"ping"}`},
+				}},
+			},
+			{Role: "assistant", Content: "the tool said ping"},
+			{Role: "assistant", Content: "you already asked"},
+		}}
+		l := newTestOpenAILLM(t, fake)
+		require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "echo", "Echo input", func(s string) string {
+			return s
+		}))
+		params := &RequestParams{UseHistory: true, Tools: []string{"echo"}}
+
+		_, err := l.GenerateString(ctx, "call echo", params)
+		require.NoError(t, err)
+		_, err = l.GenerateString(ctx, "again?", params)
+		require.NoError(t, err)
+
+		require.Len(t, fake.requests, 3)
+		msgs := fake.requests[2].Messages
+		require.Len(t, msgs, 5)
+		assert.Equal(t, "user", msgs[0].Role)
+		assert.Equal(t, "call echo", msgs[0].Content)
+		assert.Equal(t, "assistant", msgs[1].Role)
+		assert.Equal(t, "checking", msgs[1].Content)
+		require.Len(t, msgs[1].ToolCalls, 1)
+		assert.Equal(t, "call_1", msgs[1].ToolCalls[0].ID)
+		assert.Equal(t, "echo", msgs[1].ToolCalls[0].Function.Name)
+		assert.JSONEq(t, `{"input":"ping"}`, msgs[1].ToolCalls[0].Function.Arguments)
+		assert.Equal(t, "tool", msgs[2].Role)
+		assert.Equal(t, "call_1", msgs[2].ToolCallID)
+		assert.Equal(t, "ping", msgs[2].Content)
+		assert.Equal(t, "the tool said ping", msgs[3].Content)
+		assert.Equal(t, "again?", msgs[4].Content)
+	})
+
+	t.Run("tool loop", func(t *testing.T) {
+		fake := &fakeOpenAIServer{responses: []openAIMessage{
+			{
+				Role: "assistant",
+				ToolCalls: []openAIToolCall{{
+					ID:       "call_1",
+					Type:     "function",
+					Function: openAIFunctionCall{Name: "echo", Arguments: `{"input":"ping"}`},
+				}},
+			},
+			{Role: "assistant", Content: "the tool said ping"},
+		}}
+		l := newTestOpenAILLM(t, fake)
+		require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "echo", "Echo input", func(s string) string {
+			return s
+		}))
+
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "call echo"}, &RequestParams{
+			Tools:         []string{"echo"},
+			ParallelTools: Bool(true),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "the tool said ping", resp.Content)
+		require.Len(t, resp.ToolCalls, 1)
+		assert.Equal(t, "echo", resp.ToolCalls[0].Name)
+		assert.Equal(t, "ping", resp.ToolCalls[0].Response)
+
+		require.Len(t, fake.requests, 2)
+		first := fake.requests[0]
+		require.Len(t, first.Tools, 1)
+		assert.Equal(t, "echo", first.Tools[0].Function.Name)
+		assert.Equal(t, "object", first.Tools[0].Function.Parameters["type"])
+		require.NotNil(t, first.ParallelToolCalls)
+		assert.True(t, *first.ParallelToolCalls)
+
+		followUp := fake.requests[1].Messages
+		require.Len(t, followUp, 3)
+		assert.Equal(t, "assistant", followUp[1].Role)
+		assert.Equal(t, "call_1", followUp[1].ToolCalls[0].ID)
+		assert.Equal(t, "tool", followUp[2].Role)
+		assert.Equal(t, "call_1", followUp[2].ToolCallID)
+		assert.Equal(t, "ping", followUp[2].Content)
+	})
+
+	t.Run("reasoning model", func(t *testing.T) {
+		fake := &fakeOpenAIServer{responses: []openAIMessage{{Role: "assistant", Content: "thought about it"}}}
+		l := newTestOpenAILLM(t, fake)
+
+		_, err := l.GenerateString(ctx, "think", &RequestParams{
+			Model:       "openai.o3-mini.high",
+			MaxTokens:   100,
+			Temperature: Float32(0.7),
+		})
+		require.NoError(t, err)
+
+		require.Len(t, fake.requests, 1)
+		req := fake.requests[0]
+		assert.Equal(t, "o3-mini", req.Model)
+		assert.Equal(t, "high", req.ReasoningEffort)
+		assert.Equal(t, 100, req.MaxCompletionTokens)
+		assert.Zero(t, req.MaxTokens)
+		assert.Nil(t, req.Temperature)
+	})
+
+	t.Run("model from another provider", func(t *testing.T) {
+		l := newTestOpenAILLM(t, &fakeOpenAIServer{})
+
+		_, err := l.GenerateString(ctx, "hi", &RequestParams{Model: "sonnet"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `served by provider "anthropic"`)
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		fake := &fakeOpenAIServer{status: http.StatusBadRequest}
+		l := newTestOpenAILLM(t, fake)
+
+		_, err := l.GenerateString(ctx, "hi", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 400: boom")
+	})
+
+	t.Run("api key required for default endpoint", func(t *testing.T) {
+		t.Setenv("OPENAI_API_KEY", "")
+		t.Setenv("OPENAI_BASE_URL", "")
+
+		l := NewOpenAILLM("test")
+		err := l.Initialize(ctx, &config.Settings{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "OPENAI_API_KEY")
+	})
+}
+
+func TestOpenAIMessage_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  openAIMessage
+		want string
+	}{
+		{"empty tool result", openAIMessage{Role: "tool", ToolCallID: "call-1"}, `{"role":"tool","tool_call_id":"call-1","content":""}`},
+		{"empty user message", openAIMessage{Role: "user"}, `{"role":"user","content":""}`},
+		{"assistant with tool calls only", openAIMessage{Role: "assistant", ToolCalls: []openAIToolCall{{ID: "call-1", Type: "function"}}},
+			`{"role":"assistant","tool_calls":[{"id":"call-1","type":"function","function":{"name":"","arguments":""}}]}`},
+		{"assistant text", openAIMessage{Role: "assistant", Content: "hi"}, `{"role":"assistant","content":"hi"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.msg)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(data))
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/logging"
+	"github.com/adimarco/hive/tools"
+)
+
+// CallToolPrefix marks a PassthroughLLM message as a tool invocation.
+// The message format is: ***CALL_TOOL <tool_name> [json_arguments]
+const CallToolPrefix = "***CALL_TOOL"
+
+// PassthroughLLM is a deterministic AugmentedLLM for tests. It echoes the
+// input back, optionally through a transform function, and never calls an
+// external API. Messages starting with CallToolPrefix run the named tool
+// through the registry and return its result instead.
+type PassthroughLLM struct {
+	name      string
+	memory    Memory
+	logger    logging.Logger
+	defaults  *RequestParams
+	tools     *tools.SimpleToolRegistry
+	transform func(string) (string, error)
+}
+
+// NewPassthroughLLM creates a new PassthroughLLM that echoes its input
+func NewPassthroughLLM(name string) *PassthroughLLM {
+	return &PassthroughLLM{
+		name:   name,
+		memory: NewSimpleMemory(),
+		logger: logging.GetLogger("llm.passthrough"),
+		tools:  tools.NewSimpleToolRegistry(),
+		defaults: &RequestParams{
+			UseHistory:    true,
+			MaxIterations: 10,
+		},
+	}
+}
+
+// WithTransform sets a function applied to each input to produce the response
+func (l *PassthroughLLM) WithTransform(fn func(string) string) *PassthroughLLM {
+	l.transform = func(s string) (string, error) {
+		return fn(s), nil
+	}
+	return l
+}
+
+// WithTransformE sets a transform function that may fail, which is useful
+// for simulating provider errors
+func (l *PassthroughLLM) WithTransformE(fn func(string) (string, error)) *PassthroughLLM {
+	l.transform = fn
+	return l
+}
+
+// Initialize is a no-op; PassthroughLLM needs no configuration
+func (l *PassthroughLLM) Initialize(ctx context.Context, cfg *config.Settings) error {
+	return nil
+}
+
+// Generate echoes the message back, or runs a tool for ***CALL_TOOL messages
+func (l *PassthroughLLM) Generate(ctx context.Context, msg Message, params *RequestParams) (Message, error) {
+	if err := ctx.Err(); err != nil {
+		return Message{}, err
+	}
+
+	reqParams := mergeRequestParams(l.defaults, params)
+	memory := requestMemory(reqParams, l.memory)
+
+	// Store user message in history if enabled
+	if reqParams.UseHistory {
+		if err := memory.Add(msg, false); err != nil {
+			return Message{}, fmt.Errorf("failed to add message to history: %w", err)
+		}
+	}
+
+	response := Message{
+		Type: MessageTypeAssistant,
+		Name: l.name,
+	}
+
+	var turns []Message
+	if strings.HasPrefix(msg.Content, CallToolPrefix) {
+		call, err := parseCallTool(msg.Content)
+		if err != nil {
+			return Message{}, err
+		}
+		result := executeAllowedTool(ctx, l.tools, reqParams, call)
+		call.Response = result.Content
+		call.IsError = result.IsError
+		turns = toolUseTurn(l.name, "", []ToolCall{call})
+		response.Content = result.Content
+		response.ToolCalls = []ToolCall{call}
+		response.Usage = &Usage{}
+		response.Usage.addTool(result.Cost)
+	} else {
+		content := msg.Content
+		if l.transform != nil {
+			var err error
+			if content, err = l.transform(content); err != nil {
+				return Message{}, err
+			}
+		}
+		response.Content = content
+	}
+
+	l.logger.Debug(ctx, "Generated passthrough response", logging.WithData(map[string]interface{}{
+		"content": response.Content,
+	}))
+
+	// Store the tool use round, if any, and the response in history if
+	// enabled, the same way the provider LLMs do
+	if reqParams.UseHistory {
+		final := response
+		final.ToolCalls = nil
+		final.Usage = nil
+		for _, m := range append(turns, final) {
+			if err := memory.Add(m, false); err != nil {
+				return Message{}, fmt.Errorf("failed to add response to history: %w", err)
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// GenerateStream returns the response of Generate as a stream of events
+func (l *PassthroughLLM) GenerateStream(ctx context.Context, msg Message, params *RequestParams) (<-chan StreamEvent, error) {
+	return streamGenerated(ctx, func() (Message, error) {
+		return l.Generate(ctx, msg, params)
+	}), nil
+}
+
+// GenerateString is a convenience method for simple text interactions
+func (l *PassthroughLLM) GenerateString(ctx context.Context, content string, params *RequestParams) (string, error) {
+	response, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: content}, params)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// CallTool executes a tool call through the registry and returns its content
+func (l *PassthroughLLM) CallTool(ctx context.Context, call ToolCall) (string, error) {
+	result, err := l.ExecuteTool(ctx, call.Name, call.Args)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExecuteTool executes a specific tool directly
+func (l *PassthroughLLM) ExecuteTool(ctx context.Context, toolName string, args map[string]any) (tools.ToolResult, error) {
+	result, err := l.tools.Call(ctx, toolName, args)
+	if err != nil {
+		return tools.ToolResult{}, fmt.Errorf("failed to execute tool %s: %w", toolName, err)
+	}
+	return result, nil
+}
+
+// Name returns the identifier for this LLM instance
+func (l *PassthroughLLM) Name() string {
+	return l.name
+}
+
+// Provider returns the LLM provider
+func (l *PassthroughLLM) Provider() string {
+	return "passthrough"
+}
+
+// Cleanup closes the tool registry, cleaning up its tools and providers,
+// and clears the conversation history
+func (l *PassthroughLLM) Cleanup() error {
+	return errors.Join(l.tools.Close(), l.memory.Clear(true))
+}
+
+// Tools returns the tool registry for this LLM
+func (l *PassthroughLLM) Tools() tools.ToolRegistry {
+	return l.tools
+}
+
+// Memory returns the conversation memory, so tests can inspect history
+func (l *PassthroughLLM) Memory() Memory {
+	return l.memory
+}
+
+// parseCallTool parses "***CALL_TOOL <name> [json_arguments]" into a ToolCall
+func parseCallTool(content string) (ToolCall, error) {
+	rest := strings.TrimSpace(strings.TrimPrefix(content, CallToolPrefix))
+	name, rawArgs, _ := strings.Cut(rest, " ")
+	if name == "" {
+		return ToolCall{}, fmt.Errorf("%s requires a tool name", CallToolPrefix)
+	}
+
+	call := ToolCall{
+		ID:   "passthrough-" + name,
+		Name: name,
+		Args: map[string]any{},
+	}
+	if rawArgs = strings.TrimSpace(rawArgs); rawArgs != "" {
+		if err := json.Unmarshal([]byte(rawArgs), &call.Args); err != nil {
+			return ToolCall{}, fmt.Errorf("invalid %s arguments: %w", CallToolPrefix, err)
+		}
+	}
+	return call, nil
+}
+
+// executeAllowedTool runs a tool call through the registry, limited to the
+// tools params makes available and subject to their approval policy just like the
+// provider tool loops. Failures become error results rather than errors,
+// mirroring what a model would see. Calls without arguments, such as those
+// loaded from a script, are given an empty argument object.
+func executeAllowedTool(ctx context.Context, registry tools.ToolRegistry, params *RequestParams, call ToolCall) tools.ToolResult {
+	allowed := false
+	if tool, err := registry.Get(call.Name); err == nil {
+		selected, _ := tools.SelectTools(registry, params.Tools, params.ExcludeTools)
+		allowed = newToolNames(selected).available(tool.Name)
+	}
+	if !allowed {
+		return tools.NewErrorResult(fmt.Errorf("tool %q is not available to this request", call.Name))
+	}
+	if err := authorizeToolCall(ctx, registry, params.Approval, call); err != nil {
+		return tools.NewErrorResult(err)
+	}
+
+	args := call.Args
+	if args == nil {
+		args = map[string]any{}
+	}
+	result, err := registry.Call(ctx, call.Name, args)
+	if err != nil {
+		return tools.NewErrorResult(fmt.Errorf("Tool execution failed: %w", err))
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/tools"
+)
+
+func TestPassthroughLLM(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("echoes input", func(t *testing.T) {
+		l := NewPassthroughLLM("echo")
+
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "hello"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", resp.Content)
+		assert.Equal(t, MessageTypeAssistant, resp.Type)
+		assert.Equal(t, "echo", resp.Name)
+		assert.Equal(t, "passthrough", l.Provider())
+	})
+
+	t.Run("transform", func(t *testing.T) {
+		l := NewPassthroughLLM("upper").WithTransform(strings.ToUpper)
+
+		resp, err := l.GenerateString(ctx, "hello", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO", resp)
+	})
+
+	t.Run("transform error", func(t *testing.T) {
+		l := NewPassthroughLLM("failing").WithTransformE(func(string) (string, error) {
+			return "", fmt.Errorf("provider unavailable")
+		})
+
+		_, err := l.GenerateString(ctx, "hello", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "provider unavailable")
+	})
+
+	t.Run("history", func(t *testing.T) {
+		l := NewPassthroughLLM("echo")
+
+		_, err := l.GenerateString(ctx, "first", nil)
+		require.NoError(t, err)
+		_, err = l.GenerateString(ctx, "second", &RequestParams{UseHistory: false})
+		require.NoError(t, err)
+
+		history, err := l.Memory().Get(true)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, MessageTypeUser, history[0].Type)
+		assert.Equal(t, MessageTypeAssistant, history[1].Type)
+		assert.Equal(t, "first", history[1].Content)
+	})
+
+	t.Run("call tool", func(t *testing.T) {
+		l := NewPassthroughLLM("tools")
+		require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "echo", "Echo input", func(s string) string {
+			return "echo: " + s
+		}))
+
+		resp, err := l.GenerateString(ctx, `***CALL_TOOL echo {"input":"ping"}`, &RequestParams{
+			Tools: []string{"echo"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "echo: ping", resp)
+	})
+
+	t.Run("call tool history", func(t *testing.T) {
+		l := NewPassthroughLLM("tools")
+		require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "echo", "Echo input", func(s string) string {
+			return "echo: " + s
+		}))
+
+		_, err := l.GenerateString(ctx, `***CALL_TOOL echo {"input":"ping"}`, &RequestParams{UseHistory: true, Tools: []string{"echo"}})
+		require.NoError(t, err)
+		resp, err := l.GenerateString(ctx, "***CALL_TOOL missing", &RequestParams{UseHistory: true, Tools: []string{"echo"}})
+		require.NoError(t, err)
+
+		history, err := l.Memory().Get(true)
+		require.NoError(t, err)
+		require.Len(t, history, 8)
+
+		// Each call is recorded as a tool use turn followed by the response
+		assert.Equal(t, MessageTypeAssistant, history[1].Type)
+		assert.Equal(t, []ToolCall{{ID: "passthrough-echo", Name: "echo", Args: map[string]any{"input": "ping"}}}, history[1].ToolCalls)
+		assert.Equal(t, MessageTypeTool, history[2].Type)
+		assert.Equal(t, []ToolCall{{ID: "passthrough-echo", Name: "echo", Response: "echo: ping"}}, history[2].ToolCalls)
+		assert.Equal(t, MessageTypeAssistant, history[3].Type)
+		assert.Equal(t, "echo: ping", history[3].Content)
+		assert.Empty(t, history[3].ToolCalls)
+		assert.Nil(t, history[3].Usage)
+
+		// Failed calls are recorded as errors
+		require.Len(t, history[6].ToolCalls, 1)
+		assert.True(t, history[6].ToolCalls[0].IsError)
+		assert.Equal(t, resp, history[6].ToolCalls[0].Response)
+		assert.Equal(t, resp, history[7].Content)
+	})
+
+	t.Run("call tool not in request", func(t *testing.T) {
+		l := NewPassthroughLLM("tools")
+		require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "echo", "Echo input", func(s string) string {
+			return s
+		}))
+
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "***CALL_TOOL echo"}, nil)
+		require.NoError(t, err)
+		assert.Contains(t, resp.Content, `tool "echo" is not available`)
+		require.Len(t, resp.ToolCalls, 1)
+		assert.Equal(t, "echo", resp.ToolCalls[0].Name)
+		assert.True(t, resp.ToolCalls[0].IsError)
+	})
+
+	t.Run("invalid call tool", func(t *testing.T) {
+		l := NewPassthroughLLM("tools")
+
+		_, err := l.GenerateString(ctx, "***CALL_TOOL", nil)
+		require.Error(t, err)
+
+		_, err = l.GenerateString(ctx, "***CALL_TOOL echo {not json", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid ***CALL_TOOL arguments")
+	})
+}
@@ -0,0 +1,232 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/logging"
+	"github.com/adimarco/hive/tools"
+)
+
+// PlaybackLLM is a deterministic AugmentedLLM for tests that replays the
+// assistant turns of a recorded conversation in order. When a replayed turn
+// requests tools, they are executed through the registry and playback moves
+// on to the next assistant turn, just like a provider tool loop would.
+type PlaybackLLM struct {
+	name     string
+	memory   Memory
+	logger   logging.Logger
+	defaults *RequestParams
+	tools    *tools.SimpleToolRegistry
+
+	mu     sync.Mutex
+	script []Message
+	pos    int
+}
+
+// NewPlaybackLLM creates a PlaybackLLM from a conversation. Only assistant
+// messages are replayed; user and other messages are ignored, so a full
+// recorded conversation can be used as-is.
+func NewPlaybackLLM(name string, conversation []Message) *PlaybackLLM {
+	script := make([]Message, 0, len(conversation))
+	for _, msg := range conversation {
+		if msg.Type == MessageTypeAssistant {
+			script = append(script, deepCopyMessage(msg))
+		}
+	}
+
+	return &PlaybackLLM{
+		name:   name,
+		memory: NewSimpleMemory(),
+		logger: logging.GetLogger("llm.playback"),
+		tools:  tools.NewSimpleToolRegistry(),
+		defaults: &RequestParams{
+			UseHistory:    true,
+			ParallelTools: Bool(true),
+			MaxIterations: 10,
+		},
+		script: script,
+	}
+}
+
+// Initialize is a no-op; PlaybackLLM needs no configuration
+func (l *PlaybackLLM) Initialize(ctx context.Context, cfg *config.Settings) error {
+	return nil
+}
+
+// Generate returns the next assistant turn from the script
+func (l *PlaybackLLM) Generate(ctx context.Context, msg Message, params *RequestParams) (Message, error) {
+	if err := ctx.Err(); err != nil {
+		return Message{}, err
+	}
+
+	reqParams := mergeRequestParams(l.defaults, params)
+	memory := requestMemory(reqParams, l.memory)
+
+	// Store user message in history if enabled
+	if reqParams.UseHistory {
+		if err := memory.Add(msg, false); err != nil {
+			return Message{}, fmt.Errorf("failed to add message to history: %w", err)
+		}
+	}
+
+	turn, err := l.next()
+	if err != nil {
+		return Message{}, err
+	}
+
+	// Replay tool turns until the script reaches a plain response. Usage
+	// recorded with the script is summed so cost accounting can be tested.
+	var toolCalls []ToolCall
+	var turns []Message
+	var usage Usage
+	for iterCount := 0; len(turn.ToolCalls) > 0; iterCount++ {
+		if iterCount >= reqParams.MaxIterations {
+			return Message{}, fmt.Errorf("playback exceeded %d tool iterations", reqParams.MaxIterations)
+		}
+
+		if turn.Usage != nil {
+			usage.Add(*turn.Usage)
+		}
+		calls := make([]ToolCall, len(turn.ToolCalls))
+		copy(calls, turn.ToolCalls)
+		results := executeToolCalls(ctx, calls, reqParams.parallelTools(), reqParams.MaxParallelTools, nil,
+			func(ctx context.Context, i int) tools.ToolResult {
+				return executeAllowedTool(ctx, l.tools, reqParams, calls[i])
+			})
+		for i, result := range results {
+			usage.addTool(result.Cost)
+			l.logger.Debug(ctx, "Replayed tool call", logging.WithData(map[string]interface{}{
+				"tool":   calls[i].Name,
+				"result": result.Content,
+			}))
+		}
+		toolCalls = append(toolCalls, calls...)
+		turns = append(turns, toolUseTurn(l.name, turn.Content, calls)...)
+
+		if turn, err = l.next(); err != nil {
+			return Message{}, err
+		}
+	}
+
+	if turn.Usage != nil {
+		usage.Add(*turn.Usage)
+	}
+
+	response := Message{
+		Type:      MessageTypeAssistant,
+		Content:   turn.Content,
+		Name:      l.name,
+		ToolCalls: toolCalls,
+		Metadata:  turn.Metadata,
+		Parts:     turn.Parts,
+	}
+	if usage != (Usage{}) {
+		response.Usage = &usage
+	}
+
+	// Store the tool use rounds and the response in history if enabled,
+	// the same way the provider LLMs do
+	if reqParams.UseHistory {
+		final := response
+		final.ToolCalls = nil
+		final.Usage = nil
+		for _, m := range append(turns, final) {
+			if err := memory.Add(m, false); err != nil {
+				return Message{}, fmt.Errorf("failed to add response to history: %w", err)
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// next pops the next assistant turn from the script
+func (l *PlaybackLLM) next() (Message, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pos >= len(l.script) {
+		return Message{}, fmt.Errorf("playback exhausted after %d responses", len(l.script))
+	}
+	msg := deepCopyMessage(l.script[l.pos])
+	l.pos++
+	return msg, nil
+}
+
+// Remaining returns the number of assistant turns left to replay
+func (l *PlaybackLLM) Remaining() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.script) - l.pos
+}
+
+// Reset rewinds playback to the start of the script
+func (l *PlaybackLLM) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pos = 0
+}
+
+// GenerateStream returns the response of Generate as a stream of events
+func (l *PlaybackLLM) GenerateStream(ctx context.Context, msg Message, params *RequestParams) (<-chan StreamEvent, error) {
+	return streamGenerated(ctx, func() (Message, error) {
+		return l.Generate(ctx, msg, params)
+	}), nil
+}
+
+// GenerateString is a convenience method for simple text interactions
+func (l *PlaybackLLM) GenerateString(ctx context.Context, content string, params *RequestParams) (string, error) {
+	response, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: content}, params)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// CallTool executes a tool call through the registry and returns its content
+func (l *PlaybackLLM) CallTool(ctx context.Context, call ToolCall) (string, error) {
+	result, err := l.ExecuteTool(ctx, call.Name, call.Args)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// ExecuteTool executes a specific tool directly
+func (l *PlaybackLLM) ExecuteTool(ctx context.Context, toolName string, args map[string]any) (tools.ToolResult, error) {
+	result, err := l.tools.Call(ctx, toolName, args)
+	if err != nil {
+		return tools.ToolResult{}, fmt.Errorf("failed to execute tool %s: %w", toolName, err)
+	}
+	return result, nil
+}
+
+// Name returns the identifier for this LLM instance
+func (l *PlaybackLLM) Name() string {
+	return l.name
+}
+
+// Provider returns the LLM provider
+func (l *PlaybackLLM) Provider() string {
+	return "playback"
+}
+
+// Cleanup closes the tool registry, cleaning up its tools and providers,
+// and clears the conversation history
+func (l *PlaybackLLM) Cleanup() error {
+	return errors.Join(l.tools.Close(), l.memory.Clear(true))
+}
+
+// Tools returns the tool registry for this LLM
+func (l *PlaybackLLM) Tools() tools.ToolRegistry {
+	return l.tools
+}
+
+// Memory returns the conversation memory, so tests can inspect history
+func (l *PlaybackLLM) Memory() Memory {
+	return l.memory
+}
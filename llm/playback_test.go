@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/tools"
+)
+
+func TestPlaybackLLM(t *testing.T) {
+	ctx := context.Background()
+
+	conversation := []Message{
+		{Type: MessageTypeUser, Content: "hi"},
+		{Type: MessageTypeAssistant, Content: "hello"},
+		{Type: MessageTypeUser, Content: "what time is it?"},
+		{
+			Type: MessageTypeAssistant,
+			ToolCalls: []ToolCall{{
+				ID:   "call_1",
+				Name: "clock",
+				Args: map[string]any{"input": "UTC"},
+			}},
+		},
+		{Type: MessageTypeAssistant, Content: "it is noon"},
+	}
+
+	t.Run("replays assistant turns in order", func(t *testing.T) {
+		l := NewPlaybackLLM("playback", conversation[:2])
+		assert.Equal(t, 1, l.Remaining())
+
+		resp, err := l.GenerateString(ctx, "anything", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", resp)
+		assert.Equal(t, 0, l.Remaining())
+
+		_, err = l.GenerateString(ctx, "again", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "playback exhausted")
+
+		l.Reset()
+		resp, err = l.GenerateString(ctx, "again", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", resp)
+	})
+
+	t.Run("executes replayed tool calls", func(t *testing.T) {
+		l := NewPlaybackLLM("playback", conversation)
+		require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "clock", "Tell the time", func(tz string) string {
+			return "12:00 " + tz
+		}))
+
+		_, err := l.GenerateString(ctx, "hi", nil)
+		require.NoError(t, err)
+
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "what time is it?"}, &RequestParams{
+			Tools: []string{"clock"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "it is noon", resp.Content)
+		require.Len(t, resp.ToolCalls, 1)
+		assert.Equal(t, "clock", resp.ToolCalls[0].Name)
+		assert.Equal(t, "12:00 UTC", resp.ToolCalls[0].Response)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		l := NewPlaybackLLM("playback", conversation[:2])
+
+		_, err := l.GenerateString(ctx, "hi", &RequestParams{UseHistory: true})
+		require.NoError(t, err)
+
+		history, err := l.Memory().Get(true)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, "hi", history[0].Content)
+		assert.Equal(t, "hello", history[1].Content)
+	})
+
+	t.Run("tool iteration limit", func(t *testing.T) {
+		l := NewPlaybackLLM("playback", conversation[3:])
+
+		_, err := l.GenerateString(ctx, "loop", &RequestParams{MaxIterations: 1, Tools: []string{"clock"}})
+		require.NoError(t, err)
+
+		l = NewPlaybackLLM("playback", []Message{conversation[3], conversation[3], conversation[4]})
+		_, err = l.GenerateString(ctx, "loop", &RequestParams{MaxIterations: 1})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeded 1 tool iterations")
+	})
+}
@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelPrice holds the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input      float64 // Uncached prompt tokens
+	Output     float64 // Generated tokens
+	CacheWrite float64 // Prompt tokens written to the cache
+	CacheRead  float64 // Prompt tokens read from the cache
+}
+
+// Cost returns the USD cost of the token counts in usage
+func (p ModelPrice) Cost(usage Usage) float64 {
+	return (float64(usage.InputTokens)*p.Input +
+		float64(usage.OutputTokens)*p.Output +
+		float64(usage.CacheCreationInputTokens)*p.CacheWrite +
+		float64(usage.CacheReadInputTokens)*p.CacheRead) / 1_000_000
+}
+
+var (
+	pricesMu sync.RWMutex
+
+	// modelPrices maps model name prefixes to their list prices. Dated and
+	// -latest model names match the entry with the longest common prefix.
+	modelPrices = map[string]ModelPrice{
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+		"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03},
+		"claude-3-opus":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+		"gpt-4o":            {Input: 2.50, Output: 10, CacheRead: 1.25},
+		"gpt-4o-mini":       {Input: 0.15, Output: 0.60, CacheRead: 0.075},
+		"gpt-4.1":           {Input: 2, Output: 8, CacheRead: 0.50},
+		"gpt-4.1-mini":      {Input: 0.40, Output: 1.60, CacheRead: 0.10},
+		"gpt-4.1-nano":      {Input: 0.10, Output: 0.40, CacheRead: 0.025},
+		"o1":                {Input: 15, Output: 60, CacheRead: 7.50},
+		"o1-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.55},
+		"o3":                {Input: 10, Output: 40, CacheRead: 2.50},
+		"o3-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.55},
+		"o4-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.275},
+	}
+)
+
+// RegisterModelPrice sets the price for a model or model name prefix,
+// overriding the built-in table. Use it for negotiated rates or models
+// served by other OpenAI-compatible endpoints.
+func RegisterModelPrice(model string, price ModelPrice) {
+	pricesMu.Lock()
+	defer pricesMu.Unlock()
+	modelPrices[model] = price
+}
+
+// LookupModelPrice returns the price of a model, matching the longest
+// registered prefix of the model name
+func LookupModelPrice(model string) (ModelPrice, bool) {
+	pricesMu.RLock()
+	defer pricesMu.RUnlock()
+
+	var best string
+	for prefix := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return modelPrices[best], true
+}
+
+// priceUsage sets the model and cost of usage. Models without a known
+// price are recorded with zero cost.
+func priceUsage(model string, usage *Usage) {
+	usage.Model = model
+	if price, ok := LookupModelPrice(model); ok {
+		usage.Cost = price.Cost(*usage)
+	}
+}
@@ -0,0 +1,373 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adimarco/hive/config"
+)
+
+// ModelSpec is a fully resolved model selection
+type ModelSpec struct {
+	// Provider is the name of the provider serving the model (e.g. "anthropic")
+	Provider string
+	// Model is the provider-specific model identifier
+	Model string
+	// ReasoningEffort is an optional effort level ("low", "medium", "high")
+	ReasoningEffort string
+}
+
+// String returns the spec in <provider>.<model>.<reasoning_effort?> form
+func (s ModelSpec) String() string {
+	parts := []string{s.Provider, s.Model}
+	if s.ReasoningEffort != "" {
+		parts = append(parts, s.ReasoningEffort)
+	}
+	return strings.Join(parts, ".")
+}
+
+// reasoningEfforts lists the accepted reasoning effort suffixes
+var reasoningEfforts = map[string]bool{
+	"low":    true,
+	"medium": true,
+	"high":   true,
+}
+
+// defaultModelAliases maps short names to fully qualified model strings
+var defaultModelAliases = map[string]string{
+	"haiku":    "anthropic.claude-3-5-haiku-latest",
+	"haiku3":   "anthropic.claude-3-haiku-20240307",
+	"haiku35":  "anthropic.claude-3-5-haiku-latest",
+	"sonnet":   "anthropic.claude-3-7-sonnet-latest",
+	"sonnet35": "anthropic.claude-3-5-sonnet-latest",
+	"sonnet37": "anthropic.claude-3-7-sonnet-latest",
+	"opus":     "anthropic.claude-3-opus-latest",
+	"opus3":    "anthropic.claude-3-opus-latest",
+}
+
+// modelPrefixes infers the provider of an unqualified model name
+var modelPrefixes = []struct {
+	prefix   string
+	provider string
+}{
+	{"claude-", "anthropic"},
+	{"gpt-", "openai"},
+	{"chatgpt-", "openai"},
+	{"o1", "openai"},
+	{"o3", "openai"},
+	{"o4", "openai"},
+}
+
+// ProviderRegistry holds the known LLM providers and model aliases, and
+// resolves model strings of the form <provider>.<model_string>.<reasoning_effort?>
+type ProviderRegistry struct {
+	mu        sync.RWMutex
+	createMu  sync.Mutex // serializes Initialize+CreateLLM on shared providers
+	providers map[string]Provider
+	aliases   map[string]string
+}
+
+// NewProviderRegistry creates an empty ProviderRegistry
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{
+		providers: make(map[string]Provider),
+		aliases:   make(map[string]string),
+	}
+}
+
+var (
+	defaultRegistry     *ProviderRegistry
+	defaultRegistryOnce sync.Once
+)
+
+// DefaultProviders returns the shared registry with the built-in Anthropic
+// and OpenAI providers and the standard model aliases
+func DefaultProviders() *ProviderRegistry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewProviderRegistry()
+		_ = defaultRegistry.Register(NewAnthropicProvider())
+		_ = defaultRegistry.Register(NewOpenAIProvider())
+		for alias, model := range defaultModelAliases {
+			defaultRegistry.RegisterAlias(alias, model)
+		}
+	})
+	return defaultRegistry
+}
+
+// ResolveModel resolves a model string or alias using the default registry
+func ResolveModel(model string) (ModelSpec, error) {
+	return DefaultProviders().Resolve(model)
+}
+
+// ResolveModelFor resolves a model string for an LLM of the given provider
+// using the default registry
+func ResolveModelFor(provider, model string) (ModelSpec, error) {
+	return DefaultProviders().ResolveFor(provider, model)
+}
+
+// Register adds a provider to the registry
+func (r *ProviderRegistry) Register(p Provider) error {
+	name := p.Name()
+	if name == "" {
+		return fmt.Errorf("provider name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.providers[name]; exists {
+		return fmt.Errorf("provider %q already registered", name)
+	}
+	r.providers[name] = p
+	return nil
+}
+
+// RegisterAlias maps a short name to a model string. The target may itself be
+// fully qualified (anthropic.claude-3-5-haiku-latest) or a bare model name.
+func (r *ProviderRegistry) RegisterAlias(alias, model string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[alias] = model
+}
+
+// Get retrieves a provider by name
+func (r *ProviderRegistry) Get(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q, must be one of: %s",
+			name, strings.Join(r.providerNames(), ", "))
+	}
+	return p, nil
+}
+
+// Resolve turns a model string or alias into a ModelSpec.
+// Unqualified model names are matched to a provider by their prefix
+// (claude-* is Anthropic, gpt-* and o1/o3/o4 are OpenAI).
+func (r *ProviderRegistry) Resolve(model string) (ModelSpec, error) {
+	spec, err := r.parse(model)
+	if err != nil {
+		return ModelSpec{}, err
+	}
+	if spec.Provider == "" {
+		return ModelSpec{}, fmt.Errorf("unknown provider or model alias %q: use <provider>.<model> with one of: %s",
+			model, strings.Join(r.Providers(), ", "))
+	}
+	return spec, nil
+}
+
+// ResolveFor resolves a model string for an LLM of the given provider.
+// Bare model names that cannot be attributed to any provider are assumed to
+// belong to it, which allows arbitrary model names on local servers. A model
+// that resolves to a different registered provider is an error; LLMs from
+// unregistered providers, such as the test doubles, accept any model.
+func (r *ProviderRegistry) ResolveFor(provider, model string) (ModelSpec, error) {
+	spec, err := r.parse(model)
+	if err != nil {
+		return ModelSpec{}, err
+	}
+	if spec.Provider == "" {
+		spec.Provider = provider
+	}
+
+	r.mu.RLock()
+	_, registered := r.providers[provider]
+	r.mu.RUnlock()
+
+	if registered && spec.Provider != provider {
+		return ModelSpec{}, fmt.Errorf("model %q is served by provider %q, not %q", model, spec.Provider, provider)
+	}
+	return spec, nil
+}
+
+// parse splits a model string into its parts, leaving Provider empty when it
+// cannot be determined
+func (r *ProviderRegistry) parse(model string) (ModelSpec, error) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return ModelSpec{}, fmt.Errorf("model string is empty")
+	}
+
+	r.mu.RLock()
+	if target, ok := r.aliases[model]; ok {
+		model = target
+	}
+	parts := strings.Split(model, ".")
+	var spec ModelSpec
+	if _, ok := r.providers[parts[0]]; ok && len(parts) > 1 {
+		spec.Provider = parts[0]
+		parts = parts[1:]
+	}
+	r.mu.RUnlock()
+
+	if len(parts) > 1 && reasoningEfforts[parts[len(parts)-1]] {
+		spec.ReasoningEffort = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+
+	spec.Model = strings.Join(parts, ".")
+	if spec.Model == "" {
+		return ModelSpec{}, fmt.Errorf("model string %q has no model name", model)
+	}
+
+	if spec.Provider == "" {
+		for _, p := range modelPrefixes {
+			if strings.HasPrefix(spec.Model, p.prefix) {
+				spec.Provider = p.provider
+				break
+			}
+		}
+	}
+
+	return spec, nil
+}
+
+// Providers returns the sorted names of the registered providers
+func (r *ProviderRegistry) Providers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.providerNames()
+}
+
+// providerNames returns sorted provider names; callers must hold the lock
+func (r *ProviderRegistry) providerNames() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreateLLM resolves cfg.DefaultModel, initializes the matching provider and
+// creates an LLM that uses the resolved model by default
+func (r *ProviderRegistry) CreateLLM(ctx context.Context, name string, cfg *config.Settings) (AugmentedLLM, error) {
+	spec, err := r.Resolve(cfg.DefaultModel)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := r.Get(spec.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	r.createMu.Lock()
+	defer r.createMu.Unlock()
+
+	if err := p.Initialize(ctx, cfg); err != nil {
+		return nil, fmt.Errorf("failed to initialize provider %q: %w", spec.Provider, err)
+	}
+
+	return p.CreateLLM(name, &RequestParams{
+		Model:           spec.Model,
+		ReasoningEffort: spec.ReasoningEffort,
+	})
+}
+
+// AnthropicProvider creates AnthropicLLM instances
+type AnthropicProvider struct {
+	settings *config.Settings
+}
+
+// NewAnthropicProvider creates a new AnthropicProvider
+func NewAnthropicProvider() *AnthropicProvider {
+	return &AnthropicProvider{}
+}
+
+// Initialize stores the configuration used for new LLMs
+func (p *AnthropicProvider) Initialize(ctx context.Context, cfg *config.Settings) error {
+	p.settings = cfg
+	return nil
+}
+
+// CreateLLM creates and initializes a new AnthropicLLM
+func (p *AnthropicProvider) CreateLLM(name string, params *RequestParams) (AugmentedLLM, error) {
+	l := NewAnthropicLLM(name)
+	if err := l.Initialize(context.Background(), p.settings); err != nil {
+		return nil, err
+	}
+	applyDefaults(l.defaults, params)
+	return l, nil
+}
+
+// Name returns the provider name
+func (p *AnthropicProvider) Name() string {
+	return "anthropic"
+}
+
+// OpenAIProvider creates OpenAILLM instances
+type OpenAIProvider struct {
+	settings *config.Settings
+}
+
+// NewOpenAIProvider creates a new OpenAIProvider
+func NewOpenAIProvider() *OpenAIProvider {
+	return &OpenAIProvider{}
+}
+
+// Initialize stores the configuration used for new LLMs
+func (p *OpenAIProvider) Initialize(ctx context.Context, cfg *config.Settings) error {
+	p.settings = cfg
+	return nil
+}
+
+// CreateLLM creates and initializes a new OpenAILLM
+func (p *OpenAIProvider) CreateLLM(name string, params *RequestParams) (AugmentedLLM, error) {
+	l := NewOpenAILLM(name)
+	if err := l.Initialize(context.Background(), p.settings); err != nil {
+		return nil, err
+	}
+	applyDefaults(l.defaults, params)
+	return l, nil
+}
+
+// Name returns the provider name
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// applyDefaults overrides an LLM's default request parameters with the
+// non-zero fields of params
+func applyDefaults(defaults, params *RequestParams) {
+	if params == nil {
+		return
+	}
+	if params.Model != "" {
+		defaults.Model = params.Model
+	}
+	if params.ReasoningEffort != "" {
+		defaults.ReasoningEffort = params.ReasoningEffort
+	}
+	if params.SystemPrompt != "" {
+		defaults.SystemPrompt = params.SystemPrompt
+	}
+	if params.MaxTokens > 0 {
+		defaults.MaxTokens = params.MaxTokens
+	}
+	if params.MaxIterations > 0 {
+		defaults.MaxIterations = params.MaxIterations
+	}
+	if params.Temperature != nil {
+		defaults.Temperature = params.Temperature
+	}
+}
+
+// resolveRequestModel resolves the model in params for the given provider,
+// keeping an explicitly requested reasoning effort
+func resolveRequestModel(provider string, params *RequestParams) error {
+	spec, err := ResolveModelFor(provider, params.Model)
+	if err != nil {
+		return err
+	}
+	params.Model = spec.Model
+	if params.ReasoningEffort == "" {
+		params.ReasoningEffort = spec.ReasoningEffort
+	}
+	return nil
+}
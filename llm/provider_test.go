@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+)
+
+// stubProvider records how it was initialized and creates OpenAILLM instances
+type stubProvider struct {
+	name     string
+	settings *config.Settings
+	params   *RequestParams
+}
+
+func (p *stubProvider) Initialize(ctx context.Context, cfg *config.Settings) error {
+	p.settings = cfg
+	return nil
+}
+
+func (p *stubProvider) CreateLLM(name string, params *RequestParams) (AugmentedLLM, error) {
+	p.params = params
+	return NewOpenAILLM(name), nil
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func TestProviderRegistry_Resolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		model       string
+		want        ModelSpec
+		errContains string
+	}{
+		{
+			name:  "qualified model",
+			model: "anthropic.claude-3-5-sonnet-20241022",
+			want:  ModelSpec{Provider: "anthropic", Model: "claude-3-5-sonnet-20241022"},
+		},
+		{
+			name:  "qualified model with effort",
+			model: "openai.o3-mini.low",
+			want:  ModelSpec{Provider: "openai", Model: "o3-mini", ReasoningEffort: "low"},
+		},
+		{
+			name:  "dotted model name",
+			model: "openai.gpt-4.1",
+			want:  ModelSpec{Provider: "openai", Model: "gpt-4.1"},
+		},
+		{
+			name:  "alias",
+			model: "haiku",
+			want:  ModelSpec{Provider: "anthropic", Model: "claude-3-5-haiku-latest"},
+		},
+		{
+			name:  "bare model inferred from prefix",
+			model: "claude-3-haiku-20240307",
+			want:  ModelSpec{Provider: "anthropic", Model: "claude-3-haiku-20240307"},
+		},
+		{
+			name:  "bare model with effort",
+			model: "o1.high",
+			want:  ModelSpec{Provider: "openai", Model: "o1", ReasoningEffort: "high"},
+		},
+		{
+			name:        "unknown provider",
+			model:       "mistral.large",
+			errContains: `unknown provider or model alias "mistral.large"`,
+		},
+		{
+			name:        "unknown alias",
+			model:       "sonnet99",
+			errContains: `unknown provider or model alias "sonnet99"`,
+		},
+		{
+			name:        "empty",
+			model:       "",
+			errContains: "model string is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := DefaultProviders().Resolve(tt.model)
+			if tt.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, spec)
+		})
+	}
+}
+
+func TestProviderRegistry_ResolveFor(t *testing.T) {
+	t.Run("bare model belongs to the provider", func(t *testing.T) {
+		spec, err := ResolveModelFor("openai", "llama-3.1-8b-instruct")
+		require.NoError(t, err)
+		assert.Equal(t, ModelSpec{Provider: "openai", Model: "llama-3.1-8b-instruct"}, spec)
+	})
+
+	t.Run("provider mismatch", func(t *testing.T) {
+		_, err := ResolveModelFor("openai", "sonnet")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `served by provider "anthropic", not "openai"`)
+	})
+
+	t.Run("round trips through String", func(t *testing.T) {
+		spec, err := ResolveModelFor("openai", "o3-mini.medium")
+		require.NoError(t, err)
+		assert.Equal(t, "openai.o3-mini.medium", spec.String())
+
+		again, err := ResolveModel(spec.String())
+		require.NoError(t, err)
+		assert.Equal(t, spec, again)
+	})
+}
+
+func TestProviderRegistry_CreateLLM(t *testing.T) {
+	registry := NewProviderRegistry()
+	stub := &stubProvider{name: "local"}
+	require.NoError(t, registry.Register(stub))
+	registry.RegisterAlias("small", "local.tiny-model.low")
+
+	t.Run("duplicate provider", func(t *testing.T) {
+		err := registry.Register(&stubProvider{name: "local"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already registered")
+	})
+
+	t.Run("resolves default model", func(t *testing.T) {
+		cfg := &config.Settings{DefaultModel: "small"}
+		l, err := registry.CreateLLM(context.Background(), "test", cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "test", l.Name())
+		assert.Same(t, cfg, stub.settings)
+		require.NotNil(t, stub.params)
+		assert.Equal(t, "tiny-model", stub.params.Model)
+		assert.Equal(t, "low", stub.params.ReasoningEffort)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := registry.Get("anthropic")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown provider "anthropic", must be one of: local`)
+	})
+}
@@ -0,0 +1,290 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/logging"
+)
+
+// maxRetryAfter bounds how long a server may ask us to wait before retrying.
+// Longer delays are treated as a failure rather than blocking the caller.
+const maxRetryAfter = time.Minute
+
+// RetryPolicy controls how failed provider API calls are retried. Delays
+// grow exponentially from InitialBackoff up to MaxBackoff with full jitter,
+// unless the server asks for a specific delay with a retry-after header.
+type RetryPolicy struct {
+	MaxAttempts    int           // Attempts per call, including the first
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound for any computed delay
+
+	// sleep waits between attempts; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// DefaultRetryPolicy returns the policy used when no retry settings are configured
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// NewRetryPolicy creates a RetryPolicy from settings, using the defaults
+// for unset fields
+func NewRetryPolicy(cfg config.RetrySettings) *RetryPolicy {
+	p := DefaultRetryPolicy()
+	if cfg.MaxAttempts > 0 {
+		p.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.InitialBackoff > 0 {
+		p.InitialBackoff = cfg.InitialBackoff
+	}
+	if cfg.MaxBackoff > 0 {
+		p.MaxBackoff = cfg.MaxBackoff
+	}
+	return p
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, or
+// the attempts are exhausted. The last error is returned.
+func (p *RetryPolicy) Do(ctx context.Context, logger logging.Logger, fn func() error) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		var stop *nonRetryableError
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+		if attempt >= attempts || !IsRetryable(err) {
+			return err
+		}
+
+		delay, ok := p.delay(attempt, err)
+		if !ok {
+			return err
+		}
+
+		if logger != nil {
+			logger.Warning(ctx, "Retrying API call", logging.WithData(map[string]interface{}{
+				"attempt":      attempt,
+				"max_attempts": attempts,
+				"delay":        delay.String(),
+				"error":        err.Error(),
+			}))
+		}
+
+		sleep := p.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if serr := sleep(ctx, delay); serr != nil {
+			return fmt.Errorf("%w (retry aborted: %v)", err, serr)
+		}
+	}
+}
+
+// delay returns how long to wait after a failed attempt. A retry-after
+// header from the server takes precedence over the computed backoff; false
+// is returned when the server asks for a delay longer than maxRetryAfter.
+func (p *RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	if d, ok := retryAfter(err); ok {
+		return d, d <= maxRetryAfter
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	// Full jitter spreads out retries from agents that failed together
+	return time.Duration(rand.Float64() * backoff), true
+}
+
+// nonRetryableError marks an error that must not be retried even though
+// its cause would normally be retryable, e.g. a stream that already
+// delivered output to the caller
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string { return e.err.Error() }
+func (e *nonRetryableError) Unwrap() error { return e.err }
+
+// IsRetryable reports whether an API error is transient: rate limiting
+// (429), overload (529), request timeouts and conflicts (408, 409), server
+// errors (5xx) and dropped connections. Context cancellation is never retried.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if status, ok := statusCode(err); ok {
+		switch {
+		case status == http.StatusRequestTimeout,
+			status == http.StatusConflict,
+			status == http.StatusTooManyRequests,
+			status >= 500:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// statusCode extracts the HTTP status of a provider API error
+func statusCode(err error) (int, bool) {
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.StatusCode, true
+	}
+	var openAIErr *openAIAPIError
+	if errors.As(err, &openAIErr) {
+		return openAIErr.StatusCode, true
+	}
+	return 0, false
+}
+
+// retryAfter extracts the server-requested delay from a provider API error,
+// reading retry-after-ms, then retry-after as seconds or an HTTP date
+func retryAfter(err error) (time.Duration, bool) {
+	var header http.Header
+	var anthropicErr *anthropic.Error
+	var openAIErr *openAIAPIError
+	switch {
+	case errors.As(err, &anthropicErr) && anthropicErr.Response != nil:
+		header = anthropicErr.Response.Header
+	case errors.As(err, &openAIErr):
+		header = openAIErr.Header
+	}
+	if header == nil {
+		return 0, false
+	}
+
+	if ms, perr := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); perr == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, perr := strconv.ParseFloat(value, 64); perr == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, perr := http.ParseTime(value); perr == nil {
+		d := time.Until(at)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RateLimiter is a token bucket that limits the rate of API calls. A single
+// limiter is shared by every agent using the same LLM, so a team of agents
+// stays within the provider's limits together.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewRateLimiter creates a RateLimiter allowing requestsPerMinute on average
+// with bursts of up to burst requests. A non-positive rate returns nil, which
+// is a valid limiter that never waits.
+func NewRateLimiter(requestsPerMinute float64, burst int) *RateLimiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   requestsPerMinute / 60,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+}
+
+// Wait blocks until a request may be made or ctx is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := r.now()
+	if !r.last.IsZero() {
+		r.tokens = math.Min(r.burst, r.tokens+now.Sub(r.last).Seconds()*r.rate)
+	}
+	r.last = now
+
+	// Reserve a token now, possibly going into debt, so that concurrent
+	// callers queue up behind each other instead of all waking at once
+	r.tokens--
+	var wait time.Duration
+	if r.tokens < 0 {
+		wait = time.Duration(-r.tokens / r.rate * float64(time.Second))
+	}
+	r.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if err := r.sleep(ctx, wait); err != nil {
+		// Return the unused reservation
+		r.mu.Lock()
+		r.tokens++
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
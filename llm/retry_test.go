@@ -0,0 +1,331 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+)
+
+// recordSleeps replaces the policy's sleep with one that records delays
+// instead of waiting
+func recordSleeps(p *RetryPolicy) *[]time.Duration {
+	var delays []time.Duration
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return &delays
+}
+
+// flakyHandler fails the first failures requests with status and header,
+// then passes requests on to next
+func flakyHandler(failures, status int, header http.Header, next http.Handler) (http.Handler, *int) {
+	var mu sync.Mutex
+	calls := 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		fail := calls <= failures
+		mu.Unlock()
+
+		if !fail {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"try again"}}`))
+	}), &calls
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limited", &openAIAPIError{StatusCode: 429}, true},
+		{"overloaded", &openAIAPIError{StatusCode: 529}, true},
+		{"server error", &openAIAPIError{StatusCode: 503}, true},
+		{"request timeout", &openAIAPIError{StatusCode: 408}, true},
+		{"bad request", &openAIAPIError{StatusCode: 400}, false},
+		{"unauthorized", &openAIAPIError{StatusCode: 401}, false},
+		{"wrapped", fmt.Errorf("anthropic API error: %w", &openAIAPIError{StatusCode: 429}), true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("call failed: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	ctx := context.Background()
+	policy := func() *RetryPolicy {
+		return NewRetryPolicy(config.RetrySettings{
+			MaxAttempts:    3,
+			InitialBackoff: 100 * time.Millisecond,
+			MaxBackoff:     150 * time.Millisecond,
+		})
+	}
+
+	t.Run("retries until success", func(t *testing.T) {
+		p := policy()
+		delays := recordSleeps(p)
+
+		calls := 0
+		err := p.Do(ctx, nil, func() error {
+			calls++
+			if calls < 3 {
+				return &openAIAPIError{StatusCode: 529}
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		require.Len(t, *delays, 2)
+		assert.LessOrEqual(t, (*delays)[0], 100*time.Millisecond)
+		assert.LessOrEqual(t, (*delays)[1], 150*time.Millisecond)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		p := policy()
+		recordSleeps(p)
+
+		calls := 0
+		err := p.Do(ctx, nil, func() error {
+			calls++
+			return &openAIAPIError{StatusCode: 429, Message: "slow down"}
+		})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "slow down")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		p := policy()
+		delays := recordSleeps(p)
+
+		calls := 0
+		err := p.Do(ctx, nil, func() error {
+			calls++
+			return &openAIAPIError{StatusCode: 400}
+		})
+
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, *delays)
+	})
+
+	t.Run("does not retry marked errors", func(t *testing.T) {
+		p := policy()
+		cause := &openAIAPIError{StatusCode: 529}
+
+		calls := 0
+		err := p.Do(ctx, nil, func() error {
+			calls++
+			return &nonRetryableError{err: cause}
+		})
+
+		assert.Same(t, cause, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("honours retry-after", func(t *testing.T) {
+		p := policy()
+		delays := recordSleeps(p)
+
+		header := http.Header{}
+		header.Set("Retry-After", "2")
+		calls := 0
+		err := p.Do(ctx, nil, func() error {
+			calls++
+			if calls == 1 {
+				return &openAIAPIError{StatusCode: 429, Header: header}
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{2 * time.Second}, *delays)
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		p := policy()
+		recordSleeps(p)
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := p.Do(cctx, nil, func() error {
+			return &openAIAPIError{StatusCode: 503}
+		})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "retry aborted")
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+		ok     bool
+	}{
+		{"milliseconds", map[string]string{"Retry-After-Ms": "1500"}, 1500 * time.Millisecond, true},
+		{"seconds", map[string]string{"Retry-After": "3"}, 3 * time.Second, true},
+		{"milliseconds take precedence", map[string]string{"Retry-After-Ms": "10", "Retry-After": "3"}, 10 * time.Millisecond, true},
+		{"past date", map[string]string{"Retry-After": "Mon, 02 Jan 2006 15:04:05 GMT"}, 0, true},
+		{"invalid", map[string]string{"Retry-After": "soon"}, 0, false},
+		{"missing", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			got, ok := retryAfter(&openAIAPIError{StatusCode: 429, Header: header})
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("long delays are not retried", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Retry-After", "3600")
+		_, ok := DefaultRetryPolicy().delay(1, &openAIAPIError{StatusCode: 429, Header: header})
+		assert.False(t, ok)
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("nil limiter never waits", func(t *testing.T) {
+		var limiter *RateLimiter
+		assert.Nil(t, NewRateLimiter(0, 10))
+		assert.NoError(t, limiter.Wait(ctx))
+	})
+
+	t.Run("allows bursts then paces requests", func(t *testing.T) {
+		limiter := NewRateLimiter(60, 2)
+		now := time.Unix(0, 0)
+		limiter.now = func() time.Time { return now }
+		var waits []time.Duration
+		limiter.sleep = func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, limiter.Wait(ctx))
+		}
+		// Two requests fit the burst, the next two queue one second apart
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+
+		// After the debt is repaid and the bucket refills, a burst is allowed again
+		now = now.Add(10 * time.Second)
+		waits = nil
+		require.NoError(t, limiter.Wait(ctx))
+		require.NoError(t, limiter.Wait(ctx))
+		assert.Empty(t, waits)
+	})
+
+	t.Run("cancelled wait returns its reservation", func(t *testing.T) {
+		limiter := NewRateLimiter(60, 1)
+		now := time.Unix(0, 0)
+		limiter.now = func() time.Time { return now }
+		limiter.sleep = func(ctx context.Context, d time.Duration) error {
+			return context.Canceled
+		}
+
+		require.NoError(t, limiter.Wait(ctx))
+		assert.ErrorIs(t, limiter.Wait(ctx), context.Canceled)
+		assert.Equal(t, 0.0, limiter.tokens)
+	})
+}
+
+func TestProviderRetries(t *testing.T) {
+	ctx := context.Background()
+	retryAfterZero := http.Header{"Retry-After": []string{"0"}}
+
+	t.Run("anthropic retries overloaded responses", func(t *testing.T) {
+		fake := &fakeAnthropicServer{turns: []fakeAnthropicTurn{{text: []string{"recovered"}}}}
+		handler, calls := flakyHandler(2, 529, retryAfterZero, fake.handler(t))
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		l := NewAnthropicLLM("test")
+		require.NoError(t, l.Initialize(ctx, &config.Settings{
+			Anthropic: config.AnthropicSettings{APIKey: "test-key", BaseURL: server.URL},
+			Retry:     config.RetrySettings{MaxAttempts: 3},
+		}))
+
+		resp, err := l.GenerateString(ctx, "hi", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "recovered", resp)
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("anthropic gives up after max attempts", func(t *testing.T) {
+		fake := &fakeAnthropicServer{}
+		handler, calls := flakyHandler(5, http.StatusTooManyRequests, retryAfterZero, fake.handler(t))
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		l := NewAnthropicLLM("test")
+		require.NoError(t, l.Initialize(ctx, &config.Settings{
+			Anthropic: config.AnthropicSettings{APIKey: "test-key", BaseURL: server.URL},
+			Retry:     config.RetrySettings{MaxAttempts: 2},
+		}))
+
+		_, err := l.GenerateString(ctx, "hi", nil)
+		require.Error(t, err)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("openai retries rate limited calls", func(t *testing.T) {
+		fake := &fakeOpenAIServer{responses: []openAIMessage{{Role: "assistant", Content: "recovered"}}}
+		handler, calls := flakyHandler(1, http.StatusTooManyRequests, retryAfterZero, fake.handler(t))
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		l := NewOpenAILLM("test")
+		require.NoError(t, l.Initialize(ctx, &config.Settings{
+			DefaultModel: "local-model",
+			OpenAI:       config.OpenAISettings{BaseURL: server.URL + "/v1"},
+		}))
+
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "hi"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "recovered", resp.Content)
+		assert.Equal(t, 2, *calls)
+		// Only the successful attempt is billed
+		require.NotNil(t, resp.Usage)
+		assert.Equal(t, 1, resp.Usage.Requests)
+	})
+}
@@ -42,10 +42,26 @@ type SerializedMessage struct {
 	Content []SerializedContent `yaml:"content" json:"content"`
 	// Name optionally identifies the sender
 	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// ToolCalls holds any tool operations requested by this message
+	ToolCalls []SerializedToolCall `yaml:"tool_calls,omitempty" json:"tool_calls,omitempty"`
 	// Metadata allows for additional structured data
 	Metadata map[string]interface{} `yaml:"metadata,omitempty" json:"metadata,omitempty"`
 }
 
+// SerializedToolCall represents a tool invocation recorded in a message
+type SerializedToolCall struct {
+	// ID uniquely identifies this tool call
+	ID string `yaml:"id,omitempty" json:"id,omitempty"`
+	// Name identifies which tool to call
+	Name string `yaml:"name" json:"name"`
+	// Args holds the parameters for the tool call
+	Args map[string]interface{} `yaml:"args,omitempty" json:"args,omitempty"`
+	// Response stores the result of the tool call
+	Response string `yaml:"response,omitempty" json:"response,omitempty"`
+	// IsError reports whether Response is an error result
+	IsError bool `yaml:"is_error,omitempty" json:"is_error,omitempty"`
+}
+
 // SerializedConversation represents a sequence of messages that can be
 // saved/loaded as a unit. This is useful for test scenarios and history.
 type SerializedConversation struct {
@@ -104,6 +120,17 @@ func (sm *SerializedMessage) ToMessage() (llm.Message, error) {
 		}
 	}
 
+	// Convert tool calls
+	for _, call := range sm.ToolCalls {
+		msg.ToolCalls = append(msg.ToolCalls, llm.ToolCall{
+			ID:       call.ID,
+			Name:     call.Name,
+			Args:     call.Args,
+			Response: call.Response,
+			IsError:  call.IsError,
+		})
+	}
+
 	return msg, nil
 }
 
@@ -150,6 +177,17 @@ func FromMessage(msg llm.Message) SerializedMessage {
 		sm.Content = append(sm.Content, content)
 	}
 
+	// Convert tool calls
+	for _, call := range msg.ToolCalls {
+		sm.ToolCalls = append(sm.ToolCalls, SerializedToolCall{
+			ID:       call.ID,
+			Name:     call.Name,
+			Args:     call.Args,
+			Response: call.Response,
+			IsError:  call.IsError,
+		})
+	}
+
 	return sm
 }
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/adimarco/hive/llm"
 )
@@ -88,6 +89,30 @@ func TestSerializedMessage_Conversion(t *testing.T) {
 		assert.Equal(t, "data.json", sm2.Content[3].Path)
 	})
 
+	t.Run("tool result message", func(t *testing.T) {
+		msg := llm.Message{
+			Type: llm.MessageTypeTool,
+			ToolCalls: []llm.ToolCall{
+				{ID: "call_1", Name: "fetch", Response: "page contents"},
+				{ID: "call_2", Name: "fetch", Response: "not found", IsError: true},
+			},
+		}
+
+		sm := FromMessage(msg)
+		assert.Equal(t, "tool", sm.Role)
+		require.Len(t, sm.ToolCalls, 2)
+		assert.True(t, sm.ToolCalls[1].IsError)
+
+		data, err := yaml.Marshal(sm)
+		require.NoError(t, err)
+		var decoded SerializedMessage
+		require.NoError(t, yaml.Unmarshal(data, &decoded))
+
+		roundTrip, err := decoded.ToMessage()
+		require.NoError(t, err)
+		assert.Equal(t, msg, roundTrip)
+	})
+
 	t.Run("invalid role", func(t *testing.T) {
 		sm := SerializedMessage{
 			Role: "invalid",
@@ -131,3 +131,19 @@ func NewConversation(name string, description string, messages []llm.Message) *S
 func (sc *SerializedConversation) ToMessages() ([]llm.Message, error) {
 	return ConvertToMessages(sc.Messages)
 }
+
+// LoadPlaybackLLM creates a PlaybackLLM that replays the assistant turns of
+// the conversation stored in a YAML file.
+func LoadPlaybackLLM(name string, path string) (*llm.PlaybackLLM, error) {
+	conv, err := LoadConversation(path)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := conv.ToMessages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert messages: %w", err)
+	}
+
+	return llm.NewPlaybackLLM(name, messages), nil
+}
@@ -2,6 +2,7 @@ package serialization
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/tools"
 )
 
 func TestYAMLSerialization(t *testing.T) {
@@ -206,3 +208,88 @@ func TestConversionHelpers(t *testing.T) {
 		assert.Equal(t, messages[1].Parts[0].Content, converted[1].Parts[0].Content)
 	})
 }
+
+func TestLoadPlaybackLLM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "playback.yaml")
+	err := os.WriteFile(path, []byte(`name: weather
+messages:
+  - role: user
+    content:
+      - type: text
+        text: what's the weather?
+  - role: assistant
+    content: []
+    tool_calls:
+      - id: call_1
+        name: weather
+        args:
+          input: Paris
+  - role: assistant
+    content:
+      - type: text
+        text: sunny in Paris
+`), 0644)
+	require.NoError(t, err)
+
+	playback, err := LoadPlaybackLLM("weather", path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, playback.Remaining())
+
+	require.NoError(t, tools.RegisterFunctionTool(playback.Tools(), "weather", "Get the weather", func(city string) string {
+		return "sunny in " + city
+	}))
+
+	resp, err := playback.Generate(context.Background(), llm.Message{
+		Type:    llm.MessageTypeUser,
+		Content: "what's the weather?",
+	}, &llm.RequestParams{Tools: []string{"weather"}})
+	require.NoError(t, err)
+	assert.Equal(t, "sunny in Paris", resp.Content)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "call_1", resp.ToolCalls[0].ID)
+	assert.Equal(t, "sunny in Paris", resp.ToolCalls[0].Response)
+
+	t.Run("tool calls round trip", func(t *testing.T) {
+		conv := NewConversation("round trip", "", []llm.Message{resp})
+		out := filepath.Join(t.TempDir(), "out.yaml")
+		require.NoError(t, SaveConversation(conv, out))
+
+		loaded, err := LoadConversation(out)
+		require.NoError(t, err)
+		msgs, err := loaded.ToMessages()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, resp.ToolCalls, msgs[0].ToolCalls)
+	})
+
+	t.Run("tool call without arguments", func(t *testing.T) {
+		recorded := NewConversation("clock", "", []llm.Message{
+			{Type: llm.MessageTypeUser, Content: "what time is it?"},
+			{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "clock"}}},
+			{Type: llm.MessageTypeAssistant, Content: "it is noon"},
+		})
+		path := filepath.Join(t.TempDir(), "clock.yaml")
+		require.NoError(t, SaveConversation(recorded, path))
+
+		playback, err := LoadPlaybackLLM("clock", path)
+		require.NoError(t, err)
+		require.NoError(t, tools.RegisterFunctionTool(playback.Tools(), "clock", "Tell the time", func() string {
+			return "12:00"
+		}))
+
+		resp, err := playback.Generate(context.Background(), llm.Message{
+			Type:    llm.MessageTypeUser,
+			Content: "what time is it?",
+		}, &llm.RequestParams{Tools: []string{"clock"}})
+		require.NoError(t, err)
+		assert.Equal(t, "it is noon", resp.Content)
+		require.Len(t, resp.ToolCalls, 1)
+		assert.False(t, resp.ToolCalls[0].IsError, resp.ToolCalls[0].Response)
+		assert.Equal(t, "12:00", resp.ToolCalls[0].Response)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadPlaybackLLM("missing", filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adimarco/hive/tools"
+)
+
+// StreamEventType identifies the kind of a StreamEvent
+type StreamEventType string
+
+const (
+	// StreamEventTextDelta carries a fragment of assistant text as it is generated
+	StreamEventTextDelta StreamEventType = "text_delta"
+	// StreamEventToolUse signals that the model requested a tool call
+	StreamEventToolUse StreamEventType = "tool_use"
+	// StreamEventToolProgress carries a partial result, such as a progress
+	// update or chunk of output, of a tool that streams its results
+	StreamEventToolProgress StreamEventType = "tool_progress"
+	// StreamEventToolResult carries the result of a tool call
+	StreamEventToolResult StreamEventType = "tool_result"
+	// StreamEventUsage reports token usage for one API call
+	StreamEventUsage StreamEventType = "usage"
+	// StreamEventMessage carries the final assistant message; it is always
+	// the last event of a successful stream
+	StreamEventMessage StreamEventType = "message"
+	// StreamEventError reports a failure; it is always the last event of a
+	// failed stream
+	StreamEventError StreamEventType = "error"
+)
+
+// StreamEvent is a single event produced by GenerateStream.
+// Only the fields relevant to the event Type are set.
+type StreamEvent struct {
+	// Type identifies which of the fields below are populated
+	Type StreamEventType
+	// Text holds the text fragment for StreamEventTextDelta
+	Text string
+	// ToolCall holds the call for StreamEventToolUse, with Response filled
+	// in for StreamEventToolResult
+	ToolCall *ToolCall
+	// IsError reports whether a StreamEventToolResult is an error result
+	IsError bool
+	// Partial holds the partial result for StreamEventToolProgress
+	Partial *tools.ToolResult
+	// Usage holds token counts for StreamEventUsage
+	Usage *Usage
+	// Message holds the final response for StreamEventMessage
+	Message *Message
+	// Err holds the failure for StreamEventError
+	Err error
+}
+
+// emitFunc receives stream events from a generation. A nil emitFunc
+// discards events, which lets Generate and GenerateStream share one
+// implementation.
+type emitFunc func(StreamEvent)
+
+// send delivers an event if the emitter is set
+func (e emitFunc) send(event StreamEvent) {
+	if e != nil {
+		e(event)
+	}
+}
+
+// toolUse emits a StreamEventToolUse for call
+func (e emitFunc) toolUse(call ToolCall) {
+	e.send(StreamEvent{Type: StreamEventToolUse, ToolCall: &call})
+}
+
+// toolProgress emits a StreamEventToolProgress for call
+func (e emitFunc) toolProgress(call ToolCall, part tools.ToolResult) {
+	e.send(StreamEvent{Type: StreamEventToolProgress, ToolCall: &call, Partial: &part})
+}
+
+// toolResult emits a StreamEventToolResult for call
+func (e emitFunc) toolResult(call ToolCall, isError bool) {
+	e.send(StreamEvent{Type: StreamEventToolResult, ToolCall: &call, IsError: isError})
+}
+
+// runStream runs generate in a goroutine and returns its events on a
+// channel. The final message or error is always the last event, after
+// which the channel is closed. Consumers must drain the channel or cancel
+// ctx.
+func runStream(ctx context.Context, generate func(emit emitFunc) (Message, error)) <-chan StreamEvent {
+	events := make(chan StreamEvent, 16)
+
+	go func() {
+		defer close(events)
+
+		emit := emitFunc(func(event StreamEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		})
+
+		response, err := generate(emit)
+		if err != nil {
+			emit.send(StreamEvent{Type: StreamEventError, Err: err})
+			return
+		}
+		emit.send(StreamEvent{Type: StreamEventMessage, Message: &response})
+	}()
+
+	return events
+}
+
+// streamGenerated adapts a blocking Generate call into a stream for LLMs
+// without native streaming. The tool calls of the response are replayed as
+// events, followed by the full content as a single text delta.
+func streamGenerated(ctx context.Context, generate func() (Message, error)) <-chan StreamEvent {
+	return runStream(ctx, func(emit emitFunc) (Message, error) {
+		response, err := generate()
+		if err != nil {
+			return Message{}, err
+		}
+		for _, call := range response.ToolCalls {
+			emit.toolUse(ToolCall{ID: call.ID, Name: call.Name, Args: call.Args})
+			emit.toolResult(call, false)
+		}
+		if response.Content != "" {
+			emit.send(StreamEvent{Type: StreamEventTextDelta, Text: response.Content})
+		}
+		return response, nil
+	})
+}
+
+// CollectStream drains a stream and returns its final message. Text
+// deltas, tool and usage events are discarded.
+func CollectStream(events <-chan StreamEvent) (Message, error) {
+	for event := range events {
+		switch event.Type {
+		case StreamEventMessage:
+			return *event.Message, nil
+		case StreamEventError:
+			return Message{}, event.Err
+		}
+	}
+	return Message{}, fmt.Errorf("stream ended without a final message")
+}
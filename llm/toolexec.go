@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/adimarco/hive/tools"
+)
+
+// DefaultMaxParallelTools bounds how many tool calls from one model response
+// run at once when RequestParams.MaxParallelTools is not set
+const DefaultMaxParallelTools = 8
+
+// toolRunner executes the i-th requested tool call with its own context
+type toolRunner func(ctx context.Context, i int) tools.ToolResult
+
+// executeToolCalls runs the tool calls requested in one model response and
+// returns their results in the order of calls, filling in each call's
+// Response and IsError. With parallel set, up to limit calls run concurrently;
+// otherwise they run one at a time. Every call gets a context that is
+// cancelled when it finishes, and calls that have not started when ctx is
+// done get an error result instead of running.
+func executeToolCalls(ctx context.Context, calls []ToolCall, parallel bool, limit int, emit emitFunc, run toolRunner) []tools.ToolResult {
+	results := make([]tools.ToolResult, len(calls))
+
+	runOne := func(i int) {
+		if err := ctx.Err(); err != nil {
+			results[i] = tools.NewErrorResult(fmt.Errorf("tool call cancelled: %w", err))
+		} else {
+			callCtx, cancel := context.WithCancel(ctx)
+			results[i] = run(callCtx, i)
+			cancel()
+		}
+		calls[i].Response = results[i].Content
+		calls[i].IsError = results[i].IsError
+		emit.toolResult(calls[i], results[i].IsError)
+	}
+
+	if !parallel || len(calls) < 2 {
+		for i := range calls {
+			emit.toolUse(calls[i])
+			runOne(i)
+		}
+		return results
+	}
+
+	if limit <= 0 {
+		limit = DefaultMaxParallelTools
+	}
+
+	// Announce every call up front so consumers see the whole fan-out,
+	// then report results as they complete
+	for i := range calls {
+		emit.toolUse(calls[i])
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := range calls {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+			}
+			runOne(i)
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// authorizeToolCall asks policy to approve a call to a registered tool.
+// Unknown tools are left for the runner to report.
+func authorizeToolCall(ctx context.Context, registry tools.ToolRegistry, policy *tools.ApprovalPolicy, call ToolCall) error {
+	if policy == nil {
+		return nil
+	}
+	tool, err := registry.Get(call.Name)
+	if err != nil {
+		return nil
+	}
+	return policy.Authorize(ctx, tool, call.Args)
+}
+
+// approvedRunner wraps run so each call is approved by policy before it
+// runs. Denied calls get an error result the model can see.
+func approvedRunner(registry tools.ToolRegistry, policy *tools.ApprovalPolicy, calls []ToolCall, run toolRunner) toolRunner {
+	if policy == nil {
+		return run
+	}
+	return func(ctx context.Context, i int) tools.ToolResult {
+		if err := authorizeToolCall(ctx, registry, policy, calls[i]); err != nil {
+			return tools.NewErrorResult(err)
+		}
+		return run(ctx, i)
+	}
+}
+
+// availableRunner wraps run so calls to tools the request did not make
+// available to the model get an error result instead of running
+func availableRunner(names *toolNames, calls []ToolCall, run toolRunner) toolRunner {
+	return func(ctx context.Context, i int) tools.ToolResult {
+		if !names.available(calls[i].Name) {
+			return tools.NewErrorResult(fmt.Errorf("tool %q is not available to this request", calls[i].Name))
+		}
+		return run(ctx, i)
+	}
+}
+
+// callTool runs a tool call through the registry. When streaming, the parts
+// of tools that stream their results are emitted as progress events.
+func callTool(ctx context.Context, registry tools.ToolRegistry, call ToolCall, emit emitFunc) (tools.ToolResult, error) {
+	if emit == nil {
+		return registry.Call(ctx, call.Name, call.Args)
+	}
+
+	results, err := registry.Stream(ctx, call.Name, call.Args)
+	if err != nil {
+		return tools.ToolResult{}, err
+	}
+	for result := range results {
+		if !result.Partial {
+			return result, nil
+		}
+		emit.toolProgress(call, result)
+	}
+	if err := ctx.Err(); err != nil {
+		return tools.ToolResult{}, err
+	}
+	return tools.ToolResult{}, fmt.Errorf("tool %q returned no result", call.Name)
+}
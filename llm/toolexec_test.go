@@ -0,0 +1,200 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/tools"
+)
+
+// fanOutCalls returns n tool calls named tool_0 .. tool_n-1
+func fanOutCalls(n int) []ToolCall {
+	calls := make([]ToolCall, n)
+	for i := range calls {
+		calls[i] = ToolCall{ID: fmt.Sprintf("call_%d", i), Name: fmt.Sprintf("tool_%d", i)}
+	}
+	return calls
+}
+
+// enter records a tool starting to run, updating the peak number of tools
+// in flight, and returns a function recording that it finished
+func enter(inFlight, peak *int32) func() {
+	n := atomic.AddInt32(inFlight, 1)
+	for {
+		max := atomic.LoadInt32(peak)
+		if n <= max || atomic.CompareAndSwapInt32(peak, max, n) {
+			break
+		}
+	}
+	return func() { atomic.AddInt32(inFlight, -1) }
+}
+
+func TestExecuteToolCalls(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("parallel results keep request order", func(t *testing.T) {
+		calls := fanOutCalls(5)
+		var inFlight, maxInFlight int32
+
+		results := executeToolCalls(ctx, calls, true, 3, nil, func(ctx context.Context, i int) tools.ToolResult {
+			defer enter(&inFlight, &maxInFlight)()
+			// Later calls finish first
+			time.Sleep(time.Duration(5-i) * 5 * time.Millisecond)
+			return tools.NewToolResult(calls[i].Name)
+		})
+
+		require.Len(t, results, 5)
+		for i, result := range results {
+			assert.Equal(t, fmt.Sprintf("tool_%d", i), result.Content)
+			assert.Equal(t, result.Content, calls[i].Response)
+		}
+		assert.Greater(t, maxInFlight, int32(1))
+		assert.LessOrEqual(t, maxInFlight, int32(3))
+	})
+
+	t.Run("runs concurrently", func(t *testing.T) {
+		calls := fanOutCalls(4)
+		var started sync.WaitGroup
+		started.Add(len(calls))
+
+		// Each call waits for all the others to start, which only
+		// completes if they run at the same time
+		results := executeToolCalls(ctx, calls, true, 0, nil, func(ctx context.Context, i int) tools.ToolResult {
+			started.Done()
+			done := make(chan struct{})
+			go func() {
+				started.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return tools.NewToolResult("ok")
+			case <-time.After(5 * time.Second):
+				return tools.NewErrorResult(fmt.Errorf("tools did not run concurrently"))
+			}
+		})
+
+		for _, result := range results {
+			assert.False(t, result.IsError, result.Content)
+		}
+	})
+
+	t.Run("sequential when parallel is off", func(t *testing.T) {
+		calls := fanOutCalls(3)
+		var order []int
+		var events []StreamEventType
+		emit := emitFunc(func(event StreamEvent) { events = append(events, event.Type) })
+
+		executeToolCalls(ctx, calls, false, 0, emit, func(ctx context.Context, i int) tools.ToolResult {
+			order = append(order, i)
+			return tools.NewToolResult("ok")
+		})
+
+		assert.Equal(t, []int{0, 1, 2}, order)
+		assert.Equal(t, []StreamEventType{
+			StreamEventToolUse, StreamEventToolResult,
+			StreamEventToolUse, StreamEventToolResult,
+			StreamEventToolUse, StreamEventToolResult,
+		}, events)
+	})
+
+	t.Run("each call gets its own context", func(t *testing.T) {
+		calls := fanOutCalls(3)
+		contexts := make([]context.Context, len(calls))
+
+		executeToolCalls(ctx, calls, true, 0, nil, func(ctx context.Context, i int) tools.ToolResult {
+			contexts[i] = ctx
+			return tools.NewToolResult("ok")
+		})
+
+		for _, callCtx := range contexts {
+			assert.ErrorIs(t, callCtx.Err(), context.Canceled)
+		}
+		assert.NoError(t, ctx.Err())
+	})
+
+	t.Run("cancelled context skips pending calls", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		calls := fanOutCalls(3)
+		var ran int32
+
+		results := executeToolCalls(cctx, calls, true, 1, nil, func(ctx context.Context, i int) tools.ToolResult {
+			atomic.AddInt32(&ran, 1)
+			return tools.NewToolResult("ok")
+		})
+
+		assert.Zero(t, ran)
+		for i, result := range results {
+			assert.True(t, result.IsError)
+			assert.Contains(t, result.Content, "tool call cancelled")
+			assert.Equal(t, result.Content, calls[i].Response)
+		}
+	})
+}
+
+func TestOpenAILLM_ParallelTools(t *testing.T) {
+	ctx := context.Background()
+
+	requested := make([]openAIToolCall, 3)
+	for i := range requested {
+		requested[i] = openAIToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Type:     "function",
+			Function: openAIFunctionCall{Name: "fetch", Arguments: fmt.Sprintf(`{"input":"page%d"}`, i)},
+		}
+	}
+	fake := &fakeOpenAIServer{responses: []openAIMessage{
+		{Role: "assistant", ToolCalls: requested},
+		{Role: "assistant", Content: "fetched"},
+	}}
+	l := newTestOpenAILLM(t, fake)
+
+	var inFlight, maxInFlight int32
+	require.NoError(t, tools.RegisterFunctionTool(l.Tools(), "fetch", "Fetch a page", func(page string) string {
+		defer enter(&inFlight, &maxInFlight)()
+		time.Sleep(20 * time.Millisecond)
+		return "contents of " + page
+	}))
+
+	t.Run("parallel", func(t *testing.T) {
+		resp, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "fetch"}, &RequestParams{
+			Tools:         []string{"fetch"},
+			ParallelTools: Bool(true),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "fetched", resp.Content)
+		require.Len(t, resp.ToolCalls, 3)
+
+		followUp := fake.requests[1].Messages
+		require.Len(t, followUp, 5)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, fmt.Sprintf("call_%d", i), resp.ToolCalls[i].ID)
+			assert.Equal(t, fmt.Sprintf("call_%d", i), followUp[2+i].ToolCallID)
+			assert.Equal(t, fmt.Sprintf("contents of page%d", i), followUp[2+i].Content)
+		}
+		assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(1))
+	})
+
+	t.Run("sequential", func(t *testing.T) {
+		fake.responses = []openAIMessage{
+			{Role: "assistant", ToolCalls: requested},
+			{Role: "assistant", Content: "fetched"},
+		}
+		atomic.StoreInt32(&maxInFlight, 0)
+
+		_, err := l.Generate(ctx, Message{Type: MessageTypeUser, Content: "fetch"}, &RequestParams{
+			Tools:         []string{"fetch"},
+			ParallelTools: Bool(false),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&maxInFlight))
+	})
+}
@@ -1,5 +1,14 @@
 package llm
 
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adimarco/hive/logging"
+	"github.com/adimarco/hive/tools"
+)
+
 // ToolCall represents a request to call a tool.
 // The design supports both synchronous and asynchronous tool execution,
 // with the Response field allowing for result storage.
@@ -12,4 +21,115 @@ type ToolCall struct {
 	Args map[string]any `json:"args"`
 	// Response stores the result of the tool call
 	Response string `json:"response,omitempty"`
+	// IsError reports whether Response is an error result
+	IsError bool `json:"is_error,omitempty"`
+}
+
+// toolUseTurn returns the history messages for one round of tool use: an
+// assistant message with the text and tool calls the model produced,
+// followed by a tool message carrying the results of those calls
+func toolUseTurn(name, content string, calls []ToolCall) []Message {
+	requests := make([]ToolCall, len(calls))
+	results := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		requests[i] = ToolCall{ID: call.ID, Name: call.Name, Args: call.Args}
+		results[i] = ToolCall{ID: call.ID, Name: call.Name, Response: call.Response, IsError: call.IsError}
+	}
+	return []Message{
+		{Type: MessageTypeAssistant, Content: content, Name: name, ToolCalls: requests},
+		{Type: MessageTypeTool, ToolCalls: results},
+	}
+}
+
+// maxToolNameLength is the longest tool name the provider APIs accept
+const maxToolNameLength = 64
+
+// selectTools returns the registry tools a request makes available to the
+// model, logging requested tools that are not registered
+func selectTools(ctx context.Context, logger logging.Logger, registry tools.ToolRegistry, params *RequestParams) []tools.Tool {
+	selected, missing := tools.SelectTools(registry, params.Tools, params.ExcludeTools)
+	for _, name := range missing {
+		logger.Error(ctx, "Tool not found", logging.WithData(map[string]interface{}{
+			"tool": name,
+		}))
+	}
+	return selected
+}
+
+// toolNames maps registry tool names, such as "github.create_issue", to the
+// names sent to the provider APIs, which allow only letters, digits,
+// underscores and hyphens, and maps the names the model calls back
+type toolNames struct {
+	api  map[string]string // Registry name to API name
+	tool map[string]string // API name to registry name
+}
+
+// newToolNames assigns API names to the selected tools, adding a suffix
+// where two names would otherwise become the same
+func newToolNames(selected []tools.Tool) *toolNames {
+	names := &toolNames{
+		api:  make(map[string]string, len(selected)),
+		tool: make(map[string]string, len(selected)),
+	}
+	for _, tool := range selected {
+		base := sanitizeToolName(tool.Name)
+		apiName := base
+		for i := 2; names.tool[apiName] != ""; i++ {
+			suffix := fmt.Sprintf("_%d", i)
+			apiName = base[:min(len(base), maxToolNameLength-len(suffix))] + suffix
+		}
+		names.api[tool.Name] = apiName
+		names.tool[apiName] = tool.Name
+	}
+	return names
+}
+
+// apiName returns the name a tool is sent to the API as. Tools not in the
+// request, such as those in earlier history, are sanitized.
+func (n *toolNames) apiName(name string) string {
+	if n != nil {
+		if apiName, ok := n.api[name]; ok {
+			return apiName
+		}
+	}
+	return sanitizeToolName(name)
+}
+
+// toolName returns the registry name of a tool the model called
+func (n *toolNames) toolName(apiName string) string {
+	if n != nil {
+		if name, ok := n.tool[apiName]; ok {
+			return name
+		}
+	}
+	return apiName
+}
+
+// available reports whether a tool was made available to the request
+func (n *toolNames) available(name string) bool {
+	_, ok := n.api[name]
+	return ok
+}
+
+// sanitizeToolName makes a tool name acceptable to the provider APIs: the
+// namespace separator becomes "__", other unsupported characters become
+// "_", and long names are truncated
+func sanitizeToolName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ReplaceAll(name, tools.NamespaceSeparator, "__") {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	sanitized := b.String()
+	if len(sanitized) > maxToolNameLength {
+		sanitized = sanitized[:maxToolNameLength]
+	}
+	if sanitized == "" {
+		return "_"
+	}
+	return sanitized
 }
@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adimarco/hive/tools"
+)
+
+func TestSanitizeToolName(t *testing.T) {
+	assert.Equal(t, "calculator", sanitizeToolName("calculator"))
+	assert.Equal(t, "github__create_issue", sanitizeToolName("github.create_issue"))
+	assert.Equal(t, "my_server__read_file", sanitizeToolName("my server.read/file"))
+	assert.Len(t, sanitizeToolName(strings.Repeat("a", 100)), maxToolNameLength)
+}
+
+func TestToolNames(t *testing.T) {
+	names := newToolNames([]tools.Tool{
+		{Name: "docs.search"},
+		{Name: "docs__search"},
+		{Name: "calculator"},
+	})
+
+	assert.Equal(t, "docs__search", names.apiName("docs.search"))
+	assert.Equal(t, "docs__search_2", names.apiName("docs__search"))
+	assert.Equal(t, "calculator", names.apiName("calculator"))
+
+	assert.Equal(t, "docs.search", names.toolName("docs__search"))
+	assert.Equal(t, "docs__search", names.toolName("docs__search_2"))
+
+	// Names outside the request are sanitized, and not available
+	assert.Equal(t, "web__fetch", names.apiName("web.fetch"))
+	assert.Equal(t, "web__fetch", names.toolName("web__fetch"))
+	assert.True(t, names.available("docs.search"))
+	assert.False(t, names.available("web.fetch"))
+}
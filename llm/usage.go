@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adimarco/hive/logging"
+)
+
+// Usage reports the tokens and cost consumed by LLM requests
+type Usage struct {
+	// Model is the model that served the requests, when known
+	Model string `json:"model,omitempty"`
+	// Requests is the number of API calls made
+	Requests int `json:"requests"`
+	// InputTokens is the number of uncached prompt tokens processed
+	InputTokens int `json:"input_tokens"`
+	// OutputTokens is the number of tokens generated
+	OutputTokens int `json:"output_tokens"`
+	// CacheCreationInputTokens is the number of prompt tokens written to the cache
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	// CacheReadInputTokens is the number of prompt tokens read from the cache
+	CacheReadInputTokens int `json:"cache_read_input_tokens,omitempty"`
+	// Cost is the token cost in USD, computed from the model price table
+	Cost float64 `json:"cost"`
+	// ToolCalls is the number of tools executed
+	ToolCalls int `json:"tool_calls,omitempty"`
+	// ToolCost is the sum of the credits reported by tool results
+	ToolCost uint64 `json:"tool_cost,omitempty"`
+}
+
+// Add accumulates the counts and costs of other into u
+func (u *Usage) Add(other Usage) {
+	if u.Model == "" {
+		u.Model = other.Model
+	}
+	u.Requests += other.Requests
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationInputTokens += other.CacheCreationInputTokens
+	u.CacheReadInputTokens += other.CacheReadInputTokens
+	u.Cost += other.Cost
+	u.ToolCalls += other.ToolCalls
+	u.ToolCost += other.ToolCost
+}
+
+// TotalTokens returns the number of input, cache and output tokens
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens + u.OutputTokens
+}
+
+// addTool records the execution of a tool and the credits it reported
+func (u *Usage) addTool(cost uint64) {
+	u.ToolCalls++
+	u.ToolCost += cost
+}
+
+// UsageTracker keeps running usage totals for an agent, team or app.
+// Trackers can be chained so that usage recorded for an agent also counts
+// towards the app that owns it.
+type UsageTracker struct {
+	name    string
+	logger  logging.Logger
+	mu      sync.RWMutex
+	total   Usage
+	byModel map[string]Usage
+	parent  *UsageTracker
+}
+
+// NewUsageTracker creates a UsageTracker identified by name in usage events
+func NewUsageTracker(name string) *UsageTracker {
+	return &UsageTracker{
+		name:    name,
+		logger:  logging.GetLogger("usage"),
+		byModel: make(map[string]Usage),
+	}
+}
+
+// WithParent makes usage recorded on t also count towards parent
+func (t *UsageTracker) WithParent(parent *UsageTracker) *UsageTracker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.parent = parent
+	return t
+}
+
+// Name returns the name of the tracker
+func (t *UsageTracker) Name() string {
+	return t.name
+}
+
+// Record adds usage to the tracker and its parents, and emits a "usage"
+// logging event with the request usage and the new running total
+func (t *UsageTracker) Record(ctx context.Context, usage Usage) {
+	if usage == (Usage{}) {
+		return
+	}
+
+	t.mu.Lock()
+	t.total.Add(usage)
+	model := t.byModel[usage.Model]
+	model.Add(usage)
+	t.byModel[usage.Model] = model
+	total := t.total
+	parent := t.parent
+	t.mu.Unlock()
+
+	t.logger.Info(ctx, "Recorded usage", logging.WithName("usage"), logging.WithData(map[string]interface{}{
+		"scope":         t.name,
+		"model":         usage.Model,
+		"input_tokens":  usage.InputTokens,
+		"output_tokens": usage.OutputTokens,
+		"cost":          usage.Cost,
+		"tool_calls":    usage.ToolCalls,
+		"tool_cost":     usage.ToolCost,
+		"total_tokens":  total.TotalTokens(),
+		"total_cost":    total.Cost,
+	}))
+
+	if parent != nil {
+		parent.Record(ctx, usage)
+	}
+}
+
+// Total returns the usage recorded so far
+func (t *UsageTracker) Total() Usage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	total := t.total
+	total.Model = ""
+	return total
+}
+
+// ByModel returns the usage recorded so far broken down by model
+func (t *UsageTracker) ByModel() map[string]Usage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make(map[string]Usage, len(t.byModel))
+	for model, usage := range t.byModel {
+		result[model] = usage
+	}
+	return result
+}
+
+// Reset clears the recorded usage
+func (t *UsageTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total = Usage{}
+	t.byModel = make(map[string]Usage)
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/tools"
+)
+
+func TestLookupModelPrice(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		want  ModelPrice
+		found bool
+	}{
+		{
+			name:  "dated model",
+			model: "claude-3-haiku-20240307",
+			want:  ModelPrice{Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03},
+			found: true,
+		},
+		{
+			name:  "longest prefix wins",
+			model: "gpt-4o-mini-2024-07-18",
+			want:  ModelPrice{Input: 0.15, Output: 0.60, CacheRead: 0.075},
+			found: true,
+		},
+		{
+			name:  "unknown model",
+			model: "llama-3.1-8b-instruct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, found := LookupModelPrice(tt.model)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, price)
+		})
+	}
+
+	t.Run("registered price", func(t *testing.T) {
+		RegisterModelPrice("local-priced-model", ModelPrice{Input: 1, Output: 2})
+		price, found := LookupModelPrice("local-priced-model")
+		require.True(t, found)
+		assert.InDelta(t, 0.000005, price.Cost(Usage{InputTokens: 1, OutputTokens: 2}), 1e-12)
+	})
+}
+
+func TestUsageTracker(t *testing.T) {
+	ctx := context.Background()
+
+	app := NewUsageTracker("app")
+	agent := NewUsageTracker("agent").WithParent(app)
+
+	agent.Record(ctx, Usage{Model: "sonnet", Requests: 1, InputTokens: 10, OutputTokens: 5, Cost: 0.5})
+	agent.Record(ctx, Usage{Model: "haiku", Requests: 2, InputTokens: 20, OutputTokens: 10, Cost: 0.25, ToolCalls: 1, ToolCost: 3})
+	app.Record(ctx, Usage{Model: "haiku", Requests: 1, InputTokens: 1, OutputTokens: 1, Cost: 0.25})
+
+	assert.Equal(t, Usage{Requests: 3, InputTokens: 30, OutputTokens: 15, Cost: 0.75, ToolCalls: 1, ToolCost: 3}, agent.Total())
+	assert.Equal(t, 4, app.Total().Requests)
+	assert.Equal(t, 1.0, app.Total().Cost)
+	assert.Equal(t, 47, app.Total().TotalTokens())
+
+	byModel := app.ByModel()
+	require.Len(t, byModel, 2)
+	assert.Equal(t, 3, byModel["haiku"].Requests)
+	assert.Equal(t, 0.5, byModel["haiku"].Cost)
+
+	agent.Reset()
+	assert.Equal(t, Usage{}, agent.Total())
+	assert.Equal(t, 4, app.Total().Requests)
+}
+
+func TestOpenAILLM_Usage(t *testing.T) {
+	fake := &fakeOpenAIServer{responses: []openAIMessage{
+		{
+			Role: "assistant",
+			ToolCalls: []openAIToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: openAIFunctionCall{Name: "paid", Arguments: `{"input":"x"}`},
+			}},
+		},
+		{Role: "assistant", Content: "done"},
+	}}
+	l := newTestOpenAILLM(t, fake)
+	require.NoError(t, l.Tools().Register(tools.Tool{
+		Name:   "paid",
+		Schema: []byte(`{"type":"object","properties":{"input":{"type":"string"}}}`),
+		Cost:   7,
+		Handler: func(ctx context.Context, args map[string]any) (tools.ToolResult, error) {
+			return tools.NewToolResult("ok"), nil
+		},
+	}))
+
+	resp, err := l.Generate(context.Background(), Message{Type: MessageTypeUser, Content: "go"}, &RequestParams{
+		Model: "gpt-4o-mini",
+		Tools: []string{"paid"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Usage)
+
+	// The fake server reports 100 prompt tokens (40 cached) and 20 completion tokens per call
+	assert.Equal(t, "gpt-4o-mini", resp.Usage.Model)
+	assert.Equal(t, 2, resp.Usage.Requests)
+	assert.Equal(t, 120, resp.Usage.InputTokens)
+	assert.Equal(t, 80, resp.Usage.CacheReadInputTokens)
+	assert.Equal(t, 40, resp.Usage.OutputTokens)
+	assert.InDelta(t, (120*0.15+80*0.075+40*0.60)/1_000_000, resp.Usage.Cost, 1e-12)
+	assert.Equal(t, 1, resp.Usage.ToolCalls)
+	assert.Equal(t, uint64(7), resp.Usage.ToolCost)
+}
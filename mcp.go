@@ -0,0 +1,65 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/adimarco/hive/mcp"
+	"github.com/adimarco/hive/tools"
+)
+
+// agentToolSchema is the input schema of agents exposed as tools
+var agentToolSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"message": {"type": "string", "description": "Message to send to the agent"},
+		"session": {"type": "string", "description": "Optional session ID; calls with the same session share a conversation"}
+	},
+	"required": ["message"]
+}`)
+
+// AsTool exposes the agent as a tool that takes a message and returns the
+// agent's reply, so other agents and MCP hosts can call it
+func (a *Agent) AsTool() tools.Tool {
+	return tools.Tool{
+		Name:        a.name,
+		Description: fmt.Sprintf("Send a message to the %s agent. Instructions: %s", a.name, a.instruction),
+		Category:    "agent",
+		Schema:      agentToolSchema,
+		Handler: func(ctx context.Context, args map[string]any) (tools.ToolResult, error) {
+			message, _ := args["message"].(string)
+			session, _ := args["session"].(string)
+
+			var ra *RunningAgent
+			var err error
+			if session != "" {
+				ra, err = a.RunSession(ctx, session)
+			} else {
+				ra, err = a.Run(ctx)
+			}
+			if err != nil {
+				return tools.ToolResult{}, err
+			}
+
+			reply, err := ra.Send(message)
+			if err != nil {
+				return tools.ToolResult{}, err
+			}
+			return tools.NewToolResult(reply), nil
+		},
+	}
+}
+
+// NewMCPServer creates an MCP server publishing the tools in registry and
+// the given agents as tools. Registry may be nil to publish only agents.
+// Serve it with ServeStdio or as an http.Handler.
+func NewMCPServer(name string, registry tools.ToolRegistry, agents ...*Agent) (*mcp.Server, error) {
+	server := mcp.NewServer(name, "0.1.0", registry)
+	for _, agent := range agents {
+		if err := server.AddTool(agent.AsTool()); err != nil {
+			return nil, fmt.Errorf("failed to publish agent %q: %w", agent.name, err)
+		}
+	}
+	return server, nil
+}
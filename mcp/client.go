@@ -0,0 +1,324 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adimarco/hive/logging"
+)
+
+// clientInfo identifies hive to MCP servers
+var clientInfo = Implementation{Name: "hive", Version: "0.1.0"}
+
+// reinitializeTimeout bounds the handshake repeated after a reconnect
+const reinitializeTimeout = 30 * time.Second
+
+// Client is a connection to a single MCP server
+type Client struct {
+	name      string
+	transport Transport
+	timeout   time.Duration
+	logger    logging.Logger
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *message
+	started bool
+	closed  bool // Close was called
+	ended   bool // the connection is gone
+	done    chan struct{}
+
+	server InitializeResult
+}
+
+// NewClient creates a client for the named server using transport. Call
+// Connect before using it.
+func NewClient(name string, transport Transport) *Client {
+	return &Client{
+		name:      name,
+		transport: transport,
+		logger:    logging.GetLogger("mcp.client"),
+		pending:   make(map[int64]chan *message),
+		done:      make(chan struct{}),
+	}
+}
+
+// WithRequestTimeout limits how long each request waits for its response
+func (c *Client) WithRequestTimeout(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
+}
+
+// Name returns the server name the client was created with
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Server returns what the server reported about itself during Connect
+func (c *Client) Server() InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.server
+}
+
+// Connect starts the transport and performs the initialize handshake
+func (c *Client) Connect(ctx context.Context) error {
+	if err := c.transport.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start transport: %w", err)
+	}
+	c.mu.Lock()
+	c.started = true
+	c.mu.Unlock()
+	go c.readLoop()
+
+	if err := c.initialize(ctx); err != nil {
+		_ = c.Close()
+		return err
+	}
+
+	if t, ok := c.transport.(reconnectingTransport); ok {
+		t.OnReconnect(c.reinitialize)
+	}
+	return nil
+}
+
+// initialize performs the initialize handshake
+func (c *Client) initialize(ctx context.Context) error {
+	var result InitializeResult
+	err := c.call(ctx, "initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      clientInfo,
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+
+	if err := c.notify(ctx, "notifications/initialized", nil); err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+
+	c.mu.Lock()
+	c.server = result
+	c.mu.Unlock()
+
+	c.logger.Debug(ctx, "Connected to MCP server", logging.WithData(map[string]interface{}{
+		"server":           c.name,
+		"server_name":      result.ServerInfo.Name,
+		"protocol_version": result.ProtocolVersion,
+	}))
+	return nil
+}
+
+// reinitialize repeats the handshake after the transport reconnected
+func (c *Client) reinitialize() {
+	ctx, cancel := context.WithTimeout(context.Background(), reinitializeTimeout)
+	defer cancel()
+	if err := c.initialize(ctx); err != nil {
+		c.logger.Warning(ctx, "Failed to reinitialize MCP session", logging.WithData(map[string]interface{}{
+			"server": c.name,
+			"error":  err.Error(),
+		}))
+	}
+}
+
+// ListTools returns all tools offered by the server
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var all []Tool
+	cursor := ""
+	for {
+		var page ListToolsResult
+		if err := c.call(ctx, "tools/list", listToolsParams{Cursor: cursor}, &page); err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		all = append(all, page.Tools...)
+		if page.NextCursor == "" {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool invokes a tool on the server. A tool that fails reports it
+// through CallToolResult.IsError; the returned error is for protocol and
+// connection failures.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (*CallToolResult, error) {
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close closes the connection. Pending calls fail.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	started := c.started
+	c.mu.Unlock()
+
+	err := c.transport.Close()
+	if started {
+		<-c.done
+	}
+	return err
+}
+
+// call sends a request and decodes its result into result
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	c.mu.Lock()
+	if c.closed || c.ended {
+		c.mu.Unlock()
+		return fmt.Errorf("connection to %q closed", c.name)
+	}
+	c.nextID++
+	id := c.nextID
+	replies := make(chan *message, 1)
+	c.pending[id] = replies
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	msg := message{JSONRPC: "2.0", ID: json.RawMessage(strconv.FormatInt(id, 10)), Method: method}
+	if err := c.send(ctx, &msg, params); err != nil {
+		return err
+	}
+
+	select {
+	case reply, ok := <-replies:
+		if !ok {
+			return fmt.Errorf("connection to %q closed", c.name)
+		}
+		if reply.Error != nil {
+			return reply.Error
+		}
+		if result != nil && len(reply.Result) > 0 {
+			if err := json.Unmarshal(reply.Result, result); err != nil {
+				return fmt.Errorf("invalid %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		c.cancelRequest(id, ctx.Err())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s request to %q timed out: %w", method, c.name, ctx.Err())
+		}
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification, which has no reply
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	return c.send(ctx, &message{JSONRPC: "2.0", Method: method}, params)
+}
+
+// cancelRequest tells the server a request is no longer wanted
+func (c *Client) cancelRequest(id int64, reason error) {
+	_ = c.notify(context.Background(), "notifications/cancelled", map[string]any{
+		"requestId": id,
+		"reason":    reason.Error(),
+	})
+}
+
+// send marshals params into msg and writes it to the transport
+func (c *Client) send(ctx context.Context, msg *message, params any) error {
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s params: %w", msg.Method, err)
+		}
+		msg.Params = data
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", msg.Method, err)
+	}
+	if err := c.transport.Send(ctx, data); err != nil {
+		return fmt.Errorf("failed to send %s: %w", msg.Method, err)
+	}
+	return nil
+}
+
+// readLoop dispatches incoming messages until the transport closes, then
+// fails any calls still waiting for a reply
+func (c *Client) readLoop() {
+	defer close(c.done)
+	defer func() {
+		c.mu.Lock()
+		c.ended = true
+		for id, replies := range c.pending {
+			close(replies)
+			delete(c.pending, id)
+		}
+		c.mu.Unlock()
+	}()
+
+	for data := range c.transport.Messages() {
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.logger.Warning(context.Background(), "Ignoring invalid message from MCP server", logging.WithData(map[string]interface{}{
+				"server": c.name,
+				"error":  err.Error(),
+			}))
+			continue
+		}
+
+		switch {
+		case msg.isResponse():
+			c.deliver(&msg)
+		case len(msg.ID) > 0:
+			c.handleRequest(&msg)
+		default:
+			c.logger.Debug(context.Background(), "MCP notification", logging.WithData(map[string]interface{}{
+				"server": c.name,
+				"method": msg.Method,
+			}))
+		}
+	}
+}
+
+// deliver hands a response to the call waiting for it
+func (c *Client) deliver(msg *message) {
+	id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	replies, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ok {
+		replies <- msg
+	}
+}
+
+// handleRequest answers requests sent by the server. Only ping is
+// supported; other methods are rejected.
+func (c *Client) handleRequest(msg *message) {
+	reply := message{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == "ping" {
+		reply.Result = json.RawMessage(`{}`)
+	} else {
+		reply.Error = &RPCError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not supported", msg.Method)}
+	}
+	_ = c.send(context.Background(), &reply, nil)
+}
@@ -0,0 +1,151 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer is the path of the fake MCP server binary built by TestMain
+var fakeServer string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "hive-mcp")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fakeServer = filepath.Join(dir, "fakeserver")
+	build := exec.Command("go", "build", "-o", fakeServer, "./internal/fakeserver")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to build fake MCP server:", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// connectFake starts the fake server and connects a client to it
+func connectFake(t *testing.T) (*Client, *StdioTransport) {
+	t.Helper()
+	transport := NewStdioTransport(fakeServer, nil, nil)
+	client := NewClient("fake", transport)
+	require.NoError(t, client.Connect(context.Background()))
+	t.Cleanup(func() { _ = client.Close() })
+	return client, transport
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	client, _ := connectFake(t)
+
+	t.Run("handshake", func(t *testing.T) {
+		assert.Equal(t, "fake", client.Server().ServerInfo.Name)
+		assert.Equal(t, ProtocolVersion, client.Server().ProtocolVersion)
+		assert.NotNil(t, client.Server().Capabilities.Tools)
+	})
+
+	t.Run("list tools", func(t *testing.T) {
+		listed, err := client.ListTools(ctx)
+		require.NoError(t, err)
+		require.Len(t, listed, 3)
+		assert.Equal(t, "echo", listed[0].Name)
+		assert.JSONEq(t, `{
+			"type": "object",
+			"properties": {"text": {"type": "string"}},
+			"required": ["text"]
+		}`, string(listed[0].InputSchema))
+	})
+
+	t.Run("call tool", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "echo", map[string]any{"text": "hello"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Equal(t, "hello", ContentText(result.Content))
+	})
+
+	t.Run("tool errors are results", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "fail", nil)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("protocol errors", func(t *testing.T) {
+		err := client.call(ctx, "prompts/list", nil, nil)
+		var rpcErr *RPCError
+		require.True(t, errors.As(err, &rpcErr))
+		assert.Equal(t, CodeMethodNotFound, rpcErr.Code)
+	})
+}
+
+func TestClient_Close(t *testing.T) {
+	client, transport := connectFake(t)
+	require.NoError(t, client.Close())
+
+	exited := make(chan struct{})
+	go func() {
+		_ = transport.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server process did not exit")
+	}
+
+	_, err := client.ListTools(context.Background())
+	assert.Error(t, err)
+	assert.NoError(t, client.Close())
+}
+
+func TestStdioTransport_CloseWithChildren(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	// The server ignores stdin and leaves a child holding its output open
+	transport := NewStdioTransport(sh, []string{"-c", "sleep 30 & sleep 30"}, nil)
+	transport.stopTimeout = 100 * time.Millisecond
+	require.NoError(t, transport.Start(context.Background()))
+
+	closed := make(chan struct{})
+	go func() {
+		_ = transport.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return")
+	}
+}
+
+func TestClient_ConnectFailure(t *testing.T) {
+	client := NewClient("missing", NewStdioTransport(filepath.Join(t.TempDir(), "missing"), nil, nil))
+	err := client.Connect(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to start")
+	assert.NoError(t, client.Close())
+}
+
+func TestContentText(t *testing.T) {
+	content := []Content{
+		{Type: "text", Text: "first"},
+		{Type: "image", MimeType: "image/png", Data: "aGVsbG8="},
+		{Type: "resource", Resource: &ResourceContents{URI: "file:///a.txt", Text: "contents"}},
+		{Type: "resource", Resource: &ResourceContents{URI: "file:///b.bin", Blob: "AAAA"}},
+	}
+	assert.Equal(t, "first\n[image: image/png, 6 bytes]\ncontents\n[resource: file:///b.bin]", ContentText(content))
+}
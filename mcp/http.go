@@ -0,0 +1,372 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/adimarco/hive/logging"
+)
+
+// sessionHeader carries the session ID of the streamable HTTP transport
+const sessionHeader = "Mcp-Session-Id"
+
+// HTTPTransport connects to an MCP server using the streamable HTTP
+// transport: every client message is POSTed to a single URL, and the server
+// answers with either a JSON body or an event stream of messages. Failed
+// requests are retried with backoff when sending them again is safe, and a
+// session the server has expired is replaced by a new one.
+type HTTPTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	logger  logging.Logger
+
+	maxRetries int
+	retryDelay time.Duration
+
+	mu          sync.Mutex
+	sessionID   string
+	closed      bool
+	onReconnect func()        // repeats the initialize handshake
+	renewing    chan struct{} // closed when the session being renewed is replaced
+	expired     string        // session being renewed
+
+	messages chan json.RawMessage
+	ctx      context.Context // cancelled by Close to abort requests
+	cancel   context.CancelFunc
+	inFlight sync.WaitGroup // sends and streamed replies still delivering
+}
+
+// NewHTTPTransport creates a transport for the MCP endpoint at url, sending
+// headers with every request
+func NewHTTPTransport(url string, headers map[string]string) *HTTPTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HTTPTransport{
+		url:        url,
+		headers:    headers,
+		client:     &http.Client{},
+		logger:     logging.GetLogger("mcp.http"),
+		maxRetries: defaultMaxReconnects,
+		retryDelay: defaultReconnectDelay,
+		messages:   make(chan json.RawMessage, 16),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start implements Transport. The connection is made by the first Send.
+func (t *HTTPTransport) Start(ctx context.Context) error {
+	return nil
+}
+
+// Send POSTs a message and delivers the server's reply, if any, to
+// Messages. Replies streamed as events are read in the background.
+func (t *HTTPTransport) Send(ctx context.Context, msg json.RawMessage) error {
+	return t.send(ctx, msg, true)
+}
+
+// send POSTs a message. If the server has expired the session and renew is
+// set, a new session is started and the message sent again.
+func (t *HTTPTransport) send(ctx context.Context, msg json.RawMessage, renew bool) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return fmt.Errorf("transport closed")
+	}
+	t.inFlight.Add(1)
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	defer t.inFlight.Done()
+
+	// Requests end with the caller's context or when the transport closes
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(t.ctx, cancel)
+	streaming := false
+	defer func() {
+		if !streaming {
+			stop()
+			cancel()
+		}
+	}()
+
+	resp, err := t.post(ctx, msg, sessionID)
+	if err != nil {
+		return err
+	}
+
+	// The server assigns a session in its reply to initialize
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	if resp.StatusCode == http.StatusNotFound && sessionID != "" {
+		resp.Body.Close()
+		// The server never handled the message, so it is safe to resend
+		if !renew {
+			return fmt.Errorf("session %s expired", sessionID)
+		}
+		if err := t.renewSession(ctx, sessionID); err != nil {
+			return err
+		}
+		return t.send(ctx, msg, false)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return fmt.Errorf("failed to post message: %s", resp.Status)
+	}
+	if resp.StatusCode == http.StatusAccepted {
+		resp.Body.Close()
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/event-stream":
+		streaming = true
+		t.inFlight.Add(1)
+		go func() {
+			defer t.inFlight.Done()
+			defer cancel()
+			defer stop()
+			defer resp.Body.Close()
+			_ = readEvents(resp.Body, func(event sseEvent) bool {
+				return event.Event != "message" || t.deliver(json.RawMessage(event.Data))
+			})
+		}()
+		return nil
+	default:
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		return t.deliverBody(body)
+	}
+}
+
+// renewSession replaces an expired session by clearing it and having the
+// client repeat the initialize handshake, which starts a new one. Requests
+// that find the same session expired meanwhile wait for that renewal.
+func (t *HTTPTransport) renewSession(ctx context.Context, expired string) error {
+	t.mu.Lock()
+	if renewing := t.renewing; renewing != nil {
+		inProgress := t.expired == expired
+		t.mu.Unlock()
+		if !inProgress {
+			// Sent by the handshake itself, with the new session
+			return fmt.Errorf("session %s expired", expired)
+		}
+		select {
+		case <-renewing:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return t.checkRenewed(expired)
+	}
+	if t.sessionID != expired {
+		// Another request already renewed it
+		t.mu.Unlock()
+		return t.checkRenewed(expired)
+	}
+
+	t.sessionID = ""
+	reinitialize := t.onReconnect
+	if reinitialize == nil {
+		t.mu.Unlock()
+		return fmt.Errorf("session %s expired", expired)
+	}
+	renewing := make(chan struct{})
+	t.renewing, t.expired = renewing, expired
+	t.mu.Unlock()
+
+	t.logger.Warning(ctx, "MCP session expired, starting a new one", logging.WithData(map[string]interface{}{
+		"url":     t.url,
+		"session": expired,
+	}))
+	reinitialize()
+
+	t.mu.Lock()
+	t.renewing, t.expired = nil, ""
+	t.mu.Unlock()
+	close(renewing)
+	return t.checkRenewed(expired)
+}
+
+// checkRenewed returns an error unless a new session replaced expired
+func (t *HTTPTransport) checkRenewed(expired string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID == "" || t.sessionID == expired {
+		return fmt.Errorf("session %s expired and could not be renewed", expired)
+	}
+	return nil
+}
+
+// OnReconnect registers the function repeating the initialize handshake,
+// called when the server has expired the session
+func (t *HTTPTransport) OnReconnect(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onReconnect = fn
+}
+
+// idempotentMethods are requests that may be sent again when it is unknown
+// whether the server received them
+var idempotentMethods = map[string]bool{
+	"initialize":     true,
+	"ping":           true,
+	"tools/list":     true,
+	"prompts/list":   true,
+	"resources/list": true,
+}
+
+// post sends a message, retrying requests that fail before a response if
+// they are idempotent or never reached the server, so a tool call is not
+// run twice when only the connection dropped
+func (t *HTTPTransport) post(ctx context.Context, msg json.RawMessage, sessionID string) (*http.Response, error) {
+	delay := t.retryDelay
+	idempotent := isIdempotent(msg)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(msg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set(sessionHeader, sessionID)
+		}
+		setHeaders(req, t.headers)
+
+		resp, err := t.client.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || attempt >= t.maxRetries || !(idempotent || notSent(err)) {
+			return nil, fmt.Errorf("failed to post message: %w", err)
+		}
+
+		t.logger.Warning(ctx, "MCP request failed, retrying", logging.WithData(map[string]interface{}{
+			"url":     t.url,
+			"attempt": attempt + 1,
+			"error":   err.Error(),
+		}))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to post message: %w", err)
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// isIdempotent reports whether msg is a request that is safe to repeat
+func isIdempotent(msg json.RawMessage) bool {
+	var m message
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return false
+	}
+	return idempotentMethods[m.Method]
+}
+
+// notSent reports whether a request failed before the server could have
+// received it, such as when the connection was refused
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// deliverBody delivers a JSON reply, which may be a single message or a
+// batch
+func (t *HTTPTransport) deliverBody(body []byte) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if body[0] != '[' {
+		t.deliver(json.RawMessage(body))
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	for _, msg := range batch {
+		if !t.deliver(msg) {
+			break
+		}
+	}
+	return nil
+}
+
+// deliver hands a message to the client, returning false once the
+// transport is closing
+func (t *HTTPTransport) deliver(msg json.RawMessage) bool {
+	select {
+	case t.messages <- msg:
+		return true
+	case <-t.ctx.Done():
+		return false
+	}
+}
+
+// Messages implements Transport
+func (t *HTTPTransport) Messages() <-chan json.RawMessage {
+	return t.messages
+}
+
+// Close ends the session and stops delivering messages
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.cancel()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+
+	t.inFlight.Wait()
+	close(t.messages)
+
+	if sessionID == "" {
+		return nil
+	}
+
+	// Tell the server the session is over; servers that do not support
+	// this answer 405, which is fine
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(sessionHeader, sessionID)
+	setHeaders(req, t.headers)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil
+		}
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
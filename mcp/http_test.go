@@ -0,0 +1,257 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/tools"
+)
+
+// fakeHTTPServer is an MCP server using the streamable HTTP transport. It
+// answers tool calls with an event stream and everything else with JSON.
+type fakeHTTPServer struct {
+	mu       sync.Mutex
+	started  int      // sessions started
+	current  string   // the live session
+	sessions []string // session header of each POST
+	deleted  string
+	headers  []string // X-Team header of each request
+}
+
+// expire forgets the live session, as a restarted server would
+func (f *fakeHTTPServer) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current = ""
+}
+
+func (f *fakeHTTPServer) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.headers = append(f.headers, r.Header.Get("X-Team"))
+		f.mu.Unlock()
+
+		if r.Method == http.MethodDelete {
+			f.mu.Lock()
+			f.deleted = r.Header.Get(sessionHeader)
+			f.mu.Unlock()
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.sessions = append(f.sessions, r.Header.Get(sessionHeader))
+		f.mu.Unlock()
+
+		f.mu.Lock()
+		if strings.Contains(string(body), `"initialize"`) {
+			f.started++
+			f.current = fmt.Sprintf("session-%d", f.started)
+			w.Header().Set(sessionHeader, f.current)
+		} else if r.Header.Get(sessionHeader) != f.current {
+			f.mu.Unlock()
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		f.mu.Unlock()
+
+		reply := fakeRPC(body)
+		switch {
+		case reply == nil:
+			w.WriteHeader(http.StatusAccepted)
+		case strings.Contains(string(body), `"tools/call"`):
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", reply)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(reply)
+		}
+	})
+}
+
+// failingRoundTripper fails the first failures requests without sending them
+type failingRoundTripper struct {
+	mu       sync.Mutex
+	failures int
+}
+
+func (f *failingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	fail := f.failures > 0
+	f.failures--
+	f.mu.Unlock()
+	if fail {
+		return nil, errors.New("connection refused")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// droppingRoundTripper delivers requests to the server but fails them as if
+// the connection dropped before the response arrived
+type droppingRoundTripper struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (d *droppingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	d.mu.Lock()
+	d.calls++
+	d.mu.Unlock()
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return nil, errors.New("connection reset by peer")
+}
+
+func TestHTTPTransport(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeHTTPServer{}
+	server := httptest.NewServer(fake.handler())
+	t.Cleanup(server.Close)
+
+	transport := NewHTTPTransport(server.URL, map[string]string{"X-Team": "hive"})
+	transport.retryDelay = time.Millisecond
+	transport.client = &http.Client{Transport: &failingRoundTripper{failures: 2}}
+	client := NewClient("remote", transport).WithRequestTimeout(time.Second)
+
+	// The first request only succeeds after two retries
+	require.NoError(t, client.Connect(ctx))
+	assert.Equal(t, "remote", client.Server().ServerInfo.Name)
+
+	t.Run("json replies", func(t *testing.T) {
+		listed, err := client.ListTools(ctx)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, "echo", listed[0].Name)
+	})
+
+	t.Run("streamed replies", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "echo", map[string]any{"text": "streamed"})
+		require.NoError(t, err)
+		assert.Equal(t, "streamed", ContentText(result.Content))
+	})
+
+	t.Run("session and headers", func(t *testing.T) {
+		require.NoError(t, client.Close())
+
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		assert.Equal(t, "", fake.sessions[0])
+		for _, session := range fake.sessions[1:] {
+			assert.Equal(t, "session-1", session)
+		}
+		assert.Equal(t, "session-1", fake.deleted)
+		for _, header := range fake.headers {
+			assert.Equal(t, "hive", header)
+		}
+	})
+}
+
+func TestHTTPTransport_Manager(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer((&fakeHTTPServer{}).handler())
+	t.Cleanup(server.Close)
+
+	registry := tools.NewSimpleToolRegistry()
+	manager := NewManager(config.MCPSettings{Servers: map[string]config.MCPServerSettings{
+		"remote": {Transport: "http", URL: server.URL},
+	}})
+	require.NoError(t, manager.Start(ctx, registry))
+	t.Cleanup(func() { _ = manager.Close() })
+
+	result, err := registry.Call(ctx, "echo", map[string]any{"text": "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "hi", result.Content)
+	assert.Equal(t, "remote", result.Metadata["server"])
+}
+
+func TestHTTPTransport_Retries(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer((&fakeHTTPServer{}).handler())
+	t.Cleanup(server.Close)
+
+	post := func(t *testing.T, url string, rt http.RoundTripper, msg string) error {
+		transport := NewHTTPTransport(url, nil)
+		transport.retryDelay = time.Millisecond
+		transport.client = &http.Client{Transport: rt}
+		t.Cleanup(func() { _ = transport.Close() })
+		return transport.Send(ctx, json.RawMessage(msg))
+	}
+
+	t.Run("tool calls that may have run are not retried", func(t *testing.T) {
+		rt := &droppingRoundTripper{}
+		err := post(t, server.URL, rt, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo"}}`)
+		assert.ErrorContains(t, err, "connection reset")
+		assert.Equal(t, 1, rt.calls)
+	})
+
+	t.Run("idempotent requests are retried", func(t *testing.T) {
+		rt := &droppingRoundTripper{}
+		err := post(t, server.URL, rt, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+		assert.Error(t, err)
+		assert.Equal(t, defaultMaxReconnects+1, rt.calls)
+	})
+
+	t.Run("requests that never reached the server are retried", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		rt := &countingRoundTripper{}
+		err := post(t, closed.URL, rt, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo"}}`)
+		assert.Error(t, err)
+		assert.Equal(t, defaultMaxReconnects+1, rt.calls)
+	})
+}
+
+// countingRoundTripper counts the requests it sends
+type countingRoundTripper struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestHTTPTransport_ExpiredSession(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeHTTPServer{}
+	server := httptest.NewServer(fake.handler())
+	t.Cleanup(server.Close)
+
+	client := NewClient("remote", NewHTTPTransport(server.URL, nil)).WithRequestTimeout(time.Second)
+	require.NoError(t, client.Connect(ctx))
+	t.Cleanup(func() { _ = client.Close() })
+
+	fake.expire()
+
+	// The client starts a new session and the call goes through
+	result, err := client.CallTool(ctx, "echo", map[string]any{"text": "again"})
+	require.NoError(t, err)
+	assert.Equal(t, "again", ContentText(result.Content))
+
+	_, err = client.ListTools(ctx)
+	require.NoError(t, err)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Equal(t, 2, fake.started)
+	assert.Equal(t, "session-2", fake.sessions[len(fake.sessions)-1])
+}
@@ -0,0 +1,99 @@
+// Command fakeserver is a minimal MCP server used by the mcp package tests.
+// It speaks newline-delimited JSON-RPC on stdin and stdout and offers three
+// tools: echo, fail and env.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   any             `json:"error,omitempty"`
+}
+
+var tools = []map[string]any{
+	{
+		"name":        "echo",
+		"description": "Echo the text back",
+		"inputSchema": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"text": map[string]any{"type": "string"}},
+			"required":   []string{"text"},
+		},
+	},
+	{
+		"name":        "fail",
+		"description": "Always fails",
+		"inputSchema": map[string]any{"type": "object"},
+	},
+	{
+		"name":        "env",
+		"description": "Read an environment variable",
+		"inputSchema": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"name": map[string]any{"type": "string"}},
+		},
+	},
+}
+
+func main() {
+	fmt.Fprintln(os.Stderr, "fake MCP server starting")
+
+	out := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req message
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		if len(req.ID) == 0 {
+			// Notifications need no reply
+			continue
+		}
+
+		reply := message{JSONRPC: "2.0", ID: req.ID}
+		switch req.Method {
+		case "initialize":
+			reply.Result = map[string]any{
+				"protocolVersion": "2024-11-05",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "fake", "version": "1.0"},
+			}
+		case "tools/list":
+			reply.Result = map[string]any{"tools": tools}
+		case "tools/call":
+			reply.Result = call(req.Params)
+		default:
+			reply.Error = map[string]any{"code": -32601, "message": "method not found"}
+		}
+		_ = out.Encode(reply)
+	}
+}
+
+func call(params json.RawMessage) map[string]any {
+	var p struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	_ = json.Unmarshal(params, &p)
+
+	text := func(s string) []map[string]any {
+		return []map[string]any{{"type": "text", "text": s}}
+	}
+	switch p.Name {
+	case "echo":
+		return map[string]any{"content": text(p.Arguments["text"])}
+	case "env":
+		return map[string]any{"content": text(os.Getenv(p.Arguments["name"]))}
+	default:
+		return map[string]any{"content": text("tool failed"), "isError": true}
+	}
+}
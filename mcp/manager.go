@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/logging"
+	"github.com/adimarco/hive/tools"
+)
+
+// Connect creates a client for a configured server and connects to it
+func Connect(ctx context.Context, name string, server config.MCPServerSettings) (*Client, error) {
+	if err := server.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid server %q: %w", name, err)
+	}
+
+	var transport Transport
+	switch server.Transport {
+	case "stdio":
+		transport = NewStdioTransport(server.Command, server.Args, server.Env)
+	case "sse":
+		transport = NewSSETransport(server.URL, server.Headers)
+	case "http":
+		transport = NewHTTPTransport(server.URL, server.Headers)
+	default:
+		return nil, fmt.Errorf("server %q: transport %q is not supported", name, server.Transport)
+	}
+
+	client := NewClient(name, transport).WithRequestTimeout(server.Timeout)
+	if err := client.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to server %q: %w", name, err)
+	}
+	return client, nil
+}
+
+// Manager launches the configured MCP servers and registers their tools,
+// named server.tool
+type Manager struct {
+	settings config.MCPSettings
+	logger   logging.Logger
+
+	mu         sync.Mutex
+	clients    map[string]*Client
+	registry   tools.ToolRegistry
+	registered []string
+}
+
+// NewManager creates a manager for the servers in settings
+func NewManager(settings config.MCPSettings) *Manager {
+	return &Manager{
+		settings: settings,
+		logger:   logging.GetLogger("mcp.manager"),
+		clients:  make(map[string]*Client),
+	}
+}
+
+// Start connects to every configured server and registers its tools in
+// registry. If any server fails, the servers already started are closed.
+func (m *Manager) Start(ctx context.Context, registry tools.ToolRegistry) error {
+	m.mu.Lock()
+	m.registry = registry
+	m.mu.Unlock()
+
+	names := make([]string, 0, len(m.settings.Servers))
+	for name := range m.settings.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := m.startServer(ctx, name, m.settings.Servers[name]); err != nil {
+			_ = m.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// startServer connects to one server and registers its tools
+func (m *Manager) startServer(ctx context.Context, name string, server config.MCPServerSettings) error {
+	client, err := Connect(ctx, name, server)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.clients[name] = client
+	m.mu.Unlock()
+
+	serverTools, err := client.ListTools(ctx)
+	if err != nil {
+		return fmt.Errorf("server %q: %w", name, err)
+	}
+
+	// Tools are registered as server.tool, so servers with tools of the
+	// same name do not conflict
+	for _, tool := range serverTools {
+		registered := NewTool(client, tool)
+		registered.Name = tools.QualifiedName(name, tool.Name)
+		if err := m.registry.Register(registered); err != nil {
+			return fmt.Errorf("server %q: failed to register tool %q: %w", name, tool.Name, err)
+		}
+		m.mu.Lock()
+		m.registered = append(m.registered, registered.Name)
+		m.mu.Unlock()
+	}
+
+	m.logger.Info(ctx, "Started MCP server", logging.WithData(map[string]interface{}{
+		"server": name,
+		"tools":  len(serverTools),
+	}))
+	return nil
+}
+
+// Client returns the client for a started server
+func (m *Manager) Client(name string) (*Client, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	client, ok := m.clients[name]
+	return client, ok
+}
+
+// Close unregisters the servers' tools and shuts the servers down
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	clients := m.clients
+	registered := m.registered
+	m.clients = make(map[string]*Client)
+	m.registered = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for _, name := range registered {
+		if err := m.registry.Unregister(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for name, client := range clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close server %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewTool adapts a server tool into a tools.Tool whose handler calls the
+// tool on the server
+func NewTool(client *Client, tool Tool) tools.Tool {
+	schema := tool.InputSchema
+	if len(schema) == 0 {
+		schema = []byte(`{"type":"object","properties":{}}`)
+	}
+
+	return tools.Tool{
+		Name:        tool.Name,
+		Description: tool.Description,
+		Category:    "mcp",
+		Tags:        []string{client.Name()},
+		Schema:      schema,
+		Handler: func(ctx context.Context, args map[string]any) (tools.ToolResult, error) {
+			result, err := client.CallTool(ctx, tool.Name, args)
+			if err != nil {
+				return tools.ToolResult{}, fmt.Errorf("MCP tool %q failed: %w", tool.Name, err)
+			}
+			return tools.ToolResult{
+				Content:  ContentText(result.Content),
+				IsError:  result.IsError,
+				Metadata: map[string]any{"server": client.Name()},
+			}, nil
+		},
+	}
+}
+
+// ContentText renders tool output as text. Images and binary resources are
+// replaced by a short placeholder.
+func ContentText(content []Content) string {
+	parts := make([]string, 0, len(content))
+	for _, item := range content {
+		switch item.Type {
+		case "text":
+			parts = append(parts, item.Text)
+		case "image", "audio":
+			size := base64.StdEncoding.DecodedLen(len(item.Data))
+			parts = append(parts, fmt.Sprintf("[%s: %s, %d bytes]", item.Type, item.MimeType, size))
+		case "resource":
+			if item.Resource == nil {
+				continue
+			}
+			if item.Resource.Text != "" {
+				parts = append(parts, item.Resource.Text)
+			} else {
+				parts = append(parts, fmt.Sprintf("[resource: %s]", item.Resource.URI))
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/tools"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	registry := tools.NewSimpleToolRegistry()
+	manager := NewManager(config.MCPSettings{Servers: map[string]config.MCPServerSettings{
+		"fake": {
+			Transport: "stdio",
+			Command:   fakeServer,
+			Env:       map[string]string{"FAKE_GREETING": "hi there"},
+		},
+	}})
+	require.NoError(t, manager.Start(ctx, registry))
+	t.Cleanup(func() { _ = manager.Close() })
+
+	t.Run("registers server tools", func(t *testing.T) {
+		tool, err := registry.Get("echo")
+		require.NoError(t, err)
+		assert.Equal(t, "fake.echo", tool.Name)
+		assert.Equal(t, "Echo the text back", tool.Description)
+		assert.Equal(t, "mcp", tool.Category)
+		assert.Equal(t, []string{"fake"}, tool.Tags)
+		assert.Len(t, registry.List(), 3)
+	})
+
+	t.Run("handlers proxy tool calls", func(t *testing.T) {
+		result, err := registry.Call(ctx, "echo", map[string]any{"text": "ping"})
+		require.NoError(t, err)
+		assert.Equal(t, "ping", result.Content)
+		assert.Equal(t, "fake", result.Metadata["server"])
+
+		result, err = registry.Call(ctx, "fail", map[string]any{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("arguments are validated against the server schema", func(t *testing.T) {
+		_, err := registry.Call(ctx, "echo", map[string]any{})
+		assert.Error(t, err)
+	})
+
+	t.Run("passes the configured environment", func(t *testing.T) {
+		result, err := registry.Call(ctx, "env", map[string]any{"name": "FAKE_GREETING"})
+		require.NoError(t, err)
+		assert.Equal(t, "hi there", result.Content)
+	})
+
+	t.Run("close unregisters tools and stops servers", func(t *testing.T) {
+		client, ok := manager.Client("fake")
+		require.True(t, ok)
+
+		require.NoError(t, manager.Close())
+		assert.Empty(t, registry.List())
+		_, err := client.ListTools(ctx)
+		assert.Error(t, err)
+	})
+}
+
+func TestManager_StartFailure(t *testing.T) {
+	registry := tools.NewSimpleToolRegistry()
+	manager := NewManager(config.MCPSettings{Servers: map[string]config.MCPServerSettings{
+		"a-fake":  {Transport: "stdio", Command: fakeServer},
+		"missing": {Transport: "stdio", Command: "/nonexistent/mcp-server"},
+	}})
+
+	err := manager.Start(context.Background(), registry)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"missing"`)
+
+	// The server that did start is shut down again
+	assert.Empty(t, registry.List())
+	_, ok := manager.Client("a-fake")
+	assert.False(t, ok)
+}
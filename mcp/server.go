@@ -0,0 +1,371 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adimarco/hive/logging"
+	"github.com/adimarco/hive/tools"
+)
+
+// maxRequestSize bounds the size of a message the server accepts
+const maxRequestSize = 10 << 20
+
+// defaultInputSchema is advertised for tools without a schema
+var defaultInputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// Server publishes tools to MCP clients. Tools come from a registry and
+// from tools added to the server directly, such as agents exposed as tools.
+type Server struct {
+	info     Implementation
+	registry tools.ToolRegistry
+	extra    *tools.SimpleToolRegistry
+	origins  map[string]bool // Origins allowed besides loopback ones
+	logger   logging.Logger
+}
+
+// NewServer creates a server publishing the tools in registry, which may be
+// nil if all tools are added with AddTool
+func NewServer(name, version string, registry tools.ToolRegistry) *Server {
+	return &Server{
+		info:     Implementation{Name: name, Version: version},
+		registry: registry,
+		extra:    tools.NewSimpleToolRegistry(),
+		logger:   logging.GetLogger("mcp.server"),
+	}
+}
+
+// WithAllowedOrigins lets web pages from the given origins, such as
+// "https://example.com", call the HTTP transport. Pages served from
+// loopback addresses are always allowed, and requests without an Origin
+// header, which browsers always send, are not affected.
+func (s *Server) WithAllowedOrigins(origins ...string) *Server {
+	if s.origins == nil {
+		s.origins = make(map[string]bool)
+	}
+	for _, origin := range origins {
+		s.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	return s
+}
+
+// AddTool publishes a tool in addition to those in the registry. It takes
+// precedence over a registry tool with the same name.
+func (s *Server) AddTool(tool tools.Tool) error {
+	return s.extra.Register(tool)
+}
+
+// Tools returns the published tools sorted by name. Internal registry
+// tools, those of workflows, are not published.
+func (s *Server) Tools() []tools.Tool {
+	byName := make(map[string]tools.Tool)
+	if s.registry != nil {
+		for _, tool := range s.registry.List() {
+			if !tool.IsInternal() {
+				byName[tool.Name] = tool
+			}
+		}
+	}
+	for _, tool := range s.extra.List() {
+		byName[tool.Name] = tool
+	}
+
+	result := make([]tools.Tool, 0, len(byName))
+	for _, tool := range byName {
+		result = append(result, tool)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Handle processes one JSON-RPC message or batch and returns the reply, or
+// nil if there is nothing to send back
+func (s *Server) Handle(ctx context.Context, data json.RawMessage) json.RawMessage {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return s.errorReply(nil, CodeParseError, "invalid JSON")
+		}
+		var replies []json.RawMessage
+		for _, msg := range batch {
+			if reply := s.Handle(ctx, msg); reply != nil {
+				replies = append(replies, reply)
+			}
+		}
+		if len(replies) == 0 {
+			return nil
+		}
+		reply, _ := json.Marshal(replies)
+		return reply
+	}
+
+	var req message
+	if err := json.Unmarshal(data, &req); err != nil {
+		return s.errorReply(nil, CodeParseError, "invalid JSON")
+	}
+	if req.Method == "" {
+		if req.isResponse() {
+			// Replies to requests we never send
+			return nil
+		}
+		return s.errorReply(req.ID, CodeInvalidRequest, "method is required")
+	}
+
+	result, rpcErr := s.dispatch(ctx, &req)
+	if len(req.ID) == 0 {
+		// Notifications get no reply
+		return nil
+	}
+	if rpcErr != nil {
+		return s.errorReply(req.ID, rpcErr.Code, rpcErr.Message)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return s.errorReply(req.ID, CodeInternalError, err.Error())
+	}
+	reply, _ := json.Marshal(message{JSONRPC: "2.0", ID: req.ID, Result: encoded})
+	return reply
+}
+
+// dispatch runs a request and returns its result
+func (s *Server) dispatch(ctx context.Context, req *message) (any, *RPCError) {
+	switch req.Method {
+	case "initialize":
+		return InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    ServerCapabilities{Tools: &ListChangedCapability{}},
+			ServerInfo:      s.info,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		published := s.Tools()
+		result := ListToolsResult{Tools: make([]Tool, 0, len(published))}
+		for _, tool := range published {
+			schema := tool.Schema
+			if len(schema) == 0 {
+				schema = defaultInputSchema
+			}
+			result.Tools = append(result.Tools, Tool{Name: tool.Name, Description: tool.Description, InputSchema: schema})
+		}
+		return result, nil
+	case "tools/call":
+		var params callToolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid tools/call params"}
+		}
+		return s.callTool(ctx, params)
+	default:
+		if strings.HasPrefix(req.Method, "notifications/") {
+			return nil, nil
+		}
+		return nil, &RPCError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// callTool runs a published tool
+func (s *Server) callTool(ctx context.Context, params callToolParams) (any, *RPCError) {
+	registry := tools.ToolRegistry(s.extra)
+	if _, err := s.extra.Get(params.Name); err != nil {
+		if s.registry == nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		if tool, err := s.registry.Get(params.Name); err != nil || tool.IsInternal() {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		registry = s.registry
+	}
+
+	args := params.Arguments
+	if args == nil {
+		args = map[string]any{}
+	}
+	result, err := registry.Call(ctx, params.Name, args)
+	if err != nil {
+		// Invalid arguments are reported to the model like tool errors
+		result = tools.NewErrorResult(err)
+	}
+	return CallToolResult{
+		Content: []Content{{Type: "text", Text: result.Content}},
+		IsError: result.IsError,
+	}, nil
+}
+
+// errorReply encodes an error response
+func (s *Server) errorReply(id json.RawMessage, code int, msg string) json.RawMessage {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	reply, _ := json.Marshal(message{JSONRPC: "2.0", ID: id, Error: &RPCError{Code: code, Message: msg}})
+	return reply
+}
+
+// ServeStdio serves newline-delimited JSON-RPC messages read from r,
+// writing replies to w, until r is exhausted or ctx is done. Requests are
+// handled concurrently so a slow tool does not block other calls.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var writeMu sync.Mutex
+	var handlers sync.WaitGroup
+	defer handlers.Wait()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReaderSize(r, 64*1024)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					readErr <- err
+				}
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				select {
+				case err := <-readErr:
+					return fmt.Errorf("failed to read request: %w", err)
+				default:
+					return nil
+				}
+			}
+			handlers.Add(1)
+			go func() {
+				defer handlers.Done()
+				reply := s.Handle(ctx, line)
+				if reply == nil {
+					return
+				}
+				writeMu.Lock()
+				defer writeMu.Unlock()
+				if _, err := w.Write(append(reply, '\n')); err != nil {
+					s.logger.Error(ctx, "Failed to write MCP reply", logging.WithData(map[string]interface{}{
+						"error": err.Error(),
+					}))
+				}
+			}()
+		}
+	}
+}
+
+// ServeHTTP serves the streamable HTTP transport. Each POSTed message is
+// answered with a JSON body; the server keeps no per-session state, but
+// assigns a session ID on initialize for clients that expect one. Requests
+// from web pages whose origin is not allowed are rejected, so a page the
+// user visits cannot reach the tools through DNS rebinding.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && !s.originAllowed(origin) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	if isInitialize(body) && r.Header.Get(sessionHeader) == "" {
+		w.Header().Set(sessionHeader, newSessionID())
+	}
+
+	reply := s.Handle(r.Context(), body)
+	if reply == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(reply)
+}
+
+// originAllowed reports whether a web page from origin may call the
+// server: it is served from a loopback address or was allowed explicitly
+func (s *Server) originAllowed(origin string) bool {
+	if s.origins[strings.TrimSuffix(origin, "/")] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isInitialize reports whether a POSTed message or batch includes an
+// initialize request
+func isInitialize(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	var msgs []message
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &msgs); err != nil {
+			return false
+		}
+	} else {
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return false
+		}
+		msgs = append(msgs, msg)
+	}
+	for _, msg := range msgs {
+		if msg.Method == "initialize" {
+			return true
+		}
+	}
+	return false
+}
+
+// newSessionID returns a random session ID
+func newSessionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
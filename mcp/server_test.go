@@ -0,0 +1,213 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/tools"
+)
+
+// newTestServer returns a server publishing an upper tool from a registry
+// and a shout tool added directly
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	registry := tools.NewSimpleToolRegistry()
+	require.NoError(t, tools.RegisterFunctionTool(registry, "upper", "Upper-case the input", strings.ToUpper))
+
+	server := NewServer("test", "1.0", registry)
+	require.NoError(t, server.AddTool(tools.New("shout").
+		WithDescription("Shout the input").
+		WithHandler(func(ctx context.Context, args map[string]any) (string, error) {
+			return strings.ToUpper(args["input"].(string)) + "!", nil
+		}).
+		Build()))
+	return server
+}
+
+func TestServer_Handle(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+
+	tests := []struct {
+		name    string
+		request string
+		want    string
+	}{
+		{
+			name:    "initialize",
+			request: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+			want: `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2024-11-05",
+				"capabilities":{"tools":{}},"serverInfo":{"name":"test","version":"1.0"}}}`,
+		},
+		{
+			name:    "ping",
+			request: `{"jsonrpc":"2.0","id":"a","method":"ping"}`,
+			want:    `{"jsonrpc":"2.0","id":"a","result":{}}`,
+		},
+		{
+			name:    "call registry tool",
+			request: `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"upper","arguments":{"input":"hi"}}}`,
+			want:    `{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"HI"}]}}`,
+		},
+		{
+			name:    "call added tool",
+			request: `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"shout","arguments":{"input":"hi"}}}`,
+			want:    `{"jsonrpc":"2.0","id":3,"result":{"content":[{"type":"text","text":"HI!"}]}}`,
+		},
+		{
+			name:    "invalid arguments",
+			request: `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"upper","arguments":{}}}`,
+			want: `{"jsonrpc":"2.0","id":4,"result":{"isError":true,"content":[{"type":"text",
+				"text":"invalid arguments: invalid arguments: [(root): input is required]"}]}}`,
+		},
+		{
+			name:    "unknown tool",
+			request: `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"missing"}}`,
+			want:    `{"jsonrpc":"2.0","id":5,"error":{"code":-32602,"message":"unknown tool \"missing\""}}`,
+		},
+		{
+			name:    "unknown method",
+			request: `{"jsonrpc":"2.0","id":6,"method":"prompts/list"}`,
+			want:    `{"jsonrpc":"2.0","id":6,"error":{"code":-32601,"message":"method \"prompts/list\" not found"}}`,
+		},
+		{
+			name:    "invalid json",
+			request: `{"jsonrpc":`,
+			want:    `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"invalid JSON"}}`,
+		},
+		{
+			name:    "batch",
+			request: `[{"jsonrpc":"2.0","id":7,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`,
+			want:    `[{"jsonrpc":"2.0","id":7,"result":{}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := server.Handle(ctx, json.RawMessage(tt.request))
+			assert.JSONEq(t, tt.want, string(reply))
+		})
+	}
+
+	t.Run("notifications get no reply", func(t *testing.T) {
+		assert.Nil(t, server.Handle(ctx, json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+	})
+
+	t.Run("list tools", func(t *testing.T) {
+		reply := server.Handle(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		var msg message
+		require.NoError(t, json.Unmarshal(reply, &msg))
+		var result ListToolsResult
+		require.NoError(t, json.Unmarshal(msg.Result, &result))
+
+		require.Len(t, result.Tools, 2)
+		assert.Equal(t, "shout", result.Tools[0].Name)
+		assert.JSONEq(t, `{"type":"object","properties":{}}`, string(result.Tools[0].InputSchema))
+		assert.Equal(t, "upper", result.Tools[1].Name)
+		assert.Contains(t, string(result.Tools[1].InputSchema), `"required"`)
+	})
+}
+
+func TestServer_ServeStdio(t *testing.T) {
+	server := newTestServer(t)
+	in := strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"upper","arguments":{"input":"stdio"}}}`,
+	}, "\n"))
+	var out bytes.Buffer
+
+	require.NoError(t, server.ServeStdio(context.Background(), in, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	replies := map[string]string{}
+	for _, line := range lines {
+		var msg message
+		require.NoError(t, json.Unmarshal([]byte(line), &msg))
+		replies[string(msg.ID)] = string(msg.Result)
+	}
+	assert.Contains(t, replies["1"], `"serverInfo"`)
+	assert.JSONEq(t, `{"content":[{"type":"text","text":"STDIO"}]}`, replies["2"])
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	ctx := context.Background()
+	httpServer := httptest.NewServer(newTestServer(t))
+	t.Cleanup(httpServer.Close)
+
+	// The server is reachable with the package's own client
+	client, err := Connect(ctx, "hive", config.MCPServerSettings{Transport: "http", URL: httpServer.URL})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	assert.Equal(t, "test", client.Server().ServerInfo.Name)
+	listed, err := client.ListTools(ctx)
+	require.NoError(t, err)
+	assert.Len(t, listed, 2)
+
+	result, err := client.CallTool(ctx, "shout", map[string]any{"input": "http"})
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP!", ContentText(result.Content))
+}
+
+func TestServer_ServeHTTPOrigin(t *testing.T) {
+	server := newTestServer(t).WithAllowedOrigins("https://app.example.com/")
+	post := func(origin, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+	for _, origin := range []string{"", "http://localhost:3000", "http://127.0.0.1", "http://[::1]:8080", "https://app.example.com"} {
+		assert.Equal(t, http.StatusOK, post(origin, ping).Code, origin)
+	}
+	for _, origin := range []string{"https://evil.example", "null", "http://localhost.evil.example"} {
+		assert.Equal(t, http.StatusForbidden, post(origin, ping).Code, origin)
+	}
+
+	t.Run("session assigned only on initialize", func(t *testing.T) {
+		rec := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+		assert.NotEmpty(t, rec.Header().Get(sessionHeader))
+
+		rec = post("", `[{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}]`)
+		assert.NotEmpty(t, rec.Header().Get(sessionHeader))
+
+		rec = post("", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"upper","arguments":{"input":"initialize"}}}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(sessionHeader))
+	})
+}
+
+func TestServer_InternalTools(t *testing.T) {
+	ctx := context.Background()
+	registry := tools.NewSimpleToolRegistry()
+	require.NoError(t, tools.RegisterFunctionTool(registry, "upper", "Upper-case the input", strings.ToUpper))
+	require.NoError(t, registry.Register(tools.New("router.select_agents").
+		WithTags(tools.TagInternal).
+		WithHandler(func(ctx context.Context, args map[string]any) (string, error) { return "recorded", nil }).
+		Build()))
+	server := NewServer("test", "1.0", registry)
+
+	published := server.Tools()
+	require.Len(t, published, 1)
+	assert.Equal(t, "upper", published[0].Name)
+
+	reply := server.Handle(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"router.select_agents"}}`))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"unknown tool \"router.select_agents\""}}`, string(reply))
+}
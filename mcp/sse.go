@@ -0,0 +1,332 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adimarco/hive/logging"
+)
+
+// Reconnect defaults for the SSE transport
+const (
+	defaultMaxReconnects  = 5
+	defaultReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay     = 10 * time.Second
+)
+
+// sseEvent is one event of a text/event-stream
+type sseEvent struct {
+	Event string
+	Data  string
+}
+
+// readEvents parses a text/event-stream, calling fn for each event until
+// the stream ends or fn returns false
+func readEvents(r io.Reader, fn func(sseEvent) bool) error {
+	reader := bufio.NewReader(r)
+	var event sseEvent
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "" && err == nil:
+			// A blank line dispatches the event
+			if len(data) > 0 {
+				event.Data = strings.Join(data, "\n")
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				if !fn(event) {
+					return nil
+				}
+			}
+			event, data = sseEvent{}, nil
+		case strings.HasPrefix(line, ":"):
+			// Comment, used as a keep-alive
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event.Event = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// setHeaders adds the configured headers to an HTTP request
+func setHeaders(req *http.Request, headers map[string]string) {
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+}
+
+// SSETransport connects to an MCP server using the HTTP+SSE transport:
+// server messages arrive on a long-lived event stream, and client messages
+// are POSTed to an endpoint the server announces on that stream. A dropped
+// stream is reconnected with exponential backoff.
+type SSETransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	logger  logging.Logger
+
+	maxReconnects  int
+	reconnectDelay time.Duration
+
+	mu          sync.Mutex
+	started     bool
+	endpoint    string
+	ready       chan struct{} // closed when the first endpoint is known
+	onReconnect func()
+
+	messages chan json.RawMessage
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewSSETransport creates a transport for the SSE endpoint at url, sending
+// headers with every request
+func NewSSETransport(url string, headers map[string]string) *SSETransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SSETransport{
+		url:            url,
+		headers:        headers,
+		client:         &http.Client{},
+		logger:         logging.GetLogger("mcp.sse"),
+		maxReconnects:  defaultMaxReconnects,
+		reconnectDelay: defaultReconnectDelay,
+		ready:          make(chan struct{}),
+		messages:       make(chan json.RawMessage, 16),
+		ctx:            ctx,
+		cancel:         cancel,
+		done:           make(chan struct{}),
+	}
+}
+
+// Start opens the event stream and waits for the server to announce its
+// message endpoint
+func (t *SSETransport) Start(ctx context.Context) error {
+	t.mu.Lock()
+	t.started = true
+	t.mu.Unlock()
+
+	body, err := t.connect(ctx)
+	if err != nil {
+		close(t.done)
+		close(t.messages)
+		return err
+	}
+	go t.run(body)
+
+	select {
+	case <-t.ready:
+		return nil
+	case <-t.done:
+		return fmt.Errorf("event stream closed before the endpoint was announced")
+	case <-ctx.Done():
+		t.cancel()
+		return ctx.Err()
+	}
+}
+
+// connect opens the event stream
+func (t *SSETransport) connect(ctx context.Context) (io.ReadCloser, error) {
+	// The stream outlives ctx, which only bounds connecting
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	setHeaders(req, t.headers)
+
+	stop := context.AfterFunc(ctx, t.cancelIfNotReady)
+	defer stop()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", t.url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to connect to %s: %s", t.url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// cancelIfNotReady aborts a first connection attempt whose context ends
+func (t *SSETransport) cancelIfNotReady() {
+	select {
+	case <-t.ready:
+	default:
+		t.cancel()
+	}
+}
+
+// run reads the event stream, reconnecting when it drops, until the
+// transport is closed or reconnecting fails
+func (t *SSETransport) run(body io.ReadCloser) {
+	defer close(t.done)
+	defer close(t.messages)
+
+	for {
+		err := readEvents(body, t.handleEvent)
+		body.Close()
+		if t.ctx.Err() != nil {
+			return
+		}
+
+		t.logger.Warning(context.Background(), "MCP event stream dropped, reconnecting", logging.WithData(map[string]interface{}{
+			"url":   t.url,
+			"error": fmt.Sprint(err),
+		}))
+		t.mu.Lock()
+		t.endpoint = ""
+		t.mu.Unlock()
+
+		if body = t.reconnect(); body == nil {
+			return
+		}
+	}
+}
+
+// reconnect reopens the event stream with exponential backoff, returning
+// nil when the transport is closed or all attempts fail
+func (t *SSETransport) reconnect() io.ReadCloser {
+	delay := t.reconnectDelay
+	for attempt := 1; attempt <= t.maxReconnects; attempt++ {
+		select {
+		case <-time.After(delay):
+		case <-t.ctx.Done():
+			return nil
+		}
+
+		body, err := t.connect(t.ctx)
+		if err == nil {
+			return body
+		}
+		t.logger.Warning(context.Background(), "MCP reconnect failed", logging.WithData(map[string]interface{}{
+			"url":     t.url,
+			"attempt": attempt,
+			"error":   err.Error(),
+		}))
+		delay = min(delay*2, maxReconnectDelay)
+	}
+	return nil
+}
+
+// handleEvent records the message endpoint and forwards messages
+func (t *SSETransport) handleEvent(event sseEvent) bool {
+	switch event.Event {
+	case "endpoint":
+		endpoint, err := url.Parse(t.url)
+		if err == nil {
+			endpoint, err = endpoint.Parse(event.Data)
+		}
+		if err != nil {
+			t.logger.Warning(context.Background(), "Ignoring invalid MCP endpoint", logging.WithData(map[string]interface{}{
+				"endpoint": event.Data,
+			}))
+			return true
+		}
+		t.mu.Lock()
+		t.endpoint = endpoint.String()
+		reconnected := false
+		select {
+		case <-t.ready:
+			reconnected = true
+		default:
+			close(t.ready)
+		}
+		onReconnect := t.onReconnect
+		t.mu.Unlock()
+
+		// A new stream is a new session, which the client must initialize
+		// again; it needs this goroutine to deliver the replies
+		if reconnected && onReconnect != nil {
+			go onReconnect()
+		}
+	case "message":
+		select {
+		case t.messages <- json.RawMessage(event.Data):
+		case <-t.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// OnReconnect registers a function called when the event stream has been
+// reconnected and the server has announced a new endpoint
+func (t *SSETransport) OnReconnect(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onReconnect = fn
+}
+
+// Send POSTs a message to the endpoint announced by the server
+func (t *SSETransport) Send(ctx context.Context, msg json.RawMessage) error {
+	t.mu.Lock()
+	endpoint := t.endpoint
+	t.mu.Unlock()
+	if endpoint == "" {
+		return fmt.Errorf("not connected to %s", t.url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setHeaders(req, t.headers)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post message: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post message: %s", resp.Status)
+	}
+	return nil
+}
+
+// Messages implements Transport
+func (t *SSETransport) Messages() <-chan json.RawMessage {
+	return t.messages
+}
+
+// Close closes the event stream
+func (t *SSETransport) Close() error {
+	t.cancel()
+	t.mu.Lock()
+	started := t.started
+	t.mu.Unlock()
+	if started {
+		<-t.done
+	}
+	return nil
+}
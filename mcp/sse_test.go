@@ -0,0 +1,220 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/tools"
+)
+
+// fakeRPC answers a JSON-RPC message the way a small MCP server with an
+// echo tool and a slow tool that never replies would. It returns nil for
+// notifications and unanswered requests.
+func fakeRPC(data []byte) []byte {
+	var req message
+	if err := json.Unmarshal(data, &req); err != nil || len(req.ID) == 0 {
+		return nil
+	}
+
+	var result any
+	switch req.Method {
+	case "initialize":
+		result = InitializeResult{ProtocolVersion: ProtocolVersion, ServerInfo: Implementation{Name: "remote", Version: "1.0"}}
+	case "tools/list":
+		result = ListToolsResult{Tools: []Tool{{
+			Name:        "echo",
+			Description: "Echo the text back",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}}}`),
+		}}}
+	case "tools/call":
+		var params callToolParams
+		_ = json.Unmarshal(req.Params, &params)
+		if params.Name == "slow" {
+			return nil
+		}
+		result = CallToolResult{Content: []Content{{Type: "text", Text: fmt.Sprint(params.Arguments["text"])}}}
+	default:
+		return nil
+	}
+
+	data, _ = json.Marshal(result)
+	reply, _ := json.Marshal(message{JSONRPC: "2.0", ID: req.ID, Result: data})
+	return reply
+}
+
+// fakeSSEServer is an MCP server using the HTTP+SSE transport. It requires
+// an Authorization header and can drop its event stream on demand.
+type fakeSSEServer struct {
+	mu          sync.Mutex
+	connects    int
+	initializes int
+	out         chan []byte
+	drop        chan struct{}
+}
+
+func (f *fakeSSEServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		f.mu.Lock()
+		f.connects++
+		session := f.connects
+		out, drop := make(chan []byte, 16), make(chan struct{})
+		f.out, f.drop = out, drop
+		f.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, ": keep-alive\n\nevent: endpoint\ndata: /messages?session=%d\n\n", session)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case msg := <-out:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+				w.(http.Flusher).Flush()
+			case <-drop:
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		f.mu.Lock()
+		if strings.Contains(string(body), `"initialize"`) {
+			f.initializes++
+		}
+		out := f.out
+		f.mu.Unlock()
+
+		if reply := fakeRPC(body); reply != nil {
+			out <- reply
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return mux
+}
+
+// dropStream ends the current event stream
+func (f *fakeSSEServer) dropStream() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	close(f.drop)
+}
+
+// counts returns the number of streams opened and handshakes received
+func (f *fakeSSEServer) counts() (connects, initializes int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connects, f.initializes
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := "event: endpoint\ndata: /messages\n\n" +
+		": comment\n" +
+		"data: {\"a\":1}\r\n\r\n" +
+		"event: message\ndata: line one\ndata: line two\n\n" +
+		"data: unterminated"
+
+	var events []sseEvent
+	require.NoError(t, readEvents(strings.NewReader(stream), func(event sseEvent) bool {
+		events = append(events, event)
+		return true
+	}))
+
+	assert.Equal(t, []sseEvent{
+		{Event: "endpoint", Data: "/messages"},
+		{Event: "message", Data: `{"a":1}`},
+		{Event: "message", Data: "line one\nline two"},
+	}, events)
+}
+
+func TestSSETransport(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeSSEServer{}
+	server := httptest.NewServer(fake.handler())
+	t.Cleanup(server.Close)
+
+	transport := NewSSETransport(server.URL+"/sse", map[string]string{"Authorization": "Bearer secret"})
+	transport.reconnectDelay = 10 * time.Millisecond
+	client := NewClient("remote", transport).WithRequestTimeout(time.Second)
+	require.NoError(t, client.Connect(ctx))
+	t.Cleanup(func() { _ = client.Close() })
+
+	t.Run("call tool", func(t *testing.T) {
+		assert.Equal(t, "remote", client.Server().ServerInfo.Name)
+		result, err := client.CallTool(ctx, "echo", map[string]any{"text": "over sse"})
+		require.NoError(t, err)
+		assert.Equal(t, "over sse", ContentText(result.Content))
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		_, err := client.WithRequestTimeout(50*time.Millisecond).CallTool(ctx, "slow", nil)
+		client.WithRequestTimeout(time.Second)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out")
+	})
+
+	t.Run("reconnects and reinitializes", func(t *testing.T) {
+		fake.dropStream()
+		require.Eventually(t, func() bool {
+			connects, initializes := fake.counts()
+			return connects == 2 && initializes == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		result, err := client.CallTool(ctx, "echo", map[string]any{"text": "again"})
+		require.NoError(t, err)
+		assert.Equal(t, "again", ContentText(result.Content))
+	})
+}
+
+func TestSSETransport_Headers(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer((&fakeSSEServer{}).handler())
+	t.Cleanup(server.Close)
+
+	t.Run("registers tools from configured server", func(t *testing.T) {
+		registry := tools.NewSimpleToolRegistry()
+		manager := NewManager(config.MCPSettings{Servers: map[string]config.MCPServerSettings{
+			"remote": {
+				Transport: "sse",
+				URL:       server.URL + "/sse",
+				Headers:   map[string]string{"Authorization": "Bearer secret"},
+				Timeout:   time.Second,
+			},
+		}})
+		require.NoError(t, manager.Start(ctx, registry))
+		t.Cleanup(func() { _ = manager.Close() })
+
+		result, err := registry.Call(ctx, "echo", map[string]any{"text": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, "hi", result.Content)
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		_, err := Connect(ctx, "remote", config.MCPServerSettings{Transport: "sse", URL: server.URL + "/sse"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+	})
+}
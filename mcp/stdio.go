@@ -0,0 +1,223 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adimarco/hive/logging"
+)
+
+// stopTimeout is how long Close waits for a server process to exit after
+// its stdin is closed before killing it, and again after killing it
+const stopTimeout = 3 * time.Second
+
+// StdioTransport runs an MCP server as a child process and exchanges
+// newline-delimited JSON-RPC messages over its stdin and stdout. The
+// server's stderr is forwarded to the debug log.
+type StdioTransport struct {
+	command string
+	args    []string
+	env     map[string]string
+	logger  logging.Logger
+
+	stopTimeout time.Duration
+
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	output   []io.Closer // the server's stdout and stderr
+	writeMu  sync.Mutex
+	messages chan json.RawMessage
+	closing  chan struct{}
+	exited   chan struct{}
+	waitErr  error
+
+	closeOnce sync.Once
+}
+
+// NewStdioTransport creates a transport for the server started by command.
+// The environment is inherited from this process, with env added on top.
+func NewStdioTransport(command string, args []string, env map[string]string) *StdioTransport {
+	return &StdioTransport{
+		command: command,
+		args:    args,
+		env:     env,
+		logger:  logging.GetLogger("mcp.stdio"),
+
+		stopTimeout: stopTimeout,
+		messages:    make(chan json.RawMessage, 16),
+		closing:     make(chan struct{}),
+		exited:      make(chan struct{}),
+	}
+}
+
+// Start launches the server process
+func (t *StdioTransport) Start(ctx context.Context) error {
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(t.env))
+	for k := range t.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+t.env[k])
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stderr: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %q: %w", t.command, err)
+	}
+	t.cmd = cmd
+	t.stdin = stdin
+	t.output = []io.Closer{stdout, stderr}
+
+	var output sync.WaitGroup
+	output.Add(2)
+	go func() {
+		defer output.Done()
+		t.readMessages(stdout)
+	}()
+	go func() {
+		defer output.Done()
+		t.logStderr(stderr)
+	}()
+	go func() {
+		// Wait must only be called once all output has been read
+		output.Wait()
+		t.waitErr = cmd.Wait()
+		close(t.exited)
+	}()
+
+	return nil
+}
+
+// readMessages forwards each line of the server's stdout as a message
+func (t *StdioTransport) readMessages(stdout io.Reader) {
+	defer close(t.messages)
+
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			select {
+			case t.messages <- json.RawMessage(line):
+			case <-t.closing:
+				_, _ = io.Copy(io.Discard, reader)
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// logStderr forwards the server's stderr to the debug log
+func (t *StdioTransport) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		t.logger.Debug(context.Background(), scanner.Text(), logging.WithData(map[string]interface{}{
+			"command": t.command,
+		}))
+	}
+}
+
+// Send writes a message to the server's stdin
+func (t *StdioTransport) Send(ctx context.Context, msg json.RawMessage) error {
+	select {
+	case <-t.exited:
+		return fmt.Errorf("server process has exited")
+	default:
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	if t.stdin == nil {
+		return fmt.Errorf("transport not started")
+	}
+	if _, err := t.stdin.Write(append(bytes.TrimSpace(msg), '\n')); err != nil {
+		return fmt.Errorf("failed to write to server: %w", err)
+	}
+	return nil
+}
+
+// Messages implements Transport
+func (t *StdioTransport) Messages() <-chan json.RawMessage {
+	return t.messages
+}
+
+// Close closes the server's stdin and waits for it to exit, killing the
+// process if it does not stop in time. Processes the server started, as
+// npx and uvx do, may keep its output open after it is killed; the output
+// is then closed so Close does not wait for them.
+func (t *StdioTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closing)
+		if t.cmd == nil {
+			close(t.messages)
+			return
+		}
+
+		t.writeMu.Lock()
+		_ = t.stdin.Close()
+		t.writeMu.Unlock()
+
+		select {
+		case <-t.exited:
+			return
+		case <-time.After(t.stopTimeout):
+		}
+		t.logger.Warning(context.Background(), "MCP server did not exit, killing it", logging.WithData(map[string]interface{}{
+			"command": t.command,
+		}))
+		_ = t.cmd.Process.Kill()
+
+		select {
+		case <-t.exited:
+			return
+		case <-time.After(t.stopTimeout):
+		}
+		t.logger.Warning(context.Background(), "MCP server output still open after kill, closing it", logging.WithData(map[string]interface{}{
+			"command": t.command,
+		}))
+		for _, output := range t.output {
+			_ = output.Close()
+		}
+
+		select {
+		case <-t.exited:
+		case <-time.After(t.stopTimeout):
+			t.logger.Error(context.Background(), "MCP server did not exit", logging.WithData(map[string]interface{}{
+				"command": t.command,
+			}))
+		}
+	})
+	return nil
+}
+
+// Wait blocks until the server process has exited and returns its exit error
+func (t *StdioTransport) Wait() error {
+	<-t.exited
+	return t.waitErr
+}
@@ -0,0 +1,28 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Transport carries JSON-RPC messages between a client and an MCP server
+type Transport interface {
+	// Start opens the connection
+	Start(ctx context.Context) error
+
+	// Send delivers one JSON-RPC message to the server
+	Send(ctx context.Context, msg json.RawMessage) error
+
+	// Messages returns the messages received from the server. The channel
+	// is closed when the connection ends.
+	Messages() <-chan json.RawMessage
+
+	// Close ends the connection and releases its resources
+	Close() error
+}
+
+// reconnectingTransport is implemented by transports that can reconnect on
+// their own. The client uses it to repeat the initialize handshake.
+type reconnectingTransport interface {
+	OnReconnect(fn func())
+}
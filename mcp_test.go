@@ -0,0 +1,113 @@
+package hive
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/mcp"
+	"github.com/adimarco/hive/tools"
+)
+
+func TestNewMCPServer(t *testing.T) {
+	ctx := context.Background()
+
+	registry := tools.NewSimpleToolRegistry()
+	require.NoError(t, tools.RegisterFunctionTool(registry, "reverse", "Reverse the input", func(s string) string {
+		runes := []rune(s)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	}))
+	agent := New("shout", "Shout the input").
+		WithLLM(llm.NewPassthroughLLM("shout").WithTransform(strings.ToUpper)).
+		WithHistory()
+
+	server, err := NewMCPServer("test", registry, agent)
+	require.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client, err := mcp.Connect(ctx, "hive", config.MCPServerSettings{Transport: "http", URL: httpServer.URL})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	t.Run("lists agents and tools", func(t *testing.T) {
+		listed, err := client.ListTools(ctx)
+		require.NoError(t, err)
+		require.Len(t, listed, 2)
+		assert.Equal(t, "reverse", listed[0].Name)
+		assert.Equal(t, "shout", listed[1].Name)
+		assert.Contains(t, listed[1].Description, "Shout the input")
+		assert.Contains(t, string(listed[1].InputSchema), `"message"`)
+	})
+
+	t.Run("calls agent", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "shout", map[string]any{"message": "hello"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Equal(t, "HELLO", mcp.ContentText(result.Content))
+	})
+
+	t.Run("calls agent in session", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "shout", map[string]any{"message": "again", "session": "s1"})
+		require.NoError(t, err)
+		assert.Equal(t, "AGAIN", mcp.ContentText(result.Content))
+	})
+
+	t.Run("calls registry tool", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "reverse", map[string]any{"input": "abc"})
+		require.NoError(t, err)
+		assert.Equal(t, "cba", mcp.ContentText(result.Content))
+	})
+
+	t.Run("missing message", func(t *testing.T) {
+		result, err := client.CallTool(ctx, "shout", map[string]any{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestNewAppFromSettings_Agents(t *testing.T) {
+	settings := &config.Settings{
+		Anthropic: config.AnthropicSettings{APIKey: "test-key"},
+		Agents: map[string]config.AgentSettings{
+			"reviewer": {Instruction: "Review code", History: true},
+		},
+	}
+
+	t.Run("creates configured agents", func(t *testing.T) {
+		app, err := NewAppFromSettings("test", settings)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = app.Close() })
+
+		agent, ok := app.GetAgent("reviewer")
+		require.True(t, ok)
+		assert.Equal(t, "Review code", agent.instruction)
+
+		_, ok = app.GetAgent("missing")
+		assert.False(t, ok)
+
+		server, err := app.MCPServer(agent)
+		require.NoError(t, err)
+		var names []string
+		for _, tool := range server.Tools() {
+			names = append(names, tool.Name)
+		}
+		assert.Contains(t, names, "reviewer")
+	})
+
+	t.Run("invalid agent", func(t *testing.T) {
+		invalid := *settings
+		invalid.Agents = map[string]config.AgentSettings{"empty": {}}
+		_, err := NewAppFromSettings("test", &invalid)
+		assert.ErrorContains(t, err, `invalid agent "empty"`)
+	})
+}
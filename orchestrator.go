@@ -0,0 +1,240 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/tools"
+)
+
+// DefaultMaxSteps is how many steps an orchestrator may delegate unless
+// WithMaxSteps sets another budget
+const DefaultMaxSteps = 10
+
+// delegateToolName is the tool an orchestrator's LLM calls to hand a step
+// to a team member
+var delegateToolName = tools.QualifiedName("orchestrator", "delegate")
+
+// delegateArgs are the arguments of the delegate tool
+type delegateArgs struct {
+	Member      string `json:"member" jsonschema:"description=Name of the team member to do the step"`
+	Instruction string `json:"instruction" jsonschema:"description=What the member should do\\, with any context it needs"`
+}
+
+// PlanStep is a step an orchestrator delegated and its outcome
+type PlanStep struct {
+	Member      string
+	Instruction string
+	Output      string
+	Err         error
+	Duration    time.Duration
+}
+
+// OrchestratorResult is the outcome of an orchestrated goal: the steps
+// delegated, in the order they were started, and the final answer
+type OrchestratorResult struct {
+	Goal   string
+	Plan   []PlanStep
+	Output string
+}
+
+// Orchestrator is a workflow agent working towards a goal with a team:
+// its LLM plans steps and delegates them to members with a tool call,
+// reviews their results, delegates more until done or the step budget is
+// spent, and writes the final answer. Steps delegated in the same turn run
+// in parallel.
+type Orchestrator struct {
+	*Agent
+	members     []*Agent
+	maxSteps    int
+	stepTimeout time.Duration
+}
+
+// NewOrchestrator creates an orchestrator delegating to members
+func NewOrchestrator(name string, members ...*Agent) *Orchestrator {
+	o := &Orchestrator{
+		Agent: New(name, "You coordinate a team of specialists to achieve the goal you are given. "+
+			"Break the goal into steps, delegate each step to the best suited member, review their results, "+
+			"and combine them into a complete answer."),
+		members:  members,
+		maxSteps: DefaultMaxSteps,
+	}
+	o.agentType = AgentTypeOrchestrator
+	o.workflow = o
+	return o
+}
+
+// WithMaxSteps sets how many steps may be delegated for one goal. Further
+// delegations are refused, asking the LLM to answer with what it has.
+func (o *Orchestrator) WithMaxSteps(n int) *Orchestrator {
+	o.maxSteps = n
+	return o
+}
+
+// WithStepTimeout bounds how long each delegated step may take
+func (o *Orchestrator) WithStepTimeout(timeout time.Duration) *Orchestrator {
+	o.stepTimeout = timeout
+	return o
+}
+
+// Execute works towards goal and returns the plan and final answer. The
+// result holds the steps delegated so far even when an error is returned.
+func (o *Orchestrator) Execute(ctx context.Context, goal string) (OrchestratorResult, error) {
+	return o.execute(ctx, o.llm, goal)
+}
+
+// send implements workflow
+func (o *Orchestrator) send(ctx context.Context, model llm.AugmentedLLM, msg string) (string, error) {
+	result, err := o.execute(ctx, model, msg)
+	return result.Output, err
+}
+
+// execute works towards goal using model for planning and for members
+// without an LLM of their own
+func (o *Orchestrator) execute(ctx context.Context, model llm.AugmentedLLM, goal string) (OrchestratorResult, error) {
+	result := OrchestratorResult{Goal: goal}
+	if len(o.members) == 0 {
+		return result, fmt.Errorf("orchestrator %q has no members", o.name)
+	}
+	if model == nil {
+		return result, fmt.Errorf("orchestrator %q has no LLM", o.name)
+	}
+	if err := registerDelegateTool(model.Tools()); err != nil {
+		return result, err
+	}
+
+	ra, err := o.Agent.runWith(ctx, model)
+	if err != nil {
+		return result, err
+	}
+	params := ra.buildRequestParams()
+	params.SystemPrompt = o.systemPrompt()
+	params.Tools = []string{delegateToolName}
+	params.ExcludeTools = nil
+	params.ParallelTools = llm.Bool(true)
+	// Refused delegations, such as to unknown members, also take a turn,
+	// so the tool loop allows twice the budget before giving up
+	params.MaxIterations = 2*max(o.maxSteps, 1) + 1
+
+	// The delegate tool finds this run in the context of its calls
+	run := &orchestration{orchestrator: o, model: model}
+	response, err := model.Generate(context.WithValue(ctx, orchestrationKey{}, run), llm.Message{
+		Type:    llm.MessageTypeUser,
+		Content: goal,
+	}, params)
+	result.Plan = run.plan()
+	if err != nil {
+		return result, fmt.Errorf("orchestrator %q failed: %w", o.name, err)
+	}
+	o.recordUsage(ctx, response)
+
+	if strings.TrimSpace(response.Content) == "" {
+		return result, fmt.Errorf("orchestrator %q did not produce a final answer", o.name)
+	}
+	result.Output = response.Content
+	return result, nil
+}
+
+// systemPrompt describes the team and how to delegate to it
+func (o *Orchestrator) systemPrompt() string {
+	var b strings.Builder
+	b.WriteString(o.instruction)
+	b.WriteString("\n\nTeam members:\n")
+	for _, member := range o.members {
+		fmt.Fprintf(&b, "- %s: %s\n", member.name, member.instruction)
+	}
+	fmt.Fprintf(&b, "\nDelegate each step by calling %s with the member's name and a complete instruction; "+
+		"members see only that instruction. Steps that do not depend on each other can be delegated in the same turn "+
+		"and run in parallel. You may delegate at most %d steps. When the goal is achieved, reply with the final answer.",
+		delegateToolName, o.maxSteps)
+	return b.String()
+}
+
+// member returns the named member
+func (o *Orchestrator) member(name string) *Agent {
+	for _, member := range o.members {
+		if member.name == name {
+			return member
+		}
+	}
+	return nil
+}
+
+// orchestrationKey is the context key of the running orchestration
+type orchestrationKey struct{}
+
+// orchestration is one orchestrator run, recording the steps delegated
+type orchestration struct {
+	orchestrator *Orchestrator
+	model        llm.AugmentedLLM
+
+	mu    sync.Mutex
+	steps []PlanStep
+}
+
+// delegate runs one step on a member, within the step budget
+func (r *orchestration) delegate(ctx context.Context, args delegateArgs) (string, error) {
+	o := r.orchestrator
+	member := o.member(args.Member)
+	if member == nil {
+		names := make([]string, len(o.members))
+		for i, m := range o.members {
+			names[i] = m.name
+		}
+		return "", fmt.Errorf("no team member named %q; members are: %s", args.Member, strings.Join(names, ", "))
+	}
+
+	r.mu.Lock()
+	if len(r.steps) >= o.maxSteps {
+		r.mu.Unlock()
+		return "", fmt.Errorf("the budget of %d steps is used up; reply with the final answer using the results so far", o.maxSteps)
+	}
+	index := len(r.steps)
+	r.steps = append(r.steps, PlanStep{Member: member.name, Instruction: args.Instruction})
+	r.mu.Unlock()
+
+	if o.stepTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.stepTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	output, err := sendTo(ctx, member, r.model, args.Instruction)
+
+	r.mu.Lock()
+	r.steps[index].Output = output
+	r.steps[index].Err = err
+	r.steps[index].Duration = time.Since(start)
+	r.mu.Unlock()
+	return output, err
+}
+
+// plan returns a copy of the steps delegated so far
+func (r *orchestration) plan() []PlanStep {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PlanStep(nil), r.steps...)
+}
+
+// registerDelegateTool registers the tool orchestrators delegate steps
+// with in registry. Steps are bounded by the orchestrator's step timeout,
+// not the registry's.
+func registerDelegateTool(registry tools.ToolRegistry) error {
+	tool, err := tools.NewFunctionTool(delegateToolName, "Delegate a step to a team member and get its result",
+		func(ctx context.Context, args delegateArgs) (string, error) {
+			run, ok := ctx.Value(orchestrationKey{}).(*orchestration)
+			if !ok {
+				return "", fmt.Errorf("%s can only be called by an orchestrator", delegateToolName)
+			}
+			return run.delegate(ctx, args)
+		})
+	if err != nil {
+		return err
+	}
+	tool.Timeout = -1
+	return registerWorkflowTool(registry, tool)
+}
@@ -0,0 +1,122 @@
+package hive
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/llm"
+)
+
+// delegation returns a tool call delegating instruction to member
+func delegation(id, member, instruction string) llm.ToolCall {
+	return llm.ToolCall{ID: id, Name: delegateToolName, Args: map[string]any{"member": member, "instruction": instruction}}
+}
+
+// withoutDurations clears step durations so plans can be compared
+func withoutDurations(plan []PlanStep) []PlanStep {
+	for i := range plan {
+		plan[i].Duration = 0
+	}
+	return plan
+}
+
+func TestOrchestrator(t *testing.T) {
+	ctx := context.Background()
+	researcher := transformAgent("researcher", func(s string) string { return "facts for " + s })
+	writer := transformAgent("writer", strings.ToUpper)
+
+	t.Run("delegates steps and synthesizes", func(t *testing.T) {
+		model := llm.NewPlaybackLLM("coordinator", []llm.Message{
+			{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{
+				delegation("1", "researcher", "bees"),
+				delegation("2", "researcher", "wasps"),
+			}},
+			{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{delegation("3", "writer", "compare them")}},
+			{Type: llm.MessageTypeAssistant, Content: "Bees and wasps compared."},
+		})
+		orchestrator := NewOrchestrator("lead", researcher, writer)
+		orchestrator.WithLLM(model)
+		assert.Equal(t, AgentTypeOrchestrator, orchestrator.agentType)
+
+		result, err := orchestrator.Execute(ctx, "Compare bees and wasps")
+		require.NoError(t, err)
+		assert.Equal(t, "Compare bees and wasps", result.Goal)
+		assert.Equal(t, "Bees and wasps compared.", result.Output)
+
+		// The first two steps were delegated together and ran in parallel
+		plan := withoutDurations(result.Plan)
+		require.Len(t, plan, 3)
+		assert.ElementsMatch(t, []PlanStep{
+			{Member: "researcher", Instruction: "bees", Output: "facts for bees"},
+			{Member: "researcher", Instruction: "wasps", Output: "facts for wasps"},
+		}, plan[:2])
+		assert.Equal(t, PlanStep{Member: "writer", Instruction: "compare them", Output: "COMPARE THEM"}, plan[2])
+	})
+
+	t.Run("step budget and unknown members", func(t *testing.T) {
+		model := llm.NewPlaybackLLM("coordinator", []llm.Message{
+			{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{delegation("1", "poet", "a haiku")}},
+			{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{delegation("2", "writer", "one")}},
+			{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{delegation("3", "writer", "two")}},
+			{Type: llm.MessageTypeAssistant, Content: "Done with one step."},
+		})
+		orchestrator := NewOrchestrator("lead", researcher, writer).WithMaxSteps(1)
+		orchestrator.WithLLM(model)
+
+		result, err := orchestrator.Execute(ctx, "Write")
+		require.NoError(t, err)
+		assert.Equal(t, "Done with one step.", result.Output)
+		assert.Equal(t, []PlanStep{{Member: "writer", Instruction: "one", Output: "ONE"}}, withoutDurations(result.Plan))
+	})
+
+	t.Run("no final answer", func(t *testing.T) {
+		model := llm.NewPlaybackLLM("coordinator", []llm.Message{{Type: llm.MessageTypeAssistant}})
+		orchestrator := NewOrchestrator("lead", writer)
+		orchestrator.WithLLM(model)
+
+		_, err := orchestrator.Execute(ctx, "Write")
+		assert.EqualError(t, err, `orchestrator "lead" did not produce a final answer`)
+
+		_, err = NewOrchestrator("empty").Execute(ctx, "Write")
+		assert.EqualError(t, err, `orchestrator "empty" has no members`)
+	})
+
+	t.Run("delegate outside an orchestrator", func(t *testing.T) {
+		model := llm.NewPassthroughLLM("echo")
+		require.NoError(t, registerDelegateTool(model.Tools()))
+
+		result, err := model.Tools().Call(ctx, delegateToolName, map[string]any{"member": "writer", "instruction": "x"})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content, "can only be called by an orchestrator")
+	})
+
+	t.Run("team coordinator", func(t *testing.T) {
+		// Specialists share the team's LLM, so the script interleaves the
+		// coordinator's turns with the specialist's answer
+		model := llm.NewPlaybackLLM("team", []llm.Message{
+			{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{delegation("1", "Researcher", "find sources")}},
+			{Type: llm.MessageTypeAssistant, Content: "three sources"},
+			{Type: llm.MessageTypeAssistant, Content: "Report based on three sources."},
+		})
+		team := NewTeam("research").
+			WithCoordinator("Plan the research and delegate it").
+			WithSpecialist("Researcher", "Find sources").
+			Build(model)
+		defer team.Close()
+
+		result, err := team.Orchestrate("Research hive minds")
+		require.NoError(t, err)
+		assert.Equal(t, "Report based on three sources.", result.Output)
+		assert.Equal(t, []PlanStep{{Member: "Researcher", Instruction: "find sources", Output: "three sources"}}, withoutDurations(result.Plan))
+
+		plain := TeamWithLLM("plain", model)
+		defer plain.Close()
+		_, err = plain.Orchestrate("anything")
+		assert.EqualError(t, err, `team "plain" has no coordinator`)
+	})
+}
@@ -0,0 +1,236 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adimarco/hive/llm"
+)
+
+// FailurePolicy decides how a parallel agent handles failing branches
+type FailurePolicy string
+
+const (
+	// BestEffort lets the other branches finish and fans in the successful
+	// results; the agent fails only if every branch fails
+	BestEffort FailurePolicy = "best_effort"
+	// FailFast cancels the remaining branches and fails as soon as one
+	// branch fails
+	FailFast FailurePolicy = "fail_fast"
+)
+
+// Aggregator combines the results of parallel branches into one answer
+type Aggregator func(ctx context.Context, msg string, results []BranchResult) (string, error)
+
+// BranchResult is the outcome of one branch of a parallel agent
+type BranchResult struct {
+	Agent    string
+	Output   string
+	Err      error
+	Duration time.Duration
+}
+
+// ParallelResult is the outcome of a parallel agent: the combined answer
+// and every branch's result, in the order the agents were added
+type ParallelResult struct {
+	Output   string
+	Branches []BranchResult
+}
+
+// Parallel is a workflow agent sending the same message to several agents
+// at once, then fanning their answers in to another agent or an Aggregator.
+// Without either, the answers are combined into one labelled response.
+type Parallel struct {
+	*Agent
+	branches    []*Agent
+	fanIn       *Agent
+	aggregate   Aggregator
+	concurrency int
+	timeout     time.Duration
+	policy      FailurePolicy
+}
+
+// NewParallel creates a parallel agent fanning out to agents
+func NewParallel(name string, agents ...*Agent) *Parallel {
+	p := &Parallel{
+		Agent:    New(name, "Sends the request to several agents at once and combines their answers"),
+		branches: agents,
+		policy:   BestEffort,
+	}
+	p.agentType = AgentTypeParallel
+	p.workflow = p
+	return p
+}
+
+// WithFanIn sends the request and every branch's answer to agent, whose
+// answer becomes the result
+func (p *Parallel) WithFanIn(agent *Agent) *Parallel {
+	p.fanIn = agent
+	p.aggregate = nil
+	return p
+}
+
+// WithAggregator combines the branch results with fn
+func (p *Parallel) WithAggregator(fn Aggregator) *Parallel {
+	p.aggregate = fn
+	p.fanIn = nil
+	return p
+}
+
+// WithMaxConcurrency limits how many branches run at once. Zero, the
+// default, runs every branch at once.
+func (p *Parallel) WithMaxConcurrency(n int) *Parallel {
+	p.concurrency = n
+	return p
+}
+
+// WithBranchTimeout bounds how long each branch may take. A branch that
+// takes longer fails with a timeout error.
+func (p *Parallel) WithBranchTimeout(timeout time.Duration) *Parallel {
+	p.timeout = timeout
+	return p
+}
+
+// WithFailurePolicy sets how failing branches are handled; the default is
+// BestEffort
+func (p *Parallel) WithFailurePolicy(policy FailurePolicy) *Parallel {
+	p.policy = policy
+	return p
+}
+
+// Execute runs every branch on msg and fans in their results. The result
+// holds each branch's output or error even when an error is returned.
+func (p *Parallel) Execute(ctx context.Context, msg string) (ParallelResult, error) {
+	return p.execute(ctx, p.llm, msg)
+}
+
+// send implements workflow
+func (p *Parallel) send(ctx context.Context, model llm.AugmentedLLM, msg string) (string, error) {
+	result, err := p.execute(ctx, model, msg)
+	return result.Output, err
+}
+
+// execute runs the branches, using model for agents without an LLM of
+// their own
+func (p *Parallel) execute(ctx context.Context, model llm.AugmentedLLM, msg string) (ParallelResult, error) {
+	var result ParallelResult
+	if len(p.branches) == 0 {
+		return result, fmt.Errorf("parallel %q has no branches", p.name)
+	}
+
+	result.Branches = p.fanOut(ctx, model, msg)
+
+	var errs []error
+	for _, branch := range result.Branches {
+		if branch.Err != nil {
+			errs = append(errs, fmt.Errorf("branch %q failed: %w", branch.Agent, branch.Err))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if len(errs) > 0 && (p.policy == FailFast || len(errs) == len(result.Branches)) {
+		return result, fmt.Errorf("parallel %q: %w", p.name, errors.Join(errs...))
+	}
+
+	output, err := p.fanInResults(ctx, model, msg, result.Branches)
+	if err != nil {
+		return result, fmt.Errorf("parallel %q: fan-in failed: %w", p.name, err)
+	}
+	result.Output = output
+	return result, nil
+}
+
+// fanOut runs the branches concurrently within the concurrency limit. With
+// FailFast, the first failure cancels the branches still running or
+// waiting, which then report the cancellation as their error.
+func (p *Parallel) fanOut(ctx context.Context, model llm.AugmentedLLM, msg string) []BranchResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := p.concurrency
+	if limit <= 0 {
+		limit = len(p.branches)
+	}
+	sem := make(chan struct{}, limit)
+
+	results := make([]BranchResult, len(p.branches))
+	var wg sync.WaitGroup
+	for i, agent := range p.branches {
+		wg.Add(1)
+		go func(i int, agent *Agent) {
+			defer wg.Done()
+			results[i] = BranchResult{Agent: agent.name}
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].Err = ctx.Err()
+				return
+			}
+
+			start := time.Now()
+			results[i].Output, results[i].Err = p.runBranch(ctx, agent, model, msg)
+			results[i].Duration = time.Since(start)
+			if results[i].Err != nil && p.policy == FailFast {
+				cancel()
+			}
+		}(i, agent)
+	}
+	wg.Wait()
+	return results
+}
+
+// runBranch sends msg to one branch, giving up when the branch times out
+// or ctx is done even if the agent does not return
+func (p *Parallel) runBranch(ctx context.Context, agent *Agent, model llm.AugmentedLLM, msg string) (string, error) {
+	branchCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		branchCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	type reply struct {
+		output string
+		err    error
+	}
+	done := make(chan reply, 1)
+	go func() {
+		output, err := sendTo(branchCtx, agent, model, msg)
+		done <- reply{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-branchCtx.Done():
+		if ctx.Err() == nil {
+			return "", fmt.Errorf("timed out after %s", p.timeout)
+		}
+		return "", ctx.Err()
+	}
+}
+
+// fanInResults combines the branch results into the parallel agent's answer
+func (p *Parallel) fanInResults(ctx context.Context, model llm.AugmentedLLM, msg string, branches []BranchResult) (string, error) {
+	if p.aggregate != nil {
+		return p.aggregate(ctx, msg, branches)
+	}
+
+	var b strings.Builder
+	writeRequest(&b, msg)
+	for _, branch := range branches {
+		writeResponse(&b, branch.Agent, branch.Output, branch.Err)
+	}
+
+	if p.fanIn == nil {
+		return b.String(), nil
+	}
+	return sendTo(ctx, p.fanIn, model, b.String())
+}
@@ -0,0 +1,171 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/llm"
+)
+
+// failingAgent returns an agent whose every request fails with err
+func failingAgent(name string, err error) *Agent {
+	return New(name, "Fail").WithLLM(llm.NewPassthroughLLM(name).WithTransformE(func(string) (string, error) {
+		return "", err
+	}))
+}
+
+// blockingAgent returns an agent that answers only once release is closed
+func blockingAgent(name string, release <-chan struct{}) *Agent {
+	return transformAgent(name, func(s string) string {
+		<-release
+		return s
+	})
+}
+
+func TestParallel(t *testing.T) {
+	ctx := context.Background()
+	upper := transformAgent("upper", strings.ToUpper)
+	reverse := transformAgent("reverse", func(s string) string {
+		runes := []rune(s)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	})
+
+	t.Run("combines branch answers", func(t *testing.T) {
+		parallel := NewParallel("both", upper, reverse)
+		assert.Equal(t, AgentTypeParallel, parallel.agentType)
+
+		result, err := parallel.Execute(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "<request>\nabc\n</request>\n\n"+
+			"<response agent=\"upper\">\nABC\n</response>\n\n"+
+			"<response agent=\"reverse\">\ncba\n</response>", result.Output)
+		require.Len(t, result.Branches, 2)
+		assert.Equal(t, "upper", result.Branches[0].Agent)
+		assert.Equal(t, "cba", result.Branches[1].Output)
+	})
+
+	t.Run("fan-in agent", func(t *testing.T) {
+		summarize := transformAgent("summarize", func(s string) string {
+			return "summary of " + s[strings.Index(s, "<response"):]
+		})
+		result, err := NewParallel("both", upper, reverse).WithFanIn(summarize).Execute(ctx, "abc")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result.Output, `summary of <response agent="upper">`))
+	})
+
+	t.Run("aggregator", func(t *testing.T) {
+		join := func(ctx context.Context, msg string, results []BranchResult) (string, error) {
+			outputs := make([]string, len(results))
+			for i, result := range results {
+				outputs[i] = result.Output
+			}
+			return strings.Join(outputs, "+"), nil
+		}
+		result, err := NewParallel("both", upper, reverse).WithAggregator(join).Execute(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "ABC+cba", result.Output)
+	})
+
+	t.Run("runs branches concurrently within the limit", func(t *testing.T) {
+		var running, peak int32
+		slow := func(name string) *Agent {
+			return transformAgent(name, func(s string) string {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return s
+			})
+		}
+
+		result, err := NewParallel("slow", slow("a"), slow("b"), slow("c"), slow("d")).
+			WithMaxConcurrency(2).
+			Execute(ctx, "x")
+		require.NoError(t, err)
+		assert.Len(t, result.Branches, 4)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		broken := failingAgent("broken", errors.New("overloaded"))
+		result, err := NewParallel("some", upper, broken).Execute(ctx, "abc")
+		require.NoError(t, err)
+		assert.Contains(t, result.Output, "<response agent=\"upper\">\nABC\n</response>")
+		assert.Contains(t, result.Output, "<error agent=\"broken\">")
+		assert.ErrorContains(t, result.Branches[1].Err, "overloaded")
+
+		// Fails only when every branch fails
+		_, err = NewParallel("none", broken).Execute(ctx, "abc")
+		assert.ErrorContains(t, err, `parallel "none": branch "broken" failed`)
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		start := time.Now()
+		result, err := NewParallel("strict", blockingAgent("stuck", release), failingAgent("broken", errors.New("overloaded"))).
+			WithFailurePolicy(FailFast).
+			Execute(ctx, "abc")
+		assert.ErrorContains(t, err, `branch "broken" failed`)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.ErrorIs(t, result.Branches[0].Err, context.Canceled)
+		assert.Empty(t, result.Output)
+	})
+
+	t.Run("branch timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		result, err := NewParallel("bounded", upper, blockingAgent("stuck", release)).
+			WithBranchTimeout(20*time.Millisecond).
+			Execute(ctx, "abc")
+		require.NoError(t, err)
+		assert.EqualError(t, result.Branches[1].Err, "timed out after 20ms")
+		assert.Contains(t, result.Output, "ABC")
+	})
+
+	t.Run("runs like an agent", func(t *testing.T) {
+		team := TeamWithLLM("team", llm.NewPassthroughLLM("team"), NewParallel("both", upper, New("plain", "Repeat")).Agent)
+		defer team.Close()
+
+		reply, err := team.Send("both", "abc")
+		require.NoError(t, err)
+		assert.Contains(t, reply, "<response agent=\"plain\">\nabc\n</response>")
+	})
+}
+
+func TestTask_Run(t *testing.T) {
+	// The first assignment is answered only once the second arrives, which
+	// happens only if assignments run concurrently
+	second := make(chan struct{})
+	model := llm.NewPassthroughLLM("team").WithTransform(func(s string) string {
+		if s == "one" {
+			<-second
+		} else {
+			close(second)
+		}
+		return strings.ToUpper(s)
+	})
+	team := TeamWithLLM("team", model, New("first", "Answer"), New("second", "Answer"))
+	defer team.Close()
+
+	responses, err := NewTask("q").AssignTo("first", "one").AssignTo("second", "two").Run(team)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"first": "ONE", "second": "TWO"}, responses)
+}
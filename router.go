@@ -0,0 +1,359 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/logging"
+	"github.com/adimarco/hive/tools"
+)
+
+// routeToolName is the tool the router's LLM calls to report its choice
+var routeToolName = tools.QualifiedName("router", "select_agents")
+
+// RoutingMethod records how a router chose its targets
+type RoutingMethod string
+
+const (
+	// RoutedByLLM means the LLM chose the targets
+	RoutedByLLM RoutingMethod = "llm"
+	// RoutedByRule means a keyword or pattern rule chose the targets
+	RoutedByRule RoutingMethod = "rule"
+	// RoutedByDefault means nothing else matched and the default agent was used
+	RoutedByDefault RoutingMethod = "default"
+)
+
+// RoutingDecision is the agents a router chose for a message and why
+type RoutingDecision struct {
+	Agents     []string
+	Confidence float64 // From 0 to 1; rules and the default are certain
+	Reason     string
+	Method     RoutingMethod
+}
+
+// RouteResult is the outcome of routing a message: the decision and each
+// chosen agent's answer
+type RouteResult struct {
+	Decision  RoutingDecision
+	Responses []StepResult
+	Output    string
+}
+
+// routeArgs are the arguments of the routing tool
+type routeArgs struct {
+	Agents     []string `json:"agents" jsonschema:"description=Names of the agents to handle the request\\, best first"`
+	Confidence float64  `json:"confidence" jsonschema:"description=How sure you are of the choice,minimum=0,maximum=1"`
+	Reason     string   `json:"reason,omitempty" jsonschema:"description=Why these agents were chosen"`
+}
+
+// route is an agent a router can choose and the rules that choose it
+type route struct {
+	agent       *Agent
+	description string
+	keywords    []string
+	patterns    []*regexp.Regexp
+}
+
+// Router is a workflow agent forwarding each message to the agents best
+// suited to it. Its LLM chooses among the routes by calling a tool; when
+// that fails or is unsure, keyword and pattern rules decide, and then the
+// default agent, if any.
+type Router struct {
+	*Agent
+	routes        []*route
+	fallback      *Agent
+	maxTargets    int
+	minConfidence float64
+	logger        logging.Logger
+}
+
+// NewRouter creates a router with no routes
+func NewRouter(name string) *Router {
+	r := &Router{
+		Agent:      New(name, "You route requests to the agents best suited to handle them."),
+		maxTargets: 1,
+		logger:     logging.GetLogger("router"),
+	}
+	r.agentType = AgentTypeRouter
+	r.workflow = r
+	return r
+}
+
+// WithRoute adds an agent the router can choose, described for the LLM
+func (r *Router) WithRoute(agent *Agent, description string) *Router {
+	r.routes = append(r.routes, &route{agent: agent, description: description})
+	return r
+}
+
+// WithKeywords routes messages containing any of the keywords, ignoring
+// case, to the named route's agent when the LLM cannot decide
+func (r *Router) WithKeywords(agentName string, keywords ...string) *Router {
+	if rt := r.route(agentName); rt != nil {
+		for _, keyword := range keywords {
+			rt.keywords = append(rt.keywords, strings.ToLower(keyword))
+		}
+	}
+	return r
+}
+
+// WithPatterns routes messages matching any of the patterns to the named
+// route's agent when the LLM cannot decide
+func (r *Router) WithPatterns(agentName string, patterns ...*regexp.Regexp) *Router {
+	if rt := r.route(agentName); rt != nil {
+		rt.patterns = append(rt.patterns, patterns...)
+	}
+	return r
+}
+
+// WithDefault sets the agent used when neither the LLM nor a rule chooses
+// one
+func (r *Router) WithDefault(agent *Agent) *Router {
+	r.fallback = agent
+	return r
+}
+
+// WithMaxTargets lets the LLM choose up to n agents, each of which answers
+// the message. The default is 1.
+func (r *Router) WithMaxTargets(n int) *Router {
+	r.maxTargets = n
+	return r
+}
+
+// WithMinConfidence sets the confidence below which the LLM's choice is
+// ignored in favor of the rules
+func (r *Router) WithMinConfidence(confidence float64) *Router {
+	r.minConfidence = confidence
+	return r
+}
+
+// Execute routes msg and forwards it to the chosen agents
+func (r *Router) Execute(ctx context.Context, msg string) (RouteResult, error) {
+	return r.execute(ctx, r.llm, msg)
+}
+
+// Route decides which agents should handle msg without forwarding it
+func (r *Router) Route(ctx context.Context, msg string) (RoutingDecision, error) {
+	return r.decide(ctx, r.llm, msg)
+}
+
+// send implements workflow
+func (r *Router) send(ctx context.Context, model llm.AugmentedLLM, msg string) (string, error) {
+	result, err := r.execute(ctx, model, msg)
+	return result.Output, err
+}
+
+// execute routes msg using model for the decision and for agents without
+// an LLM of their own
+func (r *Router) execute(ctx context.Context, model llm.AugmentedLLM, msg string) (RouteResult, error) {
+	decision, err := r.decide(ctx, model, msg)
+	if err != nil {
+		return RouteResult{}, err
+	}
+	result := RouteResult{Decision: decision}
+
+	var b strings.Builder
+	for _, name := range decision.Agents {
+		agent := r.agent(name)
+		output, err := sendTo(ctx, agent, model, msg)
+		if err != nil {
+			return result, fmt.Errorf("router %q: agent %q failed: %w", r.name, name, err)
+		}
+		result.Responses = append(result.Responses, StepResult{Agent: name, Input: msg, Output: output})
+		writeResponse(&b, name, output, nil)
+	}
+
+	if len(result.Responses) == 1 {
+		result.Output = result.Responses[0].Output
+	} else {
+		result.Output = strings.TrimPrefix(b.String(), "\n\n")
+	}
+	return result, nil
+}
+
+// decide chooses the agents for msg: the LLM's choice if it makes a
+// confident one, otherwise the first matching rule, otherwise the default
+func (r *Router) decide(ctx context.Context, model llm.AugmentedLLM, msg string) (RoutingDecision, error) {
+	if len(r.routes) == 0 && r.fallback == nil {
+		return RoutingDecision{}, fmt.Errorf("router %q has no routes", r.name)
+	}
+
+	decision, err := r.decideWithLLM(ctx, model, msg)
+	switch {
+	case err != nil:
+		r.logger.Warning(ctx, "LLM routing failed", logging.WithData(map[string]interface{}{
+			"router": r.name,
+			"error":  err.Error(),
+		}))
+	case decision.Confidence < r.minConfidence:
+		r.logger.Info(ctx, "LLM routing not confident enough", logging.WithData(map[string]interface{}{
+			"router":     r.name,
+			"agents":     decision.Agents,
+			"confidence": decision.Confidence,
+		}))
+	default:
+		r.logDecision(ctx, decision)
+		return decision, nil
+	}
+
+	if decision, ok := r.decideWithRules(msg); ok {
+		r.logDecision(ctx, decision)
+		return decision, nil
+	}
+	if r.fallback != nil {
+		decision := RoutingDecision{Agents: []string{r.fallback.name}, Confidence: 1, Method: RoutedByDefault}
+		r.logDecision(ctx, decision)
+		return decision, nil
+	}
+	if err != nil {
+		return RoutingDecision{}, fmt.Errorf("router %q: no agent chosen: %w", r.name, err)
+	}
+	return RoutingDecision{}, fmt.Errorf("router %q: no agent chosen", r.name)
+}
+
+// decideWithLLM asks the LLM to choose among the routes by calling the
+// routing tool
+func (r *Router) decideWithLLM(ctx context.Context, model llm.AugmentedLLM, msg string) (RoutingDecision, error) {
+	if model == nil {
+		return RoutingDecision{}, fmt.Errorf("no LLM")
+	}
+	if len(r.routes) == 0 {
+		return RoutingDecision{}, fmt.Errorf("no routes")
+	}
+	if err := registerRouteTool(model.Tools()); err != nil {
+		return RoutingDecision{}, err
+	}
+
+	ra, err := r.Agent.runWith(ctx, model)
+	if err != nil {
+		return RoutingDecision{}, err
+	}
+	params := ra.buildRequestParams()
+	params.Tools = []string{routeToolName}
+	params.ExcludeTools = nil
+	params.UseHistory = false
+
+	response, err := model.Generate(ctx, llm.Message{Type: llm.MessageTypeUser, Content: r.routingPrompt(msg)}, params)
+	if err != nil {
+		return RoutingDecision{}, err
+	}
+	r.recordUsage(ctx, response)
+
+	for _, call := range response.ToolCalls {
+		if call.Name != routeToolName || call.IsError {
+			continue
+		}
+		var args routeArgs
+		data, _ := json.Marshal(call.Args)
+		if err := json.Unmarshal(data, &args); err != nil {
+			return RoutingDecision{}, fmt.Errorf("invalid routing arguments: %w", err)
+		}
+
+		decision := RoutingDecision{Confidence: args.Confidence, Reason: args.Reason, Method: RoutedByLLM}
+		for _, name := range args.Agents {
+			if r.route(name) != nil && !contains(decision.Agents, name) && len(decision.Agents) < max(r.maxTargets, 1) {
+				decision.Agents = append(decision.Agents, name)
+			}
+		}
+		if len(decision.Agents) == 0 {
+			return RoutingDecision{}, fmt.Errorf("LLM chose no known agent: %v", args.Agents)
+		}
+		return decision, nil
+	}
+	return RoutingDecision{}, fmt.Errorf("LLM did not call %s", routeToolName)
+}
+
+// routingPrompt asks the LLM to choose agents for msg
+func (r *Router) routingPrompt(msg string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Choose the agents best suited to handle the request below, at most %d, and report your choice by calling %s.\n\nAgents:\n",
+		max(r.maxTargets, 1), routeToolName)
+	for _, rt := range r.routes {
+		fmt.Fprintf(&b, "- %s: %s\n", rt.agent.name, rt.description)
+	}
+	b.WriteString("\n")
+	writeRequest(&b, msg)
+	return b.String()
+}
+
+// decideWithRules returns the first route whose keywords or patterns match
+// msg
+func (r *Router) decideWithRules(msg string) (RoutingDecision, bool) {
+	lower := strings.ToLower(msg)
+	for _, rt := range r.routes {
+		for _, keyword := range rt.keywords {
+			if strings.Contains(lower, keyword) {
+				return RoutingDecision{
+					Agents:     []string{rt.agent.name},
+					Confidence: 1,
+					Reason:     fmt.Sprintf("matched keyword %q", keyword),
+					Method:     RoutedByRule,
+				}, true
+			}
+		}
+		for _, pattern := range rt.patterns {
+			if pattern.MatchString(msg) {
+				return RoutingDecision{
+					Agents:     []string{rt.agent.name},
+					Confidence: 1,
+					Reason:     fmt.Sprintf("matched pattern %q", pattern),
+					Method:     RoutedByRule,
+				}, true
+			}
+		}
+	}
+	return RoutingDecision{}, false
+}
+
+// logDecision logs the agents a message is routed to
+func (r *Router) logDecision(ctx context.Context, decision RoutingDecision) {
+	r.logger.Info(ctx, "Routed message", logging.WithData(map[string]interface{}{
+		"router":     r.name,
+		"agents":     decision.Agents,
+		"confidence": decision.Confidence,
+		"reason":     decision.Reason,
+		"method":     decision.Method,
+	}))
+}
+
+// route returns the route of the named agent
+func (r *Router) route(name string) *route {
+	for _, rt := range r.routes {
+		if rt.agent.name == name {
+			return rt
+		}
+	}
+	return nil
+}
+
+// agent returns the named route agent or the default agent
+func (r *Router) agent(name string) *Agent {
+	if rt := r.route(name); rt != nil {
+		return rt.agent
+	}
+	return r.fallback
+}
+
+// registerRouteTool registers the routing tool in registry. The tool only
+// acknowledges the choice; the router reads it from the tool call.
+func registerRouteTool(registry tools.ToolRegistry) error {
+	tool, err := tools.NewFunctionTool(routeToolName, "Report which agents should handle the request",
+		func(args routeArgs) string { return "Routing recorded." })
+	if err != nil {
+		return err
+	}
+	return registerWorkflowTool(registry, tool)
+}
+
+// contains reports whether names includes name
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
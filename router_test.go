@@ -0,0 +1,133 @@
+package hive
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/llm"
+)
+
+// routingLLM returns a PlaybackLLM whose model routes with the given
+// arguments for the routing tool
+func routingLLM(args map[string]any) *llm.PlaybackLLM {
+	return llm.NewPlaybackLLM("router", []llm.Message{
+		{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{{ID: "route-1", Name: routeToolName, Args: args}}},
+		{Type: llm.MessageTypeAssistant, Content: "Routed."},
+	})
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	billing := transformAgent("billing", func(s string) string { return "billing: " + s })
+	support := transformAgent("support", func(s string) string { return "support: " + s })
+	general := transformAgent("general", func(s string) string { return "general: " + s })
+
+	newRouter := func(model llm.AugmentedLLM) *Router {
+		router := NewRouter("triage").
+			WithRoute(billing, "Invoices, payments and refunds").
+			WithRoute(support, "Technical problems").
+			WithKeywords("billing", "invoice").
+			WithPatterns("support", regexp.MustCompile(`(?i)\berror \d+`))
+		router.WithLLM(model)
+		return router
+	}
+
+	t.Run("llm chooses the agent", func(t *testing.T) {
+		router := newRouter(routingLLM(map[string]any{"agents": []string{"billing"}, "confidence": 0.9, "reason": "about a refund"}))
+		assert.Equal(t, AgentTypeRouter, router.agentType)
+
+		result, err := router.Execute(ctx, "Can I get a refund?")
+		require.NoError(t, err)
+		assert.Equal(t, RoutingDecision{
+			Agents:     []string{"billing"},
+			Confidence: 0.9,
+			Reason:     "about a refund",
+			Method:     RoutedByLLM,
+		}, result.Decision)
+		assert.Equal(t, "billing: Can I get a refund?", result.Output)
+		assert.Equal(t, []StepResult{{Agent: "billing", Input: "Can I get a refund?", Output: "billing: Can I get a refund?"}}, result.Responses)
+	})
+
+	t.Run("multiple targets", func(t *testing.T) {
+		router := newRouter(routingLLM(map[string]any{"agents": []string{"billing", "unknown", "support", "billing"}, "confidence": 0.7})).
+			WithMaxTargets(2)
+
+		result, err := router.Execute(ctx, "Charged twice after error 500")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"billing", "support"}, result.Decision.Agents)
+		assert.Equal(t, "<response agent=\"billing\">\nbilling: Charged twice after error 500\n</response>\n\n"+
+			"<response agent=\"support\">\nsupport: Charged twice after error 500\n</response>", result.Output)
+	})
+
+	t.Run("rules decide when the llm is unsure", func(t *testing.T) {
+		router := newRouter(routingLLM(map[string]any{"agents": []string{"support"}, "confidence": 0.3})).
+			WithMinConfidence(0.5)
+
+		decision, err := router.Route(ctx, "Where is my INVOICE?")
+		require.NoError(t, err)
+		assert.Equal(t, RoutingDecision{
+			Agents:     []string{"billing"},
+			Confidence: 1,
+			Reason:     `matched keyword "invoice"`,
+			Method:     RoutedByRule,
+		}, decision)
+	})
+
+	t.Run("rules decide when the llm fails", func(t *testing.T) {
+		// An empty script fails every request
+		router := newRouter(llm.NewPlaybackLLM("router", nil))
+
+		decision, err := router.Route(ctx, "I see Error 404 on login")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"support"}, decision.Agents)
+		assert.Equal(t, RoutedByRule, decision.Method)
+
+		// The llm chose no known agent
+		router = newRouter(routingLLM(map[string]any{"agents": []string{"sales"}, "confidence": 1}))
+		decision, err = router.Route(ctx, "invoice")
+		require.NoError(t, err)
+		assert.Equal(t, RoutedByRule, decision.Method)
+	})
+
+	t.Run("default agent", func(t *testing.T) {
+		router := newRouter(llm.NewPlaybackLLM("router", nil))
+		_, err := router.Route(ctx, "hello")
+		assert.ErrorContains(t, err, `router "triage": no agent chosen`)
+
+		router.WithDefault(general)
+		result, err := router.Execute(ctx, "hello")
+		require.NoError(t, err)
+		assert.Equal(t, RoutedByDefault, result.Decision.Method)
+		assert.Equal(t, "general: hello", result.Output)
+	})
+
+	t.Run("routing tool stays internal", func(t *testing.T) {
+		model := routingLLM(map[string]any{"agents": []string{"billing"}, "confidence": 0.9})
+		_, err := newRouter(model).Route(ctx, "refund")
+		require.NoError(t, err)
+
+		tool, err := model.Tools().Get(routeToolName)
+		require.NoError(t, err)
+		assert.True(t, tool.IsInternal())
+
+		// Apps give new agents every registered tool except internal ones
+		app := &App{llm: model, agents: make(map[string]*Agent), usage: llm.NewUsageTracker("app")}
+		require.NoError(t, app.Tool("lookup", func() string { return "found" }))
+		agent := app.Agent("Help")
+		assert.Equal(t, []string{"lookup"}, agent.params.Tools)
+	})
+
+	t.Run("runs like an agent", func(t *testing.T) {
+		router := newRouter(routingLLM(map[string]any{"agents": []string{"support"}, "confidence": 0.8}))
+		ra, err := router.Run(ctx)
+		require.NoError(t, err)
+
+		reply, err := ra.Send("It crashes")
+		require.NoError(t, err)
+		assert.Equal(t, "support: It crashes", reply)
+	})
+}
@@ -1,6 +1,10 @@
 package hive
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
 
 // Task represents a research task with assignments
 type Task struct {
@@ -22,15 +26,34 @@ func (t *Task) AssignTo(member, question string) *Task {
 	return t
 }
 
-// Run executes the task with the given team
+// Run executes the task with the given team, sending the assignments to
+// their members concurrently
 func (t *Task) Run(team *Team) (map[string]string, error) {
-	responses := make(map[string]string)
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		responses = make(map[string]string)
+		errs      []error
+	)
 	for member, question := range t.assignments {
-		response, err := team.Send(member, question)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get response from %s: %w", member, err)
-		}
-		responses[member] = response
+		wg.Add(1)
+		go func(member, question string) {
+			defer wg.Done()
+			response, err := team.Send(member, question)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get response from %s: %w", member, err))
+				return
+			}
+			responses[member] = response
+		}(member, question)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 	return responses, nil
 }
@@ -3,7 +3,9 @@ package hive
 import (
 	"context"
 	"fmt"
+	"sort"
 
+	"github.com/adimarco/hive/config"
 	"github.com/adimarco/hive/llm"
 )
 
@@ -33,17 +35,20 @@ type Team struct {
 	name   string
 	llm    llm.AugmentedLLM
 	agents map[string]*Agent
+	usage  *llm.UsageTracker
 	ctx    context.Context
 	cancel context.CancelFunc
+	err    error // Invalid configuration, reported when the team is used
 }
 
 // TeamWithLLM creates a new Team with the given LLM and agents
-func TeamWithLLM(name string, llm llm.AugmentedLLM, agents ...*Agent) *Team {
+func TeamWithLLM(name string, model llm.AugmentedLLM, agents ...*Agent) *Team {
 	ctx, cancel := context.WithCancel(context.Background())
 	team := &Team{
 		name:   name,
-		llm:    llm,
+		llm:    model,
 		agents: make(map[string]*Agent),
+		usage:  llm.NewUsageTracker(name),
 		ctx:    ctx,
 		cancel: cancel,
 	}
@@ -57,19 +62,74 @@ func TeamWithLLM(name string, llm llm.AugmentedLLM, agents ...*Agent) *Team {
 
 // Send sends a message to a specific agent and returns its response
 func (t *Team) Send(agentName, message string) (string, error) {
+	if t.err != nil {
+		return "", t.err
+	}
 	agent, ok := t.agents[agentName]
 	if !ok {
 		return "", fmt.Errorf("agent %q not found", agentName)
 	}
 
-	params := &llm.RequestParams{
-		Model:      agent.model,
-		UseHistory: agent.useHistory,
-		Tools:      agent.params.Tools,
-		Config:     agent.params.Config,
+	// Workflow members, such as chains, run their own agents
+	if agent.workflow != nil {
+		return agent.workflow.send(t.ctx, t.llm, message)
+	}
+
+	// Each member keeps its own history on the shared LLM, and its other
+	// settings, such as tools and approval, apply as when it runs alone
+	ra, err := agent.runWith(t.ctx, t.llm)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := t.llm.Generate(t.ctx, llm.Message{
+		Type:    llm.MessageTypeUser,
+		Content: message,
+	}, ra.buildRequestParams())
+	if err != nil {
+		return "", err
+	}
+
+	// Usage counts towards both the team and the agent that answered
+	if response.Usage != nil {
+		t.usage.Record(t.ctx, *response.Usage)
 	}
+	agent.recordUsage(t.ctx, response)
 
-	return t.llm.GenerateString(t.ctx, message, params)
+	return response.Content, nil
+}
+
+// Orchestrate has the team's coordinator work towards goal, returning its
+// plan, each step's result and the final answer
+func (t *Team) Orchestrate(goal string) (OrchestratorResult, error) {
+	if t.err != nil {
+		return OrchestratorResult{}, t.err
+	}
+	names := make([]string, 0, len(t.agents))
+	for name := range t.agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if orchestrator, ok := t.agents[name].workflow.(*Orchestrator); ok {
+			return orchestrator.execute(t.ctx, t.llm, goal)
+		}
+	}
+	return OrchestratorResult{}, fmt.Errorf("team %q has no coordinator", t.name)
+}
+
+// Usage returns the tokens and cost spent by the team so far
+func (t *Team) Usage() llm.Usage {
+	return t.usage.Total()
+}
+
+// UsageByAgent returns the usage of each team member
+func (t *Team) UsageByAgent() map[string]llm.Usage {
+	usage := make(map[string]llm.Usage, len(t.agents))
+	for name, agent := range t.agents {
+		usage[name] = agent.Usage()
+	}
+	return usage
 }
 
 // Close cleans up team resources
@@ -82,8 +142,9 @@ func (t *Team) Close() {
 // TeamBuilder provides a fluent interface for building teams
 type TeamBuilder struct {
 	name        string
-	coordinator *Agent
+	coordinator string // Instruction of the coordinator, if any
 	specialists []*Agent
+	memory      config.MemorySettings // Memory strategy of every member
 }
 
 // NewTeam creates a new TeamBuilder
@@ -94,9 +155,11 @@ func NewTeam(name string) *TeamBuilder {
 	}
 }
 
-// WithCoordinator adds a coordinator to the team
+// WithCoordinator adds a coordinator to the team: an Orchestrator named
+// "Coordinator" that plans work towards a goal and delegates it to the
+// specialists, following instruction
 func (b *TeamBuilder) WithCoordinator(instruction string) *TeamBuilder {
-	b.coordinator = New("Coordinator", instruction).WithHistory()
+	b.coordinator = instruction
 	return b
 }
 
@@ -118,14 +181,34 @@ func (b *TeamBuilder) WithSpecialist(name, instruction string) *TeamBuilder {
 	return b
 }
 
+// WithMemory makes every member of the team use the memory strategy in
+// settings, summarizing with the team's LLM. Invalid settings are reported
+// by the built team's Send, Orchestrate and Chat.
+func (b *TeamBuilder) WithMemory(settings config.MemorySettings) *TeamBuilder {
+	b.memory = settings
+	return b
+}
+
 // Build creates the Team
 func (b *TeamBuilder) Build(llm llm.AugmentedLLM) *Team {
 	agents := make([]*Agent, 0, len(b.specialists)+1)
-	if b.coordinator != nil {
-		agents = append(agents, b.coordinator)
+	if b.coordinator != "" {
+		coordinator := NewOrchestrator("Coordinator", b.specialists...)
+		coordinator.instruction = b.coordinator
+		coordinator.WithHistory()
+		agents = append(agents, coordinator.Agent)
 	}
 	agents = append(agents, b.specialists...)
-	return TeamWithLLM(b.name, llm, agents...)
+	team := TeamWithLLM(b.name, llm, agents...)
+	if b.memory.Strategy != "" {
+		for _, agent := range agents {
+			if err := agent.WithMemorySettings(b.memory, llm); err != nil {
+				team.err = fmt.Errorf("team %q: %w", b.name, err)
+				break
+			}
+		}
+	}
+	return team
 }
 
 // ArchetypeBuilder provides a fluent interface for building archetypes
@@ -159,6 +242,9 @@ func (b *ArchetypeBuilder) Register() {
 
 // Chat starts an interactive chat session with the specified agent
 func (t *Team) Chat(agentName string) error {
+	if t.err != nil {
+		return t.err
+	}
 	agent, ok := t.agents[agentName]
 	if !ok {
 		return fmt.Errorf("agent %q not found", agentName)
@@ -0,0 +1,220 @@
+package hive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adimarco/hive/config"
+	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/tools"
+)
+
+func TestTeam_Usage(t *testing.T) {
+	turn := func(content string, cost float64) llm.Message {
+		return llm.Message{
+			Type:    llm.MessageTypeAssistant,
+			Content: content,
+			Usage:   &llm.Usage{Model: "claude-3-haiku-20240307", Requests: 1, InputTokens: 10, OutputTokens: 5, Cost: cost},
+		}
+	}
+	playback := llm.NewPlaybackLLM("playback", []llm.Message{
+		turn("research done", 0.25),
+		turn("summary done", 0.5),
+	})
+
+	team := NewTeam("research").
+		WithSpecialist("researcher", "Research things").
+		WithSpecialist("writer", "Write things").
+		Build(playback)
+	defer team.Close()
+
+	resp, err := team.Send("researcher", "look into it")
+	require.NoError(t, err)
+	assert.Equal(t, "research done", resp)
+	_, err = team.Send("writer", "summarize")
+	require.NoError(t, err)
+
+	total := team.Usage()
+	assert.Equal(t, 2, total.Requests)
+	assert.Equal(t, 0.75, total.Cost)
+
+	byAgent := team.UsageByAgent()
+	assert.Equal(t, 0.25, byAgent["researcher"].Cost)
+	assert.Equal(t, 0.5, byAgent["writer"].Cost)
+}
+
+func TestApp_Usage(t *testing.T) {
+	playback := llm.NewPlaybackLLM("playback", []llm.Message{{
+		Type:    llm.MessageTypeAssistant,
+		Content: "hello",
+		Usage:   &llm.Usage{Model: "claude-3-haiku-20240307", Requests: 1, InputTokens: 10, OutputTokens: 5, Cost: 0.1},
+	}})
+	app := &App{
+		llm:    playback,
+		agents: make(map[string]*Agent),
+		usage:  llm.NewUsageTracker("app"),
+	}
+
+	agent := app.Agent("Say hello")
+	ra, err := agent.Run(context.Background())
+	require.NoError(t, err)
+	_, err = ra.Send("hi")
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.1, agent.Usage().Cost)
+	assert.Equal(t, 0.1, app.Usage().Cost)
+	assert.Equal(t, 1, app.UsageByModel()["claude-3-haiku-20240307"].Requests)
+}
+
+func TestTeam_Memory(t *testing.T) {
+	echo := llm.NewPassthroughLLM("echo")
+
+	team := NewTeam("research").
+		WithSpecialist("researcher", "Research things").
+		WithSpecialist("writer", "Write things").
+		Build(echo)
+	defer team.Close()
+	for _, agent := range team.agents {
+		agent.WithHistory()
+	}
+
+	_, err := team.Send("researcher", "look into it")
+	require.NoError(t, err)
+	_, err = team.Send("writer", "summarize")
+	require.NoError(t, err)
+	_, err = team.Send("researcher", "dig deeper")
+	require.NoError(t, err)
+
+	history := func(name string) []string {
+		messages, err := team.agents[name].Memory().Get(true)
+		require.NoError(t, err)
+		contents := make([]string, len(messages))
+		for i, msg := range messages {
+			contents[i] = msg.Content
+		}
+		return contents
+	}
+	assert.Equal(t, []string{"look into it", "look into it", "dig deeper", "dig deeper"}, history("researcher"))
+	assert.Equal(t, []string{"summarize", "summarize"}, history("writer"))
+
+	// Nothing leaks into the shared LLM's own memory
+	shared, err := echo.Memory().Get(true)
+	require.NoError(t, err)
+	assert.Empty(t, shared)
+}
+
+func TestTeam_MemorySettings(t *testing.T) {
+	team := NewTeam("research").
+		WithCoordinator("Plan the research").
+		WithSpecialist("researcher", "Research things").
+		WithMemory(config.MemorySettings{Strategy: "token_window", MaxTokens: 1000}).
+		Build(llm.NewPassthroughLLM("echo"))
+	defer team.Close()
+
+	require.Len(t, team.agents, 2)
+	for name, agent := range team.agents {
+		assert.IsType(t, &llm.TokenWindowMemory{}, agent.Memory(), name)
+	}
+
+	// Invalid settings are reported rather than leaving memory unbounded
+	invalid := NewTeam("research").
+		WithCoordinator("Plan the research").
+		WithSpecialist("researcher", "Research things").
+		WithMemory(config.MemorySettings{Strategy: "token_window"}).
+		Build(llm.NewPassthroughLLM("echo"))
+	defer invalid.Close()
+
+	_, err := invalid.Send("researcher", "look into it")
+	assert.ErrorContains(t, err, `team "research": invalid memory settings for agent`)
+	assert.ErrorContains(t, err, "max tokens must be greater than 0")
+	_, err = invalid.Orchestrate("research it")
+	assert.ErrorContains(t, err, "max tokens must be greater than 0")
+}
+
+func TestTeam_ToolApproval(t *testing.T) {
+	playback := llm.NewPlaybackLLM("playback", []llm.Message{
+		{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "delete", Args: map[string]any{"input": "notes.txt"}}}},
+		{Type: llm.MessageTypeAssistant, Content: "could not delete"},
+	})
+	deleted := 0
+	tool, err := tools.NewFunctionTool("delete", "Delete a file", func(s string) string {
+		deleted++
+		return "deleted " + s
+	})
+	require.NoError(t, err)
+	tool.Tags = []string{tools.TagRequiresApproval}
+	require.NoError(t, playback.Tools().Register(tool))
+
+	cleaner := New("cleaner", "Clean up files").
+		WithTools("delete").
+		WithApproval(tools.NewApprovalPolicy(tools.AutoDeny("not allowed")))
+	team := TeamWithLLM("ops", playback, cleaner)
+	defer team.Close()
+
+	resp, err := team.Send("cleaner", "delete notes")
+	require.NoError(t, err)
+	assert.Equal(t, "could not delete", resp)
+	assert.Zero(t, deleted)
+}
+
+func TestTeam_WithoutTools(t *testing.T) {
+	playback := llm.NewPlaybackLLM("playback", []llm.Message{
+		{Type: llm.MessageTypeAssistant, ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "fs.delete", Args: map[string]any{"input": "notes.txt"}}}},
+		{Type: llm.MessageTypeAssistant, Content: "could not delete"},
+	})
+	deleted := 0
+	tool, err := tools.NewFunctionTool("fs.delete", "Delete a file", func(s string) string {
+		deleted++
+		return "deleted " + s
+	})
+	require.NoError(t, err)
+	require.NoError(t, playback.Tools().Register(tool))
+
+	reader := New("reader", "Read files").WithTools("fs").WithoutTools("fs.delete")
+	team := TeamWithLLM("ops", playback, reader)
+	defer team.Close()
+
+	resp, err := team.Send("reader", "delete notes")
+	require.NoError(t, err)
+	assert.Equal(t, "could not delete", resp)
+	assert.Zero(t, deleted)
+}
+
+func TestApp_ToolProvider(t *testing.T) {
+	var cleaned []string
+	app := &App{llm: llm.NewPassthroughLLM("echo"), agents: make(map[string]*Agent)}
+
+	provider := &stubProvider{
+		tools: []tools.Tool{tools.New("search").
+			WithHandler(func(ctx context.Context, args map[string]any) (string, error) {
+				return "found", nil
+			}).
+			Build()},
+		cleanup: func() { cleaned = append(cleaned, "docs") },
+	}
+	require.NoError(t, app.ToolProvider("docs", provider))
+
+	result, err := app.llm.Tools().Call(context.Background(), "docs.search", map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, "found", result.Content)
+
+	require.NoError(t, app.Close())
+	assert.Equal(t, []string{"docs"}, cleaned)
+	assert.Empty(t, app.llm.Tools().List())
+}
+
+// stubProvider is a tool provider calling cleanup when it is closed
+type stubProvider struct {
+	tools   []tools.Tool
+	cleanup func()
+}
+
+func (p *stubProvider) GetTools() []tools.Tool               { return p.tools }
+func (p *stubProvider) Initialize(ctx context.Context) error { return nil }
+func (p *stubProvider) Cleanup(ctx context.Context) error {
+	p.cleanup()
+	return nil
+}
@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// TagRequiresApproval marks tools whose calls must be approved before they
+// run
+const TagRequiresApproval = "requires-approval"
+
+// ApprovalRequest describes a tool call awaiting approval
+type ApprovalRequest struct {
+	Tool Tool
+	Args map[string]any
+}
+
+// Decision is the answer to an approval request
+type Decision struct {
+	Approved bool
+	Reason   string // Why the call was denied, shown to the model
+}
+
+// ApprovalHandler decides whether a tool call may run
+type ApprovalHandler interface {
+	Approve(ctx context.Context, req ApprovalRequest) (Decision, error)
+}
+
+// ApprovalFunc adapts a function to an ApprovalHandler
+type ApprovalFunc func(ctx context.Context, req ApprovalRequest) (Decision, error)
+
+// Approve implements ApprovalHandler
+func (f ApprovalFunc) Approve(ctx context.Context, req ApprovalRequest) (Decision, error) {
+	return f(ctx, req)
+}
+
+// AutoApprove returns a handler approving every call
+func AutoApprove() ApprovalHandler {
+	return ApprovalFunc(func(ctx context.Context, req ApprovalRequest) (Decision, error) {
+		return Decision{Approved: true}, nil
+	})
+}
+
+// AutoDeny returns a handler denying every call with reason
+func AutoDeny(reason string) ApprovalHandler {
+	return ApprovalFunc(func(ctx context.Context, req ApprovalRequest) (Decision, error) {
+		return Decision{Reason: reason}, nil
+	})
+}
+
+// ApprovalPolicy selects the tool calls that need approval and asks its
+// handler about them. Tools tagged TagRequiresApproval always need
+// approval; WithTags and WithTools add more.
+type ApprovalPolicy struct {
+	handler  ApprovalHandler
+	tags     []string
+	patterns []string
+}
+
+// NewApprovalPolicy creates a policy asking handler to approve calls
+func NewApprovalPolicy(handler ApprovalHandler) *ApprovalPolicy {
+	return &ApprovalPolicy{
+		handler: handler,
+		tags:    []string{TagRequiresApproval},
+	}
+}
+
+// WithTags requires approval for tools with any of the given tags
+func (p *ApprovalPolicy) WithTags(tags ...string) *ApprovalPolicy {
+	p.tags = append(p.tags, tags...)
+	return p
+}
+
+// WithTools requires approval for tools matching any of the given names,
+// namespaces or patterns, as matched by MatchName, e.g. "read*" or "fs"
+func (p *ApprovalPolicy) WithTools(patterns ...string) *ApprovalPolicy {
+	p.patterns = append(p.patterns, patterns...)
+	return p
+}
+
+// Requires reports whether calls to tool need approval
+func (p *ApprovalPolicy) Requires(tool Tool) bool {
+	for _, tag := range tool.Tags {
+		for _, required := range p.tags {
+			if tag == required {
+				return true
+			}
+		}
+	}
+	for _, pattern := range p.patterns {
+		if MatchName(pattern, tool.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize asks for approval of a call if the policy requires it. It
+// returns an error if the call was denied or approval failed.
+func (p *ApprovalPolicy) Authorize(ctx context.Context, tool Tool, args map[string]any) error {
+	if !p.Requires(tool) {
+		return nil
+	}
+	if p.handler == nil {
+		return fmt.Errorf("tool %q requires approval, but no approval handler is configured", tool.Name)
+	}
+
+	decision, err := p.handler.Approve(ctx, ApprovalRequest{Tool: tool, Args: args})
+	if err != nil {
+		return fmt.Errorf("approval for tool %q failed: %w", tool.Name, err)
+	}
+	if !decision.Approved {
+		if decision.Reason == "" {
+			return fmt.Errorf("tool %q was not approved", tool.Name)
+		}
+		return fmt.Errorf("tool %q was not approved: %s", tool.Name, decision.Reason)
+	}
+	return nil
+}
+
+// TerminalApprover asks a person at the terminal to approve each call.
+// Prompts are serialized, so parallel tool calls are asked about one at a
+// time.
+type TerminalApprover struct {
+	in  io.Reader
+	out io.Writer
+	mu  sync.Mutex
+}
+
+// NewTerminalApprover creates an approver prompting on out and reading
+// answers from in, usually os.Stdin and os.Stderr
+func NewTerminalApprover(in io.Reader, out io.Writer) *TerminalApprover {
+	return &TerminalApprover{in: in, out: out}
+}
+
+// Approve implements ApprovalHandler. Only "y" or "yes" approves the call.
+func (a *TerminalApprover) Approve(ctx context.Context, req ApprovalRequest) (Decision, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return Decision{}, err
+	}
+
+	args, _ := json.Marshal(req.Args)
+	fmt.Fprintf(a.out, "\nTool %q wants to run with arguments %s\nAllow? [y/N] ", req.Tool.Name, args)
+
+	answer, err := a.readLine()
+	if err != nil && answer == "" {
+		return Decision{}, fmt.Errorf("failed to read answer: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return Decision{Approved: true}, nil
+	default:
+		return Decision{Reason: "denied by the user"}, nil
+	}
+}
+
+// readLine reads up to a newline one byte at a time, so no input meant for
+// other readers of the terminal is consumed
+func (a *TerminalApprover) readLine() (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := a.in.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+// PendingApproval is a tool call waiting for an answer on a
+// ChannelApprover. Call Approve or Deny exactly once.
+type PendingApproval struct {
+	ApprovalRequest
+	reply chan Decision
+}
+
+// Approve lets the call run
+func (p *PendingApproval) Approve() {
+	p.reply <- Decision{Approved: true}
+}
+
+// Deny rejects the call, telling the model why
+func (p *PendingApproval) Deny(reason string) {
+	p.reply <- Decision{Reason: reason}
+}
+
+// ChannelApprover publishes approval requests on a channel for another
+// goroutine, such as a UI, to answer
+type ChannelApprover struct {
+	requests chan *PendingApproval
+}
+
+// NewChannelApprover creates a ChannelApprover
+func NewChannelApprover() *ChannelApprover {
+	return &ChannelApprover{requests: make(chan *PendingApproval)}
+}
+
+// Requests returns the channel of calls waiting for approval
+func (a *ChannelApprover) Requests() <-chan *PendingApproval {
+	return a.requests
+}
+
+// Approve implements ApprovalHandler, waiting until the request is
+// answered or ctx is done
+func (a *ChannelApprover) Approve(ctx context.Context, req ApprovalRequest) (Decision, error) {
+	pending := &PendingApproval{ApprovalRequest: req, reply: make(chan Decision, 1)}
+	select {
+	case a.requests <- pending:
+	case <-ctx.Done():
+		return Decision{}, ctx.Err()
+	}
+
+	select {
+	case decision := <-pending.reply:
+		return decision, nil
+	case <-ctx.Done():
+		return Decision{}, ctx.Err()
+	}
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalPolicy(t *testing.T) {
+	ctx := context.Background()
+	tagged := Tool{Name: "delete", Tags: []string{TagRequiresApproval}}
+	sensitive := Tool{Name: "transfer", Tags: []string{"payments"}}
+	fsTool := Tool{Name: "fs.read"}
+	plain := Tool{Name: "clock"}
+
+	t.Run("requires", func(t *testing.T) {
+		policy := NewApprovalPolicy(AutoApprove()).WithTags("payments").WithTools("fs.*")
+
+		assert.True(t, policy.Requires(tagged))
+		assert.True(t, policy.Requires(sensitive))
+		assert.True(t, policy.Requires(fsTool))
+		assert.False(t, policy.Requires(plain))
+
+		assert.False(t, NewApprovalPolicy(AutoApprove()).Requires(sensitive))
+	})
+
+	t.Run("authorize", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			handler ApprovalHandler
+			tool    Tool
+			wantErr string
+		}{
+			{name: "approved", handler: AutoApprove(), tool: tagged},
+			{name: "not required", handler: AutoDeny("no"), tool: plain},
+			{name: "denied", handler: AutoDeny("too risky"), tool: tagged, wantErr: `tool "delete" was not approved: too risky`},
+			{name: "denied without reason", handler: AutoDeny(""), tool: tagged, wantErr: `tool "delete" was not approved`},
+			{
+				name: "handler error",
+				handler: ApprovalFunc(func(ctx context.Context, req ApprovalRequest) (Decision, error) {
+					return Decision{}, errors.New("nobody home")
+				}),
+				tool:    tagged,
+				wantErr: `approval for tool "delete" failed: nobody home`,
+			},
+			{name: "no handler", tool: tagged, wantErr: `tool "delete" requires approval, but no approval handler is configured`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := NewApprovalPolicy(tt.handler).Authorize(ctx, tt.tool, map[string]any{})
+				if tt.wantErr == "" {
+					assert.NoError(t, err)
+				} else {
+					assert.EqualError(t, err, tt.wantErr)
+				}
+			})
+		}
+	})
+}
+
+func TestTerminalApprover(t *testing.T) {
+	ctx := context.Background()
+	req := ApprovalRequest{Tool: Tool{Name: "readFile"}, Args: map[string]any{"path": "/etc/hosts"}}
+
+	t.Run("answers", func(t *testing.T) {
+		in := strings.NewReader("y\nno\nYES\n\nrest of the chat\n")
+		var out bytes.Buffer
+		approver := NewTerminalApprover(in, &out)
+
+		for _, want := range []bool{true, false, true, false} {
+			decision, err := approver.Approve(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, want, decision.Approved)
+		}
+		assert.Contains(t, out.String(), `Tool "readFile" wants to run with arguments {"path":"/etc/hosts"}`)
+
+		// Input after the answers is left for other readers
+		rest, err := io.ReadAll(in)
+		require.NoError(t, err)
+		assert.Equal(t, "rest of the chat\n", string(rest))
+	})
+
+	t.Run("denial reason", func(t *testing.T) {
+		decision, err := NewTerminalApprover(strings.NewReader("n\n"), io.Discard).Approve(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, Decision{Reason: "denied by the user"}, decision)
+	})
+
+	t.Run("closed input", func(t *testing.T) {
+		_, err := NewTerminalApprover(strings.NewReader(""), io.Discard).Approve(ctx, req)
+		assert.ErrorContains(t, err, "failed to read answer")
+	})
+}
+
+func TestChannelApprover(t *testing.T) {
+	ctx := context.Background()
+	req := ApprovalRequest{Tool: Tool{Name: "delete"}}
+
+	t.Run("approve and deny", func(t *testing.T) {
+		approver := NewChannelApprover()
+		go func() {
+			pending := <-approver.Requests()
+			pending.Approve()
+			pending = <-approver.Requests()
+			pending.Deny("not now")
+		}()
+
+		decision, err := approver.Approve(ctx, req)
+		require.NoError(t, err)
+		assert.True(t, decision.Approved)
+
+		decision, err = approver.Approve(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, Decision{Reason: "not now"}, decision)
+	})
+
+	t.Run("gives up when the context ends", func(t *testing.T) {
+		approver := NewChannelApprover()
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+
+		_, err := approver.Approve(timeout, req)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
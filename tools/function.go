@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+var (
+	contextType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+	toolResultType = reflect.TypeOf(ToolResult{})
+)
+
+// NewFunctionTool creates a tool from a Go function. Besides the simple
+// signatures accepted by RegisterFunctionTool, fn may take an optional
+// context.Context followed by an optional struct (or struct pointer) of
+// arguments, and return a result, an error, or both:
+//
+//	func(ctx context.Context, args WeatherArgs) (Forecast, error)
+//
+// The input schema is generated from the argument struct with
+// GenerateSchema, and arguments are decoded into it before each call.
+// String and ToolResult results are used as they are; other results are
+// returned as JSON.
+func NewFunctionTool(name, description string, fn any) (Tool, error) {
+	switch fn.(type) {
+	case func() string, func(string) string, func(map[string]interface{}) string,
+		func(context.Context, map[string]interface{}) (string, error), func() (string, error):
+		return simpleFunctionTool(name, description, fn), nil
+	}
+
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func || fnValue.IsNil() {
+		return Tool{}, fmt.Errorf("tool %q: handler must be a function, got %T", name, fn)
+	}
+	fnType := fnValue.Type()
+	if fnType.IsVariadic() {
+		return Tool{}, fmt.Errorf("tool %q: variadic handlers are not supported", name)
+	}
+
+	// Inputs: [context.Context] [Args]
+	in := 0
+	takesContext := fnType.NumIn() > in && fnType.In(in) == contextType
+	if takesContext {
+		in++
+	}
+	var argsType reflect.Type
+	if fnType.NumIn() > in {
+		argsType = fnType.In(in)
+		in++
+		structType := argsType
+		if structType.Kind() == reflect.Pointer {
+			structType = structType.Elem()
+		}
+		if structType.Kind() != reflect.Struct {
+			return Tool{}, fmt.Errorf("tool %q: arguments must be a struct, got %s", name, argsType)
+		}
+	}
+	if fnType.NumIn() > in {
+		return Tool{}, fmt.Errorf("tool %q: unsupported handler type %T", name, fn)
+	}
+
+	// Outputs: [Result] [error]
+	out := fnType.NumOut()
+	returnsError := out > 0 && fnType.Out(out-1) == errorType
+	if returnsError {
+		out--
+	}
+	if out > 1 {
+		return Tool{}, fmt.Errorf("tool %q: unsupported handler type %T", name, fn)
+	}
+	returnsResult := out == 1
+
+	schema := json.RawMessage(`{"type":"object","properties":{},"required":[]}`)
+	if argsType != nil {
+		var err error
+		if schema, err = GenerateSchema(reflect.Zero(argsType).Interface()); err != nil {
+			return Tool{}, fmt.Errorf("tool %q: failed to generate schema: %w", name, err)
+		}
+	}
+
+	handler := func(ctx context.Context, args map[string]any) (ToolResult, error) {
+		var callArgs []reflect.Value
+		if takesContext {
+			callArgs = append(callArgs, reflect.ValueOf(ctx))
+		}
+		if argsType != nil {
+			value, err := decodeArgs(args, argsType)
+			if err != nil {
+				return NewErrorResult(err), nil
+			}
+			callArgs = append(callArgs, value)
+		}
+
+		results := fnValue.Call(callArgs)
+		if returnsError {
+			if err, _ := results[len(results)-1].Interface().(error); err != nil {
+				return NewErrorResult(err), nil
+			}
+		}
+		if !returnsResult {
+			return NewToolResult(""), nil
+		}
+		return resultFromValue(results[0])
+	}
+
+	return Tool{
+		Name:        name,
+		Description: description,
+		Schema:      schema,
+		Handler:     handler,
+	}, nil
+}
+
+// decodeArgs decodes tool arguments into a new value of the given struct
+// or struct pointer type
+func decodeArgs(args map[string]any, t reflect.Type) (reflect.Value, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to encode arguments: %w", err)
+	}
+
+	isPointer := t.Kind() == reflect.Pointer
+	if isPointer {
+		t = t.Elem()
+	}
+	value := reflect.New(t)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to decode arguments: %w", err)
+	}
+	if isPointer {
+		return value, nil
+	}
+	return value.Elem(), nil
+}
+
+// resultFromValue converts a handler's return value to a ToolResult
+func resultFromValue(value reflect.Value) (ToolResult, error) {
+	if value.Type() == toolResultType {
+		return value.Interface().(ToolResult), nil
+	}
+	if value.Kind() == reflect.String {
+		return NewToolResult(value.String()), nil
+	}
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return ToolResult{}, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return NewToolResult(string(data)), nil
+}
+
+// simpleFunctionTool creates a tool from one of the fixed signatures
+// supported before typed handlers
+func simpleFunctionTool(name, description string, handler interface{}) Tool {
+	// Convert the handler to a proper ToolHandler function
+	toolHandler := func(ctx context.Context, args map[string]interface{}) (ToolResult, error) {
+		// Handle different function signatures
+		switch h := handler.(type) {
+		case func() string:
+			// Simple no-arg function
+			return NewToolResult(h()), nil
+
+		case func(string) string:
+			// Function that takes a single string argument
+			input, _ := args["input"].(string)
+			return NewToolResult(h(input)), nil
+
+		case func(map[string]interface{}) string:
+			// Function that takes a map of arguments
+			return NewToolResult(h(args)), nil
+
+		case func(context.Context, map[string]interface{}) (string, error):
+			// Full handler with context and error
+			result, err := h(ctx, args)
+			if err != nil {
+				return NewErrorResult(err), nil
+			}
+			return NewToolResult(result), nil
+
+		case func() (string, error):
+			// No-arg function that can error
+			result, err := h()
+			if err != nil {
+				return NewErrorResult(err), nil
+			}
+			return NewToolResult(result), nil
+
+		default:
+			// Unsupported handler type
+			return ToolResult{}, fmt.Errorf("unsupported handler type: %T", handler)
+		}
+	}
+
+	// Generate schema based on function signature
+	var schema json.RawMessage
+	switch handler.(type) {
+	case func() string, func() (string, error):
+		// No parameters
+		schema = json.RawMessage(`{"type":"object","properties":{},"required":[]}`)
+
+	case func(string) string:
+		// Single string input
+		schema = json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"input": {"type": "string", "description": "Input text"}
+			},
+			"required": ["input"]
+		}`)
+
+	default:
+		// Default to accepting any object
+		schema = json.RawMessage(`{"type":"object","properties":{},"additionalProperties":true}`)
+	}
+
+	return Tool{
+		Name:        name,
+		Description: description,
+		Schema:      schema,
+		Handler:     toolHandler,
+	}
+}
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type weatherArgs struct {
+	City  string `json:"city" jsonschema:"description=City to look up"`
+	Units string `json:"units,omitempty" jsonschema:"enum=metric,enum=imperial"`
+}
+
+type forecast struct {
+	City string  `json:"city"`
+	Temp float64 `json:"temp"`
+}
+
+func TestNewFunctionTool(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("typed handler", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, RegisterFunctionTool(registry, "weather", "Get the weather",
+			func(ctx context.Context, args weatherArgs) (forecast, error) {
+				temp := 20.0
+				if args.Units == "imperial" {
+					temp = 68
+				}
+				return forecast{City: args.City, Temp: temp}, nil
+			}))
+
+		tool, err := registry.Get("weather")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"type": "object",
+			"properties": {
+				"city": {"type": "string", "description": "City to look up"},
+				"units": {"type": "string", "enum": ["metric", "imperial"]}
+			},
+			"required": ["city"]
+		}`, string(tool.Schema))
+
+		result, err := registry.Call(ctx, "weather", map[string]any{"city": "Oslo", "units": "imperial"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.JSONEq(t, `{"city":"Oslo","temp":68}`, result.Content)
+
+		_, err = registry.Call(ctx, "weather", map[string]any{"units": "imperial"})
+		assert.ErrorContains(t, err, "city is required")
+	})
+
+	t.Run("supported signatures", func(t *testing.T) {
+		tests := []struct {
+			name string
+			fn   any
+			args map[string]any
+			want ToolResult
+		}{
+			{
+				name: "pointer args and string result",
+				fn:   func(args *weatherArgs) string { return "sunny in " + args.City },
+				args: map[string]any{"city": "Rome"},
+				want: NewToolResult("sunny in Rome"),
+			},
+			{
+				name: "context only",
+				fn:   func(ctx context.Context) (string, error) { return "ok", nil },
+				want: NewToolResult("ok"),
+			},
+			{
+				name: "error only",
+				fn:   func(args weatherArgs) error { return nil },
+				args: map[string]any{"city": "Rome"},
+				want: NewToolResult(""),
+			},
+			{
+				name: "handler error",
+				fn: func(ctx context.Context, args weatherArgs) (forecast, error) {
+					return forecast{}, errors.New("no data")
+				},
+				args: map[string]any{"city": "Rome"},
+				want: ToolResult{Content: "no data", IsError: true},
+			},
+			{
+				name: "tool result",
+				fn: func(args weatherArgs) ToolResult {
+					return ToolResult{Content: args.City, Metadata: map[string]any{"source": "test"}}
+				},
+				args: map[string]any{"city": "Rome"},
+				want: ToolResult{Content: "Rome", Metadata: map[string]any{"source": "test"}},
+			},
+			{
+				name: "legacy string handler",
+				fn:   strings.ToUpper,
+				args: map[string]any{"input": "rome"},
+				want: NewToolResult("ROME"),
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tool, err := NewFunctionTool("test", "Test tool", tt.fn)
+				require.NoError(t, err)
+				args := tt.args
+				if args == nil {
+					args = map[string]any{}
+				}
+				require.NoError(t, ValidateArgs(tool.Schema, args))
+
+				result, err := tool.Handler(ctx, args)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, result)
+			})
+		}
+	})
+
+	t.Run("undecodable arguments", func(t *testing.T) {
+		tool, err := NewFunctionTool("test", "Test tool", func(args weatherArgs) string { return args.City })
+		require.NoError(t, err)
+
+		result, err := tool.Handler(ctx, map[string]any{"city": 42})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content, "failed to decode arguments")
+	})
+
+	t.Run("unsupported handlers", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			fn      any
+			wantErr string
+		}{
+			{"not a function", "hello", "handler must be a function"},
+			{"nil function", (func())(nil), "handler must be a function"},
+			{"non-struct args", func(n int) string { return fmt.Sprint(n) }, "arguments must be a struct"},
+			{"too many args", func(a, b weatherArgs) string { return "" }, "unsupported handler type"},
+			{"too many results", func() (string, string, error) { return "", "", nil }, "unsupported handler type"},
+			{"variadic", func(args ...weatherArgs) string { return "" }, "variadic handlers are not supported"},
+			{"unsupported field", func(args struct{ C chan int }) string { return "" }, "failed to generate schema"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := NewFunctionTool("test", "Test tool", tt.fn)
+				assert.ErrorContains(t, err, tt.wantErr)
+			})
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/adimarco/hive/logging"
+)
+
+// Interceptor runs around a tool call. It sees the tool, arguments and
+// context, and either calls next, possibly with changed arguments or
+// context, or returns a result of its own without calling the tool. It may
+// also change the result next returns.
+type Interceptor func(ctx context.Context, tool Tool, args map[string]any, next ToolHandler) (ToolResult, error)
+
+// chain wraps a tool's handler in interceptors, the first being the
+// outermost
+func chain(tool Tool, interceptors []Interceptor) ToolHandler {
+	handler := tool.Handler
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, args map[string]any) (ToolResult, error) {
+			return interceptor(ctx, tool, args, next)
+		}
+	}
+	return handler
+}
+
+// ForTools restricts an interceptor to the named tools; other calls pass
+// straight through
+func ForTools(interceptor Interceptor, names ...string) Interceptor {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+	return func(ctx context.Context, tool Tool, args map[string]any, next ToolHandler) (ToolResult, error) {
+		if !selected[tool.Name] {
+			return next(ctx, args)
+		}
+		return interceptor(ctx, tool, args, next)
+	}
+}
+
+// redacted replaces the values of redacted arguments
+const redacted = "[REDACTED]"
+
+// RedactArgs returns a copy of args with the values of the given keys
+// replaced, for logging arguments that may hold secrets
+func RedactArgs(args map[string]any, keys ...string) map[string]any {
+	result := make(map[string]any, len(args))
+	for k, v := range args {
+		result[k] = v
+	}
+	for _, key := range keys {
+		if _, ok := result[key]; ok {
+			result[key] = redacted
+		}
+	}
+	return result
+}
+
+// LoggingInterceptor logs every tool call with its arguments, duration and
+// outcome. Values of the redact keys are hidden from the log. A nil logger
+// uses the "tools" logger.
+func LoggingInterceptor(logger logging.Logger, redact ...string) Interceptor {
+	if logger == nil {
+		logger = logging.GetLogger("tools")
+	}
+	return func(ctx context.Context, tool Tool, args map[string]any, next ToolHandler) (ToolResult, error) {
+		logger.Debug(ctx, "Calling tool", logging.WithData(map[string]interface{}{
+			"tool": tool.Name,
+			"args": RedactArgs(args, redact...),
+		}))
+
+		start := time.Now()
+		result, err := next(ctx, args)
+
+		data := map[string]interface{}{
+			"tool":        tool.Name,
+			"duration_ms": time.Since(start).Milliseconds(),
+			"is_error":    err != nil || result.IsError,
+		}
+		if err != nil {
+			data["error"] = err.Error()
+		}
+		logger.Info(ctx, "Tool finished", logging.WithData(data))
+		return result, err
+	}
+}
+
+// ResultCache caches successful tool results keyed by tool name and
+// arguments. Use it only for tools without side effects, e.g. with
+// ForTools(cache.Interceptor(), "lookup").
+type ResultCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// cacheEntry is a cached result and when it expires
+type cacheEntry struct {
+	result  ToolResult
+	expires time.Time
+}
+
+// NewResultCache creates a cache whose entries live for ttl. Zero keeps
+// entries until the cache is cleared.
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Interceptor returns an interceptor answering repeated calls from the
+// cache. Cached results carry "cached": true in their metadata.
+func (c *ResultCache) Interceptor() Interceptor {
+	return func(ctx context.Context, tool Tool, args map[string]any, next ToolHandler) (ToolResult, error) {
+		key, ok := cacheKey(tool.Name, args)
+		if !ok {
+			return next(ctx, args)
+		}
+
+		if result, ok := c.get(key); ok {
+			return result, nil
+		}
+
+		result, err := next(ctx, args)
+		if err == nil && !result.IsError {
+			c.put(key, result)
+		}
+		return result, err
+	}
+}
+
+// get returns an unexpired cached result
+func (c *ResultCache) get(key string) (ToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return ToolResult{}, false
+	}
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return ToolResult{}, false
+	}
+
+	result := entry.result
+	metadata := make(map[string]any, len(result.Metadata)+1)
+	for k, v := range result.Metadata {
+		metadata[k] = v
+	}
+	metadata["cached"] = true
+	result.Metadata = metadata
+	return result, true
+}
+
+// put stores a result
+func (c *ResultCache) put(key string, result ToolResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := cacheEntry{result: result}
+	if c.ttl > 0 {
+		entry.expires = c.now().Add(c.ttl)
+	}
+	c.entries[key] = entry
+}
+
+// Len returns the number of cached results, including expired ones not yet
+// evicted
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Clear removes all cached results
+func (c *ResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+// cacheKey returns the tool name followed by the arguments as canonical
+// JSON; encoding/json sorts map keys, so equal arguments give equal keys
+func cacheKey(name string, args map[string]any) (string, bool) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	return name + "\x00" + string(data), true
+}
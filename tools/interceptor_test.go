@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRegistry returns a registry with an upper tool and a counter of
+// how often its handler ran
+func countingRegistry(t *testing.T) (*SimpleToolRegistry, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	registry := NewSimpleToolRegistry()
+	require.NoError(t, RegisterFunctionTool(registry, "upper", "Upper-case the input", func(s string) string {
+		calls.Add(1)
+		return strings.ToUpper(s)
+	}))
+	return registry, &calls
+}
+
+func TestInterceptors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("run in order around the handler", func(t *testing.T) {
+		registry, _ := countingRegistry(t)
+		var order []string
+		record := func(name string) Interceptor {
+			return func(ctx context.Context, tool Tool, args map[string]any, next ToolHandler) (ToolResult, error) {
+				order = append(order, name+" before "+tool.Name)
+				result, err := next(ctx, args)
+				order = append(order, name+" after")
+				return result, err
+			}
+		}
+		registry.Use(record("outer"), record("inner"))
+
+		result, err := registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, "HI", result.Content)
+		assert.Equal(t, []string{"outer before upper", "inner before upper", "inner after", "outer after"}, order)
+	})
+
+	t.Run("mutate args and result", func(t *testing.T) {
+		registry, _ := countingRegistry(t)
+		registry.Use(func(ctx context.Context, tool Tool, args map[string]any, next ToolHandler) (ToolResult, error) {
+			result, err := next(ctx, map[string]any{"input": args["input"].(string) + " there"})
+			result.Content += "!"
+			return result, err
+		})
+
+		result, err := registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, "HI THERE!", result.Content)
+	})
+
+	t.Run("short-circuit", func(t *testing.T) {
+		registry, calls := countingRegistry(t)
+		registry.Use(func(ctx context.Context, tool Tool, args map[string]any, next ToolHandler) (ToolResult, error) {
+			return ToolResult{}, errors.New("not authorized")
+		})
+
+		result, err := registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, NewErrorResult(errors.New("not authorized")), result)
+		assert.Zero(t, calls.Load())
+
+		// Streaming goes through the same chain
+		results, err := registry.Stream(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.True(t, (<-results).IsError)
+		assert.Zero(t, calls.Load())
+	})
+
+	t.Run("for tools", func(t *testing.T) {
+		registry, _ := countingRegistry(t)
+		require.NoError(t, RegisterFunctionTool(registry, "lower", "Lower-case the input", strings.ToLower))
+		registry.Use(ForTools(func(ctx context.Context, tool Tool, args map[string]any, next ToolHandler) (ToolResult, error) {
+			return NewToolResult("intercepted"), nil
+		}, "lower"))
+
+		result, err := registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, "HI", result.Content)
+
+		result, err = registry.Call(ctx, "lower", map[string]any{"input": "HI"})
+		require.NoError(t, err)
+		assert.Equal(t, "intercepted", result.Content)
+	})
+
+	t.Run("logging", func(t *testing.T) {
+		registry, calls := countingRegistry(t)
+		registry.Use(LoggingInterceptor(nil, "input"))
+
+		result, err := registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, "HI", result.Content)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+}
+
+func TestRedactArgs(t *testing.T) {
+	args := map[string]any{"user": "ann", "password": "secret"}
+	redactedArgs := RedactArgs(args, "password", "token")
+
+	assert.Equal(t, map[string]any{"user": "ann", "password": "[REDACTED]"}, redactedArgs)
+	assert.Equal(t, "secret", args["password"], "the original is unchanged")
+}
+
+func TestResultCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("caches by tool and canonical args", func(t *testing.T) {
+		registry, calls := countingRegistry(t)
+		cache := NewResultCache(0)
+		registry.Use(cache.Interceptor())
+
+		first, err := registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.Nil(t, first.Metadata)
+
+		second, err := registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, "HI", second.Content)
+		assert.Equal(t, true, second.Metadata["cached"])
+		assert.EqualValues(t, 1, calls.Load())
+
+		_, err = registry.Call(ctx, "upper", map[string]any{"input": "other"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, calls.Load())
+		assert.Equal(t, 2, cache.Len())
+
+		cache.Clear()
+		_, err = registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("equal args in any order share an entry", func(t *testing.T) {
+		a, okA := cacheKey("tool", map[string]any{"a": 1, "b": map[string]any{"x": true, "y": "z"}})
+		b, okB := cacheKey("tool", map[string]any{"b": map[string]any{"y": "z", "x": true}, "a": 1})
+		require.True(t, okA && okB)
+		assert.Equal(t, a, b)
+
+		other, _ := cacheKey("other", map[string]any{"a": 1, "b": map[string]any{"x": true, "y": "z"}})
+		assert.NotEqual(t, a, other)
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		registry, calls := countingRegistry(t)
+		cache := NewResultCache(time.Minute)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+		registry.Use(cache.Interceptor())
+
+		call := func() {
+			_, err := registry.Call(ctx, "upper", map[string]any{"input": "hi"})
+			require.NoError(t, err)
+		}
+		call()
+		now = now.Add(30 * time.Second)
+		call()
+		assert.EqualValues(t, 1, calls.Load())
+
+		now = now.Add(time.Minute)
+		call()
+		assert.EqualValues(t, 2, calls.Load())
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		var calls atomic.Int32
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(Tool{
+			Name: "flaky",
+			Handler: func(ctx context.Context, args map[string]any) (ToolResult, error) {
+				calls.Add(1)
+				return NewErrorResult(errors.New("try again")), nil
+			},
+		}))
+		cache := NewResultCache(0)
+		registry.Use(cache.Interceptor())
+
+		for i := 0; i < 2; i++ {
+			result, err := registry.Call(ctx, "flaky", map[string]any{})
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+		}
+		assert.EqualValues(t, 2, calls.Load())
+		assert.Zero(t, cache.Len())
+	})
+}
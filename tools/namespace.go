@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// NamespaceSeparator separates a tool's namespace, such as the MCP server
+// or provider it comes from, from its name: "github.create_issue"
+const NamespaceSeparator = "."
+
+// QualifiedName returns the name a tool is registered under in namespace
+func QualifiedName(namespace, name string) string {
+	return namespace + NamespaceSeparator + name
+}
+
+// SplitName splits a qualified tool name into its namespace and base name.
+// Names without a namespace return an empty namespace.
+func SplitName(name string) (namespace, base string) {
+	namespace, base, found := strings.Cut(name, NamespaceSeparator)
+	if !found {
+		return "", name
+	}
+	return namespace, base
+}
+
+// MatchName reports whether a tool name matches pattern. A pattern matches
+// the full name using path.Match syntax, e.g. "github.*" or "*_file", or
+// names a namespace, e.g. "github", matching every tool in it.
+func MatchName(pattern, name string) bool {
+	if matched, _ := path.Match(pattern, name); matched {
+		return true
+	}
+	namespace, _ := SplitName(name)
+	if namespace == "" {
+		return false
+	}
+	matched, _ := path.Match(pattern, namespace)
+	return matched
+}
+
+// SelectTools returns the registered tools matching any allow entry and no
+// deny pattern, in the order they were allowed. Allow entries are tool
+// names, namespaces or glob patterns; an unqualified name also finds a tool
+// registered under a namespace if only one has that name. Internal tools
+// are selected only by their exact name. Entries that match nothing are
+// returned as missing.
+func SelectTools(registry ToolRegistry, allow, deny []string) (selected []Tool, missing []string) {
+	all := registry.List()
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+
+	seen := make(map[string]bool)
+	add := func(tool Tool) {
+		if seen[tool.Name] {
+			return
+		}
+		seen[tool.Name] = true
+		for _, pattern := range deny {
+			if MatchName(pattern, tool.Name) {
+				return
+			}
+		}
+		selected = append(selected, tool)
+	}
+
+	for _, entry := range allow {
+		if tool, err := registry.Get(entry); err == nil && (tool.Name == entry || !tool.IsInternal()) {
+			add(tool)
+			continue
+		}
+		found := false
+		for _, tool := range all {
+			if !tool.IsInternal() && MatchName(entry, tool.Name) {
+				add(tool)
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, entry)
+		}
+	}
+	return selected, missing
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namedTool returns a tool doing nothing, described as description
+func namedTool(name, description string) Tool {
+	return Tool{
+		Name:        name,
+		Description: description,
+		Handler: func(ctx context.Context, args map[string]any) (ToolResult, error) {
+			return NewToolResult(name), nil
+		},
+	}
+}
+
+func TestSplitName(t *testing.T) {
+	namespace, base := SplitName("github.create_issue")
+	assert.Equal(t, "github", namespace)
+	assert.Equal(t, "create_issue", base)
+
+	namespace, base = SplitName("calculator")
+	assert.Empty(t, namespace)
+	assert.Equal(t, "calculator", base)
+}
+
+func TestMatchName(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"github.create_issue", "github.create_issue", true},
+		{"github", "github.create_issue", true},
+		{"git*", "github.create_issue", true},
+		{"github.*_issue", "github.create_issue", true},
+		{"github.*_issue", "github.list_pulls", false},
+		{"*_file", "read_file", true},
+		{"github", "gitlab.create_issue", false},
+		{"calculator", "calculator", true},
+		{"calc", "calculator", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchName(tt.pattern, tt.name), "%q matching %q", tt.pattern, tt.name)
+	}
+}
+
+func TestSelectTools(t *testing.T) {
+	registry := NewSimpleToolRegistry()
+	for _, name := range []string{"calculator", "github.create_issue", "github.list_pulls", "gitlab.create_issue", "docs.search"} {
+		require.NoError(t, registry.Register(namedTool(name, "")))
+	}
+
+	names := func(selected []Tool) []string {
+		result := make([]string, len(selected))
+		for i, tool := range selected {
+			result[i] = tool.Name
+		}
+		return result
+	}
+
+	t.Run("names, namespaces and patterns", func(t *testing.T) {
+		selected, missing := SelectTools(registry, []string{"calculator", "github", "*.search"}, nil)
+		assert.Equal(t, []string{"calculator", "github.create_issue", "github.list_pulls", "docs.search"}, names(selected))
+		assert.Empty(t, missing)
+	})
+
+	t.Run("unqualified names", func(t *testing.T) {
+		selected, _ := SelectTools(registry, []string{"search"}, nil)
+		assert.Equal(t, []string{"docs.search"}, names(selected))
+
+		// Ambiguous names select nothing
+		selected, missing := SelectTools(registry, []string{"create_issue"}, nil)
+		assert.Empty(t, selected)
+		assert.Equal(t, []string{"create_issue"}, missing)
+	})
+
+	t.Run("deny list", func(t *testing.T) {
+		selected, _ := SelectTools(registry, []string{"*"}, []string{"git*", "calculator"})
+		assert.Equal(t, []string{"docs.search"}, names(selected))
+
+		selected, _ = SelectTools(registry, []string{"github", "gitlab"}, []string{"*.create_issue"})
+		assert.Equal(t, []string{"github.list_pulls"}, names(selected))
+	})
+
+	t.Run("internal tools only by exact name", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(namedTool("docs.search", "")))
+		internal := namedTool("router.select_agents", "")
+		internal.Tags = []string{TagInternal}
+		require.NoError(t, registry.Register(internal))
+
+		selected, _ := SelectTools(registry, []string{"*", "router", "select_agents"}, nil)
+		assert.Equal(t, []string{"docs.search"}, names(selected))
+
+		selected, _ = SelectTools(registry, []string{"router.select_agents"}, nil)
+		assert.Equal(t, []string{"router.select_agents"}, names(selected))
+	})
+
+	t.Run("duplicates and missing", func(t *testing.T) {
+		selected, missing := SelectTools(registry, []string{"docs.search", "docs", "weather"}, nil)
+		assert.Equal(t, []string{"docs.search"}, names(selected))
+		assert.Equal(t, []string{"weather"}, missing)
+	})
+}
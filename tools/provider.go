@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// registeredProvider is a provider and the names its tools were registered
+// under
+type registeredProvider struct {
+	provider ToolProvider
+	names    []string
+}
+
+// RegisterProvider implements ToolRegistry.RegisterProvider. The provider is
+// initialized once and its tools registered as namespace.name; if any tool
+// fails to register, those already registered are removed and the provider
+// cleaned up.
+func (r *SimpleToolRegistry) RegisterProvider(namespace string, provider ToolProvider) error {
+	if namespace == "" {
+		return fmt.Errorf("provider namespace is required")
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return fmt.Errorf("registry is closed")
+	}
+	if _, exists := r.providers[namespace]; exists {
+		r.mu.Unlock()
+		return fmt.Errorf("provider %q already registered", namespace)
+	}
+	// Reserve the namespace while the provider initializes
+	r.providers[namespace] = registeredProvider{provider: provider}
+	r.mu.Unlock()
+
+	ctx := context.Background()
+	if err := provider.Initialize(ctx); err != nil {
+		r.dropProvider(namespace)
+		return fmt.Errorf("failed to initialize provider %q: %w", namespace, err)
+	}
+
+	var names []string
+	for _, tool := range provider.GetTools() {
+		tool.Name = QualifiedName(namespace, tool.Name)
+		if err := r.Register(tool); err != nil {
+			errs := []error{fmt.Errorf("provider %q: failed to register tool %q: %w", namespace, tool.Name, err)}
+			for _, name := range names {
+				errs = append(errs, r.Unregister(name))
+			}
+			errs = append(errs, provider.Cleanup(ctx))
+			r.dropProvider(namespace)
+			return errors.Join(errs...)
+		}
+		names = append(names, tool.Name)
+	}
+
+	r.mu.Lock()
+	r.providers[namespace] = registeredProvider{provider: provider, names: names}
+	r.mu.Unlock()
+	return nil
+}
+
+// UnregisterProvider implements ToolRegistry.UnregisterProvider, removing
+// the provider's tools and cleaning it up
+func (r *SimpleToolRegistry) UnregisterProvider(namespace string) error {
+	r.mu.Lock()
+	registered, exists := r.providers[namespace]
+	delete(r.providers, namespace)
+	r.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("provider %q not found", namespace)
+	}
+	return r.closeProvider(namespace, registered)
+}
+
+// closeProvider unregisters a provider's tools and cleans it up
+func (r *SimpleToolRegistry) closeProvider(namespace string, registered registeredProvider) error {
+	var errs []error
+	for _, name := range registered.names {
+		errs = append(errs, r.Unregister(name))
+	}
+	if err := registered.provider.Cleanup(context.Background()); err != nil {
+		errs = append(errs, fmt.Errorf("failed to cleanup provider %q: %w", namespace, err))
+	}
+	return errors.Join(errs...)
+}
+
+// dropProvider forgets a provider that failed to register
+func (r *SimpleToolRegistry) dropProvider(namespace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.providers, namespace)
+}
+
+// Close implements ToolRegistry.Close. Providers are cleaned up along with
+// their tools, then every remaining tool's Cleanup runs. The registry
+// accepts no new tools afterwards.
+func (r *SimpleToolRegistry) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	providers := r.providers
+	r.providers = make(map[string]registeredProvider)
+	r.mu.Unlock()
+
+	var errs []error
+	namespaces := make([]string, 0, len(providers))
+	for namespace := range providers {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		errs = append(errs, r.closeProvider(namespace, providers[namespace]))
+	}
+
+	r.mu.Lock()
+	remaining := r.tools
+	r.tools = make(map[string]Tool)
+	r.mu.Unlock()
+
+	names := make([]string, 0, len(remaining))
+	for name := range remaining {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cleanup := remaining[name].Cleanup; cleanup != nil {
+			if err := cleanup(context.Background()); err != nil {
+				errs = append(errs, fmt.Errorf("failed to cleanup tool %q: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
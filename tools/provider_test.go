@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider records its lifecycle calls in a shared log
+type fakeProvider struct {
+	name       string
+	tools      []Tool
+	log        *[]string
+	initErr    error
+	cleanupErr error
+}
+
+func (p *fakeProvider) GetTools() []Tool {
+	return p.tools
+}
+
+func (p *fakeProvider) Initialize(ctx context.Context) error {
+	*p.log = append(*p.log, p.name+" initialize")
+	return p.initErr
+}
+
+func (p *fakeProvider) Cleanup(ctx context.Context) error {
+	*p.log = append(*p.log, p.name+" cleanup")
+	return p.cleanupErr
+}
+
+// loggedTool returns a tool whose cleanup is recorded in log
+func loggedTool(name string, log *[]string) Tool {
+	return Tool{
+		Name: name,
+		Handler: func(ctx context.Context, args map[string]any) (ToolResult, error) {
+			return NewToolResult(name), nil
+		},
+		Cleanup: func(ctx context.Context) error {
+			*log = append(*log, name+" cleanup")
+			return nil
+		},
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("registers tools under namespace", func(t *testing.T) {
+		var log []string
+		registry := NewSimpleToolRegistry()
+		provider := &fakeProvider{name: "docs", log: &log, tools: []Tool{loggedTool("search", &log), loggedTool("fetch", &log)}}
+
+		require.NoError(t, registry.RegisterProvider("docs", provider))
+		assert.Equal(t, []string{"docs initialize"}, log)
+
+		result, err := registry.Call(ctx, "docs.search", map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "search", result.Content)
+		tool, err := registry.Get("search")
+		require.NoError(t, err)
+		assert.Equal(t, "docs.search", tool.Name)
+
+		assert.ErrorContains(t, registry.RegisterProvider("docs", provider), `provider "docs" already registered`)
+
+		require.NoError(t, registry.UnregisterProvider("docs"))
+		assert.Equal(t, []string{"docs initialize", "search cleanup", "fetch cleanup", "docs cleanup"}, log)
+		assert.Empty(t, registry.List())
+
+		assert.ErrorContains(t, registry.UnregisterProvider("docs"), `provider "docs" not found`)
+	})
+
+	t.Run("initialize failure", func(t *testing.T) {
+		var log []string
+		registry := NewSimpleToolRegistry()
+		provider := &fakeProvider{name: "docs", log: &log, initErr: errors.New("no index"), tools: []Tool{loggedTool("search", &log)}}
+
+		err := registry.RegisterProvider("docs", provider)
+		assert.EqualError(t, err, `failed to initialize provider "docs": no index`)
+		assert.Empty(t, registry.List())
+
+		// The namespace is free again
+		provider.initErr = nil
+		assert.NoError(t, registry.RegisterProvider("docs", provider))
+	})
+
+	t.Run("tool registration failure rolls back", func(t *testing.T) {
+		var log []string
+		registry := NewSimpleToolRegistry()
+		provider := &fakeProvider{name: "docs", log: &log, tools: []Tool{loggedTool("search", &log), {Name: "broken"}}}
+
+		err := registry.RegisterProvider("docs", provider)
+		assert.ErrorContains(t, err, `provider "docs": failed to register tool "docs.broken": tool handler is required`)
+		assert.Empty(t, registry.List())
+		assert.Equal(t, []string{"docs initialize", "search cleanup", "docs cleanup"}, log)
+	})
+
+	t.Run("namespace is required", func(t *testing.T) {
+		err := NewSimpleToolRegistry().RegisterProvider("", &fakeProvider{log: new([]string)})
+		assert.EqualError(t, err, "provider namespace is required")
+	})
+}
+
+func TestSimpleToolRegistry_Close(t *testing.T) {
+	var log []string
+	registry := NewSimpleToolRegistry()
+	require.NoError(t, registry.Register(loggedTool("clock", &log)))
+	require.NoError(t, registry.RegisterProvider("docs", &fakeProvider{
+		name:       "docs",
+		log:        &log,
+		tools:      []Tool{loggedTool("search", &log)},
+		cleanupErr: errors.New("index locked"),
+	}))
+	require.NoError(t, registry.RegisterProvider("web", &fakeProvider{name: "web", log: &log}))
+
+	err := registry.Close()
+	assert.EqualError(t, err, `failed to cleanup provider "docs": index locked`)
+	assert.Equal(t, []string{
+		"docs initialize", "web initialize",
+		"search cleanup", "docs cleanup", "web cleanup",
+		"clock cleanup",
+	}, log)
+	assert.Empty(t, registry.List())
+
+	// Closing again does nothing, and no new tools are accepted
+	assert.NoError(t, registry.Close())
+	err = registry.Register(loggedTool("late", &log))
+	assert.True(t, err != nil && strings.Contains(err.Error(), "registry is closed"))
+	assert.EqualError(t, registry.RegisterProvider("late", &fakeProvider{log: &log}), "registry is closed")
+}
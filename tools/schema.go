@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// GenerateSchema returns a JSON Schema describing v, which is usually a
+// struct value. Property names come from json tags, and a jsonschema tag
+// adds detail to a field:
+//
+//	type Args struct {
+//		City  string `json:"city" jsonschema:"description=City to look up"`
+//		Units string `json:"units,omitempty" jsonschema:"enum=metric,enum=imperial"`
+//	}
+//
+// Supported jsonschema options are description, enum, default, minimum,
+// maximum, minLength, maxLength, pattern, format, required and optional;
+// escape commas in values as \,. Fields are required unless they are
+// pointers, have omitempty, or are marked optional.
+func GenerateSchema(v any) (json.RawMessage, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("cannot generate schema for nil")
+	}
+	schema, err := typeSchema(t, make(map[reflect.Type]bool))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(schema)
+}
+
+// typeSchema returns the schema for a type. Seen holds the structs being
+// expanded, so recursive types end in a plain object instead of looping.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case t == rawMessageType:
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		// Any JSON value
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes byte slices as base64 strings
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := typeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := typeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}, nil
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return structSchema(t, seen)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// structSchema returns the object schema for a struct
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]any, error) {
+	properties := make(map[string]any)
+	required := []string{}
+	if err := addFields(t, seen, properties, &required); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, nil
+}
+
+// addFields adds the properties of a struct's fields, flattening embedded
+// structs the way encoding/json does
+func addFields(t reflect.Type, seen map[reflect.Type]bool, properties map[string]any, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, jsonOpts, _ := strings.Cut(jsonTag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addFields(embedded, seen, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := typeSchema(field.Type, seen)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		isRequired := field.Type.Kind() != reflect.Pointer && !hasOption(jsonOpts, "omitempty")
+		for _, opt := range splitTag(field.Tag.Get("jsonschema")) {
+			key, value, hasValue := strings.Cut(opt, "=")
+			switch key {
+			case "required":
+				isRequired = true
+			case "optional":
+				isRequired = false
+			case "description", "pattern", "format":
+				schema[key] = value
+			case "enum":
+				enum, _ := schema["enum"].([]any)
+				schema["enum"] = append(enum, parseTagValue(value, schema["type"]))
+			case "default":
+				schema["default"] = parseTagValue(value, schema["type"])
+			case "minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems":
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil || !hasValue {
+					return fmt.Errorf("field %s: invalid %s %q", field.Name, key, value)
+				}
+				schema[key] = n
+			case "":
+			default:
+				return fmt.Errorf("field %s: unknown jsonschema option %q", field.Name, key)
+			}
+		}
+
+		properties[name] = schema
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// splitTag splits a jsonschema tag on commas not escaped as \,
+func splitTag(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	var opts []string
+	var current strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			opts = append(opts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(tag[i])
+		}
+	}
+	return append(opts, current.String())
+}
+
+// parseTagValue converts an enum or default value to the field's type
+func parseTagValue(value string, schemaType any) any {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// hasOption reports whether a comma-separated tag option list contains opt
+func hasOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaBase struct {
+	ID string `json:"id" jsonschema:"description=Record ID"`
+}
+
+type schemaNode struct {
+	Value    int          `json:"value"`
+	Children []schemaNode `json:"children,omitempty"`
+}
+
+type schemaArgs struct {
+	schemaBase
+	City     string            `json:"city" jsonschema:"description=City name\\, with country"`
+	Units    string            `json:"units,omitempty" jsonschema:"enum=metric,enum=imperial,default=metric"`
+	Days     int               `json:"days" jsonschema:"minimum=1,maximum=7,optional"`
+	Detailed *bool             `json:"detailed"`
+	Scale    float64           `json:"scale,omitempty" jsonschema:"required"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	When     time.Time         `json:"when,omitempty"`
+	Tree     *schemaNode       `json:"tree,omitempty"`
+	Extra    any               `json:"extra,omitempty"`
+	Skipped  string            `json:"-"`
+	NoTag    bool
+	private  string
+}
+
+func TestGenerateSchema(t *testing.T) {
+	t.Run("struct", func(t *testing.T) {
+		schema, err := GenerateSchema(schemaArgs{})
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"type": "object",
+			"properties": {
+				"id": {"type": "string", "description": "Record ID"},
+				"city": {"type": "string", "description": "City name, with country"},
+				"units": {"type": "string", "enum": ["metric", "imperial"], "default": "metric"},
+				"days": {"type": "integer", "minimum": 1, "maximum": 7},
+				"detailed": {"type": "boolean"},
+				"scale": {"type": "number"},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+				"when": {"type": "string", "format": "date-time"},
+				"tree": {
+					"type": "object",
+					"properties": {
+						"value": {"type": "integer"},
+						"children": {"type": "array", "items": {"type": "object"}}
+					},
+					"required": ["value"]
+				},
+				"extra": {},
+				"NoTag": {"type": "boolean"}
+			},
+			"required": ["id", "city", "scale", "NoTag"]
+		}`, string(schema))
+	})
+
+	t.Run("generated schema validates arguments", func(t *testing.T) {
+		schema, err := GenerateSchema(&schemaArgs{})
+		require.NoError(t, err)
+
+		valid := map[string]any{"id": "1", "city": "Oslo", "scale": 1.5, "NoTag": true, "units": "metric"}
+		assert.NoError(t, ValidateArgs(schema, valid))
+
+		invalid := map[string]any{"id": "1", "city": "Oslo", "scale": 1.5, "NoTag": true, "units": "kelvin"}
+		assert.Error(t, ValidateArgs(schema, invalid))
+		assert.Error(t, ValidateArgs(schema, map[string]any{"city": "Oslo"}))
+	})
+
+	t.Run("typed enum and default values", func(t *testing.T) {
+		schema, err := GenerateSchema(struct {
+			Level int  `json:"level" jsonschema:"enum=1,enum=2,default=1"`
+			Force bool `json:"force" jsonschema:"default=true"`
+		}{})
+		require.NoError(t, err)
+
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal(schema, &decoded))
+		properties := decoded["properties"].(map[string]any)
+		assert.Equal(t, []any{1.0, 2.0}, properties["level"].(map[string]any)["enum"])
+		assert.Equal(t, true, properties["force"].(map[string]any)["default"])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			value   any
+			wantErr string
+		}{
+			{"nil", nil, "cannot generate schema for nil"},
+			{"channel field", struct{ C chan int }{}, "field C: unsupported type chan int"},
+			{"map key", struct{ M map[int]string }{}, "unsupported map key type int"},
+			{"unknown option", struct {
+				S string `jsonschema:"colour=red"`
+			}{}, `unknown jsonschema option "colour"`},
+			{"invalid minimum", struct {
+				N int `jsonschema:"minimum=low"`
+			}{}, `invalid minimum "low"`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := GenerateSchema(tt.value)
+				assert.ErrorContains(t, err, tt.wantErr)
+			})
+		}
+	})
+}
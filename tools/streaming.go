@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// StreamingToolHandler produces a tool's result in parts, such as progress
+// updates or chunks of output, sending each on out as it becomes available.
+// The registry reads out until the handler returns; the handler must not
+// close it.
+type StreamingToolHandler func(ctx context.Context, args map[string]any, out chan<- ToolResult) error
+
+// NewProgressResult creates a partial result reporting progress as a
+// fraction between 0 and 1, with an optional status message
+func NewProgressResult(progress float64, message string) ToolResult {
+	return ToolResult{Progress: progress, Content: message, Partial: true}
+}
+
+// NewChunkResult creates a partial result carrying a chunk of the output
+func NewChunkResult(content string) ToolResult {
+	return ToolResult{Content: content, Partial: true}
+}
+
+// streamingHandler adapts a streaming handler to a ToolHandler returning
+// the combined result, passing each part to onPart as it arrives
+func streamingHandler(stream StreamingToolHandler, onPart func(ToolResult)) ToolHandler {
+	return func(ctx context.Context, args map[string]any) (ToolResult, error) {
+		parts := make(chan ToolResult)
+		errc := make(chan error, 1)
+		go func() {
+			defer close(parts)
+			defer func() {
+				if p := recover(); p != nil {
+					errc <- fmt.Errorf("panic: %v", p)
+				}
+			}()
+			errc <- stream(ctx, args, parts)
+		}()
+
+		var collected []ToolResult
+		for part := range parts {
+			part.Partial = true
+			collected = append(collected, part)
+			if onPart != nil {
+				onPart(part)
+			}
+		}
+		if err := <-errc; err != nil {
+			return ToolResult{}, err
+		}
+		return collectResults(collected), nil
+	}
+}
+
+// collectResults combines the parts of a streamed result: contents are
+// concatenated, except progress updates whose content is only a status
+// message, costs summed, and resources and metadata merged
+func collectResults(parts []ToolResult) ToolResult {
+	var result ToolResult
+	var content strings.Builder
+	for _, part := range parts {
+		if part.Progress == 0 {
+			content.WriteString(part.Content)
+		}
+		result.IsError = result.IsError || part.IsError
+		result.Cost += part.Cost
+		result.Resources = append(result.Resources, part.Resources...)
+		for k, v := range part.Metadata {
+			if result.Metadata == nil {
+				result.Metadata = make(map[string]any)
+			}
+			result.Metadata[k] = v
+		}
+	}
+	result.Content = content.String()
+	return result
+}
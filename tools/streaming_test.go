@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// indexTool streams progress and chunks of output
+func indexTool(fail error) Tool {
+	return New("index").
+		WithStreamHandler(func(ctx context.Context, args map[string]any, out chan<- ToolResult) error {
+			out <- NewProgressResult(0.5, "scanning")
+			out <- ToolResult{Content: "a.go\n", Cost: 1, Resources: []string{"a.go"}}
+			out <- ToolResult{Content: "b.go\n", Cost: 2, Resources: []string{"b.go"}, Metadata: map[string]any{"files": 2}}
+			out <- NewProgressResult(1, "done")
+			return fail
+		}).
+		Build()
+}
+
+func TestStreamingTools(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stream forwards parts then the result", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(indexTool(nil)))
+
+		results, err := registry.Stream(ctx, "index", map[string]any{})
+		require.NoError(t, err)
+
+		var all []ToolResult
+		for result := range results {
+			all = append(all, result)
+		}
+		require.Len(t, all, 5)
+		for _, part := range all[:4] {
+			assert.True(t, part.Partial)
+		}
+		assert.Equal(t, 0.5, all[0].Progress)
+		assert.Equal(t, "scanning", all[0].Content)
+		assert.Equal(t, "a.go\n", all[1].Content)
+
+		final := all[4]
+		assert.False(t, final.Partial)
+		assert.Equal(t, "a.go\nb.go\n", final.Content)
+	})
+
+	t.Run("call collects parts", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(indexTool(nil)))
+
+		result, err := registry.Call(ctx, "index", map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, ToolResult{
+			Content:   "a.go\nb.go\n",
+			Cost:      3,
+			Resources: []string{"a.go", "b.go"},
+			Metadata:  map[string]any{"files": 2},
+		}, result)
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(indexTool(errors.New("disk full"))))
+
+		result, err := registry.Call(ctx, "index", map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, NewErrorResult(errors.New("disk full")), result)
+	})
+
+	t.Run("handler panic", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(New("broken").
+			WithStreamHandler(func(ctx context.Context, args map[string]any, out chan<- ToolResult) error {
+				panic("boom")
+			}).
+			Build()))
+
+		result, err := registry.Call(ctx, "broken", map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, NewErrorResult(errors.New("panic: boom")), result)
+	})
+
+	t.Run("parts after a timeout are dropped", func(t *testing.T) {
+		registry := NewSimpleToolRegistry().WithDefaultTimeout(20 * time.Millisecond)
+		finished := make(chan struct{})
+		require.NoError(t, registry.Register(New("slow").
+			WithStreamHandler(func(ctx context.Context, args map[string]any, out chan<- ToolResult) error {
+				defer close(finished)
+				out <- NewProgressResult(0.1, "starting")
+				time.Sleep(100 * time.Millisecond)
+				out <- NewProgressResult(0.9, "too late")
+				return nil
+			}).
+			Build()))
+
+		results, err := registry.Stream(ctx, "slow", map[string]any{})
+		require.NoError(t, err)
+
+		var all []ToolResult
+		for result := range results {
+			all = append(all, result)
+		}
+		require.Len(t, all, 2)
+		assert.Equal(t, "starting", all[0].Content)
+		assert.True(t, all[1].IsError)
+		assert.Contains(t, all[1].Content, "timed out")
+
+		// The abandoned handler finishes without sending on the closed channel
+		<-finished
+	})
+
+	t.Run("registration needs a handler", func(t *testing.T) {
+		assert.EqualError(t, NewSimpleToolRegistry().Register(Tool{Name: "empty"}), "tool handler is required")
+	})
+}
@@ -4,9 +4,33 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/adimarco/hive/logging"
+)
+
+// Execution limits applied by a new SimpleToolRegistry
+const (
+	// DefaultTimeout bounds a tool call when neither the tool nor the
+	// registry sets a timeout
+	DefaultTimeout = 2 * time.Minute
+
+	// DefaultMaxContentLength is the size in bytes above which tool
+	// output is truncated
+	DefaultMaxContentLength = 100_000
 )
 
+// TagInternal marks tools the library's workflows use to talk to their own
+// LLM, such as a router reporting its choice. They are offered only to
+// requests naming them exactly, never given to agents by pattern or
+// namespace, and not published over MCP.
+const TagInternal = "internal"
+
 // Tool represents a callable tool with metadata and execution capabilities
 type Tool struct {
 	// Core metadata
@@ -19,8 +43,10 @@ type Tool struct {
 	Schema json.RawMessage `json:"schema"` // JSON Schema for input validation
 
 	// Execution
-	Handler ToolHandler `json:"-"`    // Not serialized
-	Cost    uint64      `json:"cost"` // Credits per use
+	Handler       ToolHandler          `json:"-"`       // Not serialized
+	StreamHandler StreamingToolHandler `json:"-"`       // Used instead of Handler for tools producing results in parts
+	Cost          uint64               `json:"cost"`    // Credits per use
+	Timeout       time.Duration        `json:"timeout"` // Overrides the registry's default timeout when set; negative disables it
 
 	// Lifecycle hooks (not serialized)
 	Initialize func(ctx context.Context) error `json:"-"`
@@ -37,6 +63,10 @@ type ToolResult struct {
 	Metadata  map[string]any `json:"metadata"`
 	Cost      uint64         `json:"cost"`      // Actual cost incurred
 	Resources []string       `json:"resources"` // Resources accessed
+
+	// Set on the parts of a streamed result
+	Partial  bool    `json:"partial,omitempty"`  // This is a part, not the final result
+	Progress float64 `json:"progress,omitempty"` // Fraction complete, for progress updates
 }
 
 // ToolRegistry manages tool registration, discovery, and execution
@@ -53,6 +83,16 @@ type ToolRegistry interface {
 	// Execution
 	Call(ctx context.Context, name string, args map[string]any) (ToolResult, error)
 	Stream(ctx context.Context, name string, args map[string]any) (<-chan ToolResult, error)
+
+	// Providers
+	RegisterProvider(namespace string, provider ToolProvider) error
+	UnregisterProvider(namespace string) error
+
+	// Middleware
+	Use(interceptors ...Interceptor)
+
+	// Lifecycle
+	Close() error
 }
 
 // ToolProvider defines a system that can provide tools
@@ -65,19 +105,58 @@ type ToolProvider interface {
 	Cleanup(ctx context.Context) error
 }
 
-// SimpleToolRegistry provides a basic thread-safe implementation of ToolRegistry
+// SimpleToolRegistry provides a basic thread-safe implementation of ToolRegistry.
+// Calls are bounded by a timeout, recover from handler panics, and truncate
+// oversized output.
 type SimpleToolRegistry struct {
-	tools map[string]Tool
-	mu    sync.RWMutex
+	tools            map[string]Tool
+	providers        map[string]registeredProvider
+	interceptors     []Interceptor
+	timeout          time.Duration
+	maxContentLength int
+	logger           logging.Logger
+	closed           bool
+	mu               sync.RWMutex
 }
 
 // NewSimpleToolRegistry creates a new SimpleToolRegistry
 func NewSimpleToolRegistry() *SimpleToolRegistry {
 	return &SimpleToolRegistry{
-		tools: make(map[string]Tool),
+		tools:            make(map[string]Tool),
+		providers:        make(map[string]registeredProvider),
+		timeout:          DefaultTimeout,
+		maxContentLength: DefaultMaxContentLength,
+		logger:           logging.GetLogger("tools"),
 	}
 }
 
+// WithDefaultTimeout sets the timeout for tools that do not set their own.
+// Zero disables the timeout.
+func (r *SimpleToolRegistry) WithDefaultTimeout(timeout time.Duration) *SimpleToolRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeout = timeout
+	return r
+}
+
+// WithMaxContentLength sets the size in bytes above which tool output is
+// truncated. Zero disables truncation.
+func (r *SimpleToolRegistry) WithMaxContentLength(n int) *SimpleToolRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxContentLength = n
+	return r
+}
+
+// Use implements ToolRegistry.Use. Interceptors run in the order added, the
+// first being the outermost, inside the registry's timeout and panic
+// recovery.
+func (r *SimpleToolRegistry) Use(interceptors ...Interceptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interceptors = append(r.interceptors, interceptors...)
+}
+
 // Register implements ToolRegistry.Register
 func (r *SimpleToolRegistry) Register(tool Tool) error {
 	key := tool.Name
@@ -86,7 +165,7 @@ func (r *SimpleToolRegistry) Register(tool Tool) error {
 	if tool.Name == "" {
 		return fmt.Errorf("tool name is required")
 	}
-	if tool.Handler == nil {
+	if tool.Handler == nil && tool.StreamHandler == nil {
 		return fmt.Errorf("tool handler is required")
 	}
 
@@ -100,6 +179,10 @@ func (r *SimpleToolRegistry) Register(tool Tool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.closed {
+		return fmt.Errorf("registry is closed")
+	}
+
 	// Check for duplicate registration
 	if _, exists := r.tools[key]; exists {
 		return fmt.Errorf("tool %q already registered", tool.Name)
@@ -134,12 +217,29 @@ func (r *SimpleToolRegistry) Get(name string) (Tool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	// Parse name and version
-	tool, exists := r.tools[name]
-	if !exists {
+	if tool, exists := r.tools[name]; exists {
+		return tool, nil
+	}
+	if strings.Contains(name, NamespaceSeparator) {
+		return Tool{}, fmt.Errorf("tool %q not found", name)
+	}
+
+	// An unqualified name finds a namespaced tool if only one has that name
+	var matches []string
+	for qualified := range r.tools {
+		if _, base := SplitName(qualified); base == name {
+			matches = append(matches, qualified)
+		}
+	}
+	switch len(matches) {
+	case 0:
 		return Tool{}, fmt.Errorf("tool %q not found", name)
+	case 1:
+		return r.tools[matches[0]], nil
+	default:
+		sort.Strings(matches)
+		return Tool{}, fmt.Errorf("tool %q is ambiguous, use one of: %s", name, strings.Join(matches, ", "))
 	}
-	return tool, nil
 }
 
 // List implements ToolRegistry.List
@@ -154,7 +254,9 @@ func (r *SimpleToolRegistry) List() []Tool {
 	return tools
 }
 
-// Search implements ToolRegistry.Search
+// Search implements ToolRegistry.Search. Query keys are "name",
+// "namespace", "category", "tag" and "text", which matches name or
+// description case-insensitively; a tool must match all of them.
 func (r *SimpleToolRegistry) Search(query map[string]any) []Tool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -180,16 +282,117 @@ func (r *SimpleToolRegistry) Call(ctx context.Context, name string, args map[str
 		return ToolResult{}, fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	// Execute the tool
-	result, err := tool.Handler(ctx, args)
-	if err != nil {
-		return NewErrorResult(err), nil
+	return r.execute(ctx, tool, args, nil), nil
+}
+
+// execute runs a tool's handler within its timeout, converting errors and
+// panics into error results and truncating oversized output. The parts of
+// a streamed result are passed to onPart, if set, and logged as progress.
+func (r *SimpleToolRegistry) execute(ctx context.Context, tool Tool, args map[string]any, onPart func(ToolResult)) ToolResult {
+	if tool.StreamHandler != nil {
+		tool.Handler = streamingHandler(tool.StreamHandler, func(part ToolResult) {
+			r.logger.Progress(ctx, "Tool progress", part.Progress*100, logging.WithData(map[string]interface{}{
+				"tool":   tool.Name,
+				"length": len(part.Content),
+			}))
+			if onPart != nil {
+				onPart(part)
+			}
+		})
 	}
 
-	return result, nil
+	r.mu.RLock()
+	timeout := r.timeout
+	maxContentLength := r.maxContentLength
+	handler := chain(tool, r.interceptors)
+	r.mu.RUnlock()
+	if tool.Timeout != 0 {
+		timeout = tool.Timeout
+	}
+
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// The handler runs in its own goroutine so a handler that ignores its
+	// context cannot block the caller past the timeout
+	done := make(chan ToolResult, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				r.logger.Error(ctx, "Tool panicked", logging.WithData(map[string]interface{}{
+					"tool":  tool.Name,
+					"panic": fmt.Sprint(p),
+					"stack": string(debug.Stack()),
+				}))
+				done <- NewErrorResult(fmt.Errorf("tool %q panicked: %v", tool.Name, p))
+			}
+		}()
+
+		result, err := handler(ctx, args)
+		if err != nil {
+			result = NewErrorResult(err)
+		}
+		done <- result
+	}()
+
+	var result ToolResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		if err := parent.Err(); err != nil {
+			return NewErrorResult(fmt.Errorf("tool %q cancelled: %w", tool.Name, err))
+		}
+		r.logger.Warning(ctx, "Tool timed out", logging.WithData(map[string]interface{}{
+			"tool":    tool.Name,
+			"timeout": timeout.String(),
+		}))
+		return NewErrorResult(fmt.Errorf("tool %q timed out after %s", tool.Name, timeout))
+	}
+
+	// Charge the tool's per-use cost unless the handler reported its own
+	if result.Cost == 0 {
+		result.Cost = tool.Cost
+	}
+
+	if maxContentLength > 0 && len(result.Content) > maxContentLength {
+		r.logger.Warning(ctx, "Tool result truncated", logging.WithData(map[string]interface{}{
+			"tool":   tool.Name,
+			"length": len(result.Content),
+			"limit":  maxContentLength,
+		}))
+		result = truncateResult(result, maxContentLength)
+	}
+
+	return result
+}
+
+// truncateResult cuts a result's content to at most n bytes, on a UTF-8
+// boundary, and appends a marker telling the model output is missing
+func truncateResult(result ToolResult, n int) ToolResult {
+	length := len(result.Content)
+	cut := n
+	for cut > 0 && !utf8.RuneStart(result.Content[cut]) {
+		cut--
+	}
+	result.Content = result.Content[:cut] +
+		fmt.Sprintf("\n\n[output truncated: showing %d of %d bytes]", cut, length)
+
+	metadata := make(map[string]any, len(result.Metadata)+2)
+	for k, v := range result.Metadata {
+		metadata[k] = v
+	}
+	metadata["truncated"] = true
+	metadata["original_length"] = length
+	result.Metadata = metadata
+	return result
 }
 
-// Stream implements ToolRegistry.Stream
+// Stream implements ToolRegistry.Stream. Results with Partial set are parts
+// of a streamed result; the last result is the complete one.
 func (r *SimpleToolRegistry) Stream(ctx context.Context, name string, args map[string]any) (<-chan ToolResult, error) {
 	resultChan := make(chan ToolResult)
 
@@ -203,19 +406,29 @@ func (r *SimpleToolRegistry) Stream(ctx context.Context, name string, args map[s
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	// Start streaming in a goroutine
+	// Start streaming in a goroutine. Streaming tools send their parts
+	// before the final result; other tools send only the result.
 	go func() {
 		defer close(resultChan)
 
-		result, err := tool.Handler(ctx, args)
-		if err != nil {
+		// A handler abandoned after a timeout may still produce parts,
+		// which must not be sent once the final result is out
+		var partsMu sync.Mutex
+		finished := false
+		result := r.execute(ctx, tool, args, func(part ToolResult) {
+			partsMu.Lock()
+			defer partsMu.Unlock()
+			if finished {
+				return
+			}
 			select {
 			case <-ctx.Done():
-				return
-			case resultChan <- NewErrorResult(err):
-				return
+			case resultChan <- part:
 			}
-		}
+		})
+		partsMu.Lock()
+		finished = true
+		partsMu.Unlock()
 
 		select {
 		case <-ctx.Done():
@@ -243,6 +456,16 @@ func matchesQuery(tool Tool, query map[string]any) bool {
 			if tool.Category != v.(string) {
 				return false
 			}
+		case "namespace":
+			if namespace, _ := SplitName(tool.Name); namespace != v.(string) {
+				return false
+			}
+		case "text":
+			text := strings.ToLower(v.(string))
+			if !strings.Contains(strings.ToLower(tool.Name), text) &&
+				!strings.Contains(strings.ToLower(tool.Description), text) {
+				return false
+			}
 		case "tag":
 			found := false
 			for _, tag := range tool.Tags {
@@ -259,6 +482,16 @@ func matchesQuery(tool Tool, query map[string]any) bool {
 	return true
 }
 
+// IsInternal reports whether the tool is tagged TagInternal
+func (t *Tool) IsInternal() bool {
+	for _, tag := range t.Tags {
+		if tag == TagInternal {
+			return true
+		}
+	}
+	return false
+}
+
 // GetArgsSchema implements ToolValidator.GetArgsSchema
 func (t *Tool) GetArgsSchema() json.RawMessage {
 	return t.Schema
@@ -318,6 +551,18 @@ func (b *ToolBuilder) WithSchema(schema json.RawMessage) *ToolBuilder {
 	return b
 }
 
+// WithStreamHandler sets a handler producing the result in parts
+func (b *ToolBuilder) WithStreamHandler(handler StreamingToolHandler) *ToolBuilder {
+	b.tool.StreamHandler = handler
+	return b
+}
+
+// WithTimeout sets how long a call may run before it is abandoned
+func (b *ToolBuilder) WithTimeout(timeout time.Duration) *ToolBuilder {
+	b.tool.Timeout = timeout
+	return b
+}
+
 // WithHandler sets the tool handler function
 func (b *ToolBuilder) WithHandler(handler func(context.Context, map[string]any) (string, error)) *ToolBuilder {
 	b.tool.Handler = func(ctx context.Context, args map[string]any) (ToolResult, error) {
@@ -341,77 +586,12 @@ func Register(tool Tool) error {
 	return registry.Register(tool)
 }
 
-// RegisterFunctionTool creates and registers a tool from a simple function
-// It examines the function signature to generate an appropriate schema
+// RegisterFunctionTool creates and registers a tool from a function.
+// See NewFunctionTool for the supported signatures.
 func RegisterFunctionTool(registry ToolRegistry, name, description string, handler interface{}) error {
-	// Convert the handler to a proper ToolHandler function
-	toolHandler := func(ctx context.Context, args map[string]interface{}) (ToolResult, error) {
-		// Handle different function signatures
-		switch h := handler.(type) {
-		case func() string:
-			// Simple no-arg function
-			return NewToolResult(h()), nil
-
-		case func(string) string:
-			// Function that takes a single string argument
-			input, _ := args["input"].(string)
-			return NewToolResult(h(input)), nil
-
-		case func(map[string]interface{}) string:
-			// Function that takes a map of arguments
-			return NewToolResult(h(args)), nil
-
-		case func(context.Context, map[string]interface{}) (string, error):
-			// Full handler with context and error
-			result, err := h(ctx, args)
-			if err != nil {
-				return NewErrorResult(err), nil
-			}
-			return NewToolResult(result), nil
-
-		case func() (string, error):
-			// No-arg function that can error
-			result, err := h()
-			if err != nil {
-				return NewErrorResult(err), nil
-			}
-			return NewToolResult(result), nil
-
-		default:
-			// Unsupported handler type
-			return ToolResult{}, fmt.Errorf("unsupported handler type: %T", handler)
-		}
-	}
-
-	// Generate schema based on function signature
-	var schema json.RawMessage
-	switch handler.(type) {
-	case func() string, func() (string, error):
-		// No parameters
-		schema = json.RawMessage(`{"type":"object","properties":{},"required":[]}`)
-
-	case func(string) string:
-		// Single string input
-		schema = json.RawMessage(`{
-			"type": "object",
-			"properties": {
-				"input": {"type": "string", "description": "Input text"}
-			},
-			"required": ["input"]
-		}`)
-
-	default:
-		// Default to accepting any object
-		schema = json.RawMessage(`{"type":"object","properties":{},"additionalProperties":true}`)
-	}
-
-	// Create and register the tool
-	tool := Tool{
-		Name:        name,
-		Description: description,
-		Schema:      schema,
-		Handler:     toolHandler,
+	tool, err := NewFunctionTool(name, description, handler)
+	if err != nil {
+		return err
 	}
-
 	return registry.Register(tool)
 }
@@ -188,6 +188,34 @@ func TestSimpleToolRegistry(t *testing.T) {
 		assert.Len(t, results, 2)
 	})
 
+	t.Run("namespaced tools", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(namedTool("github.create_issue", "Open an issue")))
+		require.NoError(t, registry.Register(namedTool("gitlab.create_issue", "Open an issue")))
+		require.NoError(t, registry.Register(namedTool("docs.search", "Search the documentation")))
+
+		// Unqualified names resolve when only one namespace has the tool
+		tool, err := registry.Get("search")
+		require.NoError(t, err)
+		assert.Equal(t, "docs.search", tool.Name)
+
+		_, err = registry.Get("create_issue")
+		assert.EqualError(t, err, `tool "create_issue" is ambiguous, use one of: github.create_issue, gitlab.create_issue`)
+		_, err = registry.Get("web.search")
+		assert.EqualError(t, err, `tool "web.search" not found`)
+
+		// Search by namespace and by text in the name or description
+		results := registry.Search(map[string]any{"namespace": "github"})
+		require.Len(t, results, 1)
+		assert.Equal(t, "github.create_issue", results[0].Name)
+
+		assert.Len(t, registry.Search(map[string]any{"text": "ISSUE"}), 2)
+		results = registry.Search(map[string]any{"text": "documentation"})
+		require.Len(t, results, 1)
+		assert.Equal(t, "docs.search", results[0].Name)
+		assert.Empty(t, registry.Search(map[string]any{"namespace": "docs", "text": "issue"}))
+	})
+
 	t.Run("lifecycle hooks", func(t *testing.T) {
 		registry := NewSimpleToolRegistry()
 
@@ -316,4 +344,140 @@ func TestToolResult(t *testing.T) {
 		assert.Equal(t, uint64(100), result.Cost)
 		assert.Equal(t, []string{"res1", "res2"}, result.Resources)
 	})
+	t.Run("tool cost charged per call", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(Tool{
+			Name: "flat",
+			Cost: 5,
+			Handler: func(ctx context.Context, args map[string]any) (ToolResult, error) {
+				return NewToolResult("ok"), nil
+			},
+		}))
+		require.NoError(t, registry.Register(Tool{
+			Name: "metered",
+			Cost: 5,
+			Handler: func(ctx context.Context, args map[string]any) (ToolResult, error) {
+				return ToolResult{Content: "ok", Cost: 12}, nil
+			},
+		}))
+
+		result, err := registry.Call(context.Background(), "flat", nil)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), result.Cost)
+
+		result, err = registry.Call(context.Background(), "metered", nil)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(12), result.Cost)
+	})
+}
+
+func TestSimpleToolRegistry_Limits(t *testing.T) {
+	ctx := context.Background()
+
+	// blocking never returns unless its context ends
+	blocking := func(ignoreContext bool) ToolHandler {
+		return func(ctx context.Context, args map[string]any) (ToolResult, error) {
+			if ignoreContext {
+				time.Sleep(time.Second)
+				return NewToolResult("too late"), nil
+			}
+			<-ctx.Done()
+			return ToolResult{}, ctx.Err()
+		}
+	}
+
+	t.Run("default timeout", func(t *testing.T) {
+		registry := NewSimpleToolRegistry().WithDefaultTimeout(20 * time.Millisecond)
+		require.NoError(t, registry.Register(Tool{Name: "slow", Handler: blocking(false)}))
+
+		result, err := registry.Call(ctx, "slow", map[string]any{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Equal(t, `tool "slow" timed out after 20ms`, result.Content)
+	})
+
+	t.Run("tool timeout overrides default", func(t *testing.T) {
+		registry := NewSimpleToolRegistry().WithDefaultTimeout(time.Hour)
+		tool := New("slow").WithTimeout(20 * time.Millisecond).Build()
+		tool.Handler = blocking(true)
+		require.NoError(t, registry.Register(tool))
+
+		start := time.Now()
+		result, err := registry.Call(ctx, "slow", map[string]any{})
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 500*time.Millisecond, "handlers ignoring their context are abandoned")
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content, "timed out")
+	})
+
+	t.Run("negative tool timeout disables default", func(t *testing.T) {
+		registry := NewSimpleToolRegistry().WithDefaultTimeout(20 * time.Millisecond)
+		tool := New("slow").WithTimeout(-1).Build()
+		tool.Handler = func(ctx context.Context, args map[string]any) (ToolResult, error) {
+			time.Sleep(50 * time.Millisecond)
+			return NewToolResult("done"), ctx.Err()
+		}
+		require.NoError(t, registry.Register(tool))
+
+		result, err := registry.Call(ctx, "slow", map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "done", result.Content)
+	})
+
+	t.Run("cancelled caller", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(Tool{Name: "slow", Handler: blocking(false)}))
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		result, err := registry.Call(cancelled, "slow", map[string]any{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Equal(t, `tool "slow" cancelled: context canceled`, result.Content)
+	})
+
+	t.Run("panic recovery", func(t *testing.T) {
+		registry := NewSimpleToolRegistry()
+		require.NoError(t, registry.Register(Tool{
+			Name: "broken",
+			Handler: func(ctx context.Context, args map[string]any) (ToolResult, error) {
+				var m map[string]int
+				m["boom"]++
+				return ToolResult{}, nil
+			},
+		}))
+
+		result, err := registry.Call(ctx, "broken", map[string]any{})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content, `tool "broken" panicked: assignment to entry in nil map`)
+
+		// Streaming goes through the same protection
+		results, err := registry.Stream(ctx, "broken", map[string]any{})
+		require.NoError(t, err)
+		streamed := <-results
+		assert.True(t, streamed.IsError)
+	})
+
+	t.Run("truncation", func(t *testing.T) {
+		registry := NewSimpleToolRegistry().WithMaxContentLength(10)
+		require.NoError(t, RegisterFunctionTool(registry, "echo", "Echo the input", func(s string) string { return s }))
+
+		result, err := registry.Call(ctx, "echo", map[string]any{"input": "short"})
+		require.NoError(t, err)
+		assert.Equal(t, "short", result.Content)
+		assert.Nil(t, result.Metadata)
+
+		// The cut backs up to the start of the multi-byte rune at byte 9
+		result, err = registry.Call(ctx, "echo", map[string]any{"input": "abcdefghié and more"})
+		require.NoError(t, err)
+		assert.Equal(t, "abcdefghi\n\n[output truncated: showing 9 of 20 bytes]", result.Content)
+		assert.Equal(t, true, result.Metadata["truncated"])
+		assert.Equal(t, 20, result.Metadata["original_length"])
+
+		registry.WithMaxContentLength(0)
+		result, err = registry.Call(ctx, "echo", map[string]any{"input": "abcdefghié and more"})
+		require.NoError(t, err)
+		assert.Equal(t, "abcdefghié and more", result.Content)
+	})
 }
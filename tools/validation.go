@@ -69,7 +69,7 @@ func ValidateTool(tool *Tool, registry ToolRegistry) error {
 	}
 
 	// Validate handler
-	if tool.Handler == nil {
+	if tool.Handler == nil && tool.StreamHandler == nil {
 		return fmt.Errorf("tool handler is required")
 	}
 
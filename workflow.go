@@ -0,0 +1,70 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adimarco/hive/llm"
+	"github.com/adimarco/hive/tools"
+)
+
+// workflow is implemented by agents that answer by running other agents,
+// such as chains, instead of prompting an LLM themselves
+type workflow interface {
+	// send runs the workflow on msg. Member agents without an LLM of their
+	// own use model.
+	send(ctx context.Context, model llm.AugmentedLLM, msg string) (string, error)
+}
+
+// sendTo sends msg to a member of a workflow in its default session, using
+// model if the member has no LLM of its own
+func sendTo(ctx context.Context, agent *Agent, model llm.AugmentedLLM, msg string) (string, error) {
+	if agent.llm != nil {
+		model = agent.llm
+	}
+	if model == nil && agent.workflow == nil {
+		return "", fmt.Errorf("agent %q has no LLM", agent.name)
+	}
+
+	ra, err := agent.runWith(ctx, model)
+	if err != nil {
+		return "", err
+	}
+	return ra.Send(msg)
+}
+
+// writeRequest writes the message sent to a workflow, for an agent that
+// reads other agents' answers to it
+func writeRequest(b *strings.Builder, msg string) {
+	fmt.Fprintf(b, "<request>\n%s\n</request>", msg)
+}
+
+// writeResponse writes an agent's answer, or the error it failed with,
+// after the request
+func writeResponse(b *strings.Builder, agent, output string, err error) {
+	if err != nil {
+		fmt.Fprintf(b, "\n\n<error agent=%q>\n%s\n</error>", agent, err)
+		return
+	}
+	fmt.Fprintf(b, "\n\n<response agent=%q>\n%s\n</response>", agent, output)
+}
+
+// registerWorkflowTool registers a tool a workflow's LLM calls, unless it
+// is already registered, such as by another workflow sharing the LLM. The
+// tool is tagged internal, so it is not given to other agents or published
+// over MCP.
+func registerWorkflowTool(registry tools.ToolRegistry, tool tools.Tool) error {
+	tool.Tags = append(tool.Tags, tools.TagInternal)
+	if _, err := registry.Get(tool.Name); err == nil {
+		return nil
+	}
+	if err := registry.Register(tool); err != nil {
+		// Another workflow may have registered it meanwhile
+		if _, getErr := registry.Get(tool.Name); getErr == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to register tool %q: %w", tool.Name, err)
+	}
+	return nil
+}